@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AbsenceCode describes a non-shift value that may be entered in a schedule cell.
+// Paid codes count DefaultHours of each working day towards the month's hours and are paid at
+// PayPercent of the rate.
+type AbsenceCode struct {
+	Code         string  `json:"code"`
+	Name         string  `json:"name"`
+	Paid         bool    `json:"paid"`
+	DefaultHours float64 `json:"default_hours"`
+	PayPercent   float64 `json:"pay_percent"`
+}
+
+func defaultAbsenceCodes() []AbsenceCode {
+	return []AbsenceCode{
+		{Code: "DW", Name: "Dzień wolny"},
+		{Code: "UW", Name: "Urlop wypoczynkowy", Paid: true, DefaultHours: 8, PayPercent: 100},
+		{Code: "UŻ", Name: "Urlop na żądanie", Paid: true, DefaultHours: 8, PayPercent: 100},
+		{Code: "L4", Name: "Zwolnienie lekarskie", Paid: true, DefaultHours: 8, PayPercent: 80},
+		{Code: "UO", Name: "Urlop okolicznościowy", Paid: true, DefaultHours: 8, PayPercent: 100},
+		{Code: "UB", Name: "Urlop bezpłatny"},
+		{Code: "NN", Name: "Nieobecność nieusprawiedliwiona"},
+	}
+}
+
+// absenceCodes returns the shop's configured registry or the default one
+func (shop Shop) absenceCodes() []AbsenceCode {
+	if len(shop.AbsenceCodes) == 0 {
+		return defaultAbsenceCodes()
+	}
+	return shop.AbsenceCodes
+}
+
+func (shop Shop) absenceCode(value string) (AbsenceCode, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for _, code := range shop.absenceCodes() {
+		if code.Code == value {
+			return code, true
+		}
+	}
+	return AbsenceCode{}, false
+}
+
+// isSickLeave reports whether a cell value is the code sick leave is written with
+func isSickLeave(value string) bool {
+	return strings.ToUpper(strings.TrimSpace(value)) == leaveTypeCodes["sick"]
+}
+
+func validateAbsenceCodes(codes []AbsenceCode) error {
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		if code.Code == "" {
+			return fmt.Errorf("absence code cannot be empty")
+		}
+		if code.Code != strings.ToUpper(strings.TrimSpace(code.Code)) {
+			return fmt.Errorf("absence code %q must be uppercase without spaces", code.Code)
+		}
+		if _, _, isShift := parseShiftTimes(code.Code); isShift {
+			return fmt.Errorf("absence code %q looks like a shift", code.Code)
+		}
+		if seen[code.Code] {
+			return fmt.Errorf("duplicate absence code %q", code.Code)
+		}
+		seen[code.Code] = true
+
+		if code.DefaultHours < 0 || code.DefaultHours > 24 {
+			return fmt.Errorf("default_hours of %s must be between 0 and 24", code.Code)
+		}
+		if code.PayPercent < 0 || code.PayPercent > 100 {
+			return fmt.Errorf("pay_percent of %s must be between 0 and 100", code.Code)
+		}
+	}
+
+	// Approved leave is written into the sheets with these codes
+	for _, required := range leaveTypeCodes {
+		if !seen[required] {
+			return fmt.Errorf("absence code %s is used by leave requests and cannot be removed", required)
+		}
+	}
+	if !seen["DW"] {
+		return fmt.Errorf("absence code DW cannot be removed")
+	}
+	return nil
+}
+
+// validateScheduleCells lists the cells that hold neither a shift, a shift template code nor a
+// registered absence code
+func validateScheduleCells(data [][]interface{}, shop Shop, month string, year int) []string {
+	var problems []string
+	forEachScheduleCell(data, shop.Employees, month, year, func(email string, date time.Time, value string) {
+		if _, _, _, isShift := shop.resolveShift(value, date); isShift {
+			return
+		}
+		if _, known := shop.absenceCode(value); known {
+			return
+		}
+		problems = append(problems, fmt.Sprintf("%s %s: %q is neither a shift (HH:MM-HH:MM), a shift template nor a known absence code",
+			date.Format(dateLayout), shop.Employees[email].Name, value))
+	})
+	return problems
+}
+
+// absencePay returns the paid hours and pay for one absence day. Only working days are paid,
+// the same days that leave is written on and deducted from the entitlement; a code on a
+// weekend or public holiday replaces no working time.
+func absencePay(shop Shop, employee Employee, absence ScheduledAbsence) (float64, float64) {
+	code, known := shop.absenceCode(absence.Code)
+	if !known || !code.Paid {
+		return 0, 0
+	}
+	date, err := time.ParseInLocation(dateLayout, absence.Date, time.Local)
+	if err != nil || !isWorkingDay(date) {
+		return 0, 0
+	}
+	rate := employeeRateOn(shop.ID, employee, date)
+	return code.DefaultHours, code.DefaultHours * rate * code.PayPercent / 100
+}
+
+func handleAbsenceCodes(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var shop Shop
+	var exists bool
+	switch session.Role {
+	case "employer":
+		shop, exists = getEmployerShop(session.UserInfo.Email, shopID)
+	case "employee":
+		_, shop, exists = findShopForEmployee(session.UserInfo.Email, shopID)
+	}
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"codes": shop.absenceCodes()})
+
+	case http.MethodPut:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can manage absence codes", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			Codes []AbsenceCode `json:"codes"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := validateAbsenceCodes(req.Codes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, code := range req.Codes {
+			if shop.hasShiftTemplate(code.Code) {
+				http.Error(w, fmt.Sprintf("absence code %s is already used by a shift template", code.Code), http.StatusBadRequest)
+				return
+			}
+		}
+
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][shopID]
+		shop.AbsenceCodes = req.Codes
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][shopID] = shop
+		employerShopsMutex.Unlock()
+
+		go saveShopsData()
+
+		log.Printf("Updated absence codes for shop %s by employer %s", shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"codes": shop.absenceCodes()})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAbsencePayOnWorkingDaysOnly(t *testing.T) {
+	const email = "anna@example.com"
+	week := func(code string) []ScheduledAbsence {
+		var absences []ScheduledAbsence
+		start, _ := time.ParseInLocation(dateLayout, "2025-03-03", time.Local) // Monday
+		for day := 0; day < 7; day++ {
+			date := start.AddDate(0, 0, day).Format(dateLayout)
+			absences = append(absences, ScheduledAbsence{EmployeeEmail: email, Date: date, Code: code})
+		}
+		return absences
+	}
+
+	tests := []struct {
+		name      string
+		absences  []ScheduledAbsence
+		wantHours float64
+		wantPay   float64
+	}{
+		{
+			name:      "a week of annual leave pays its five working days",
+			absences:  week("UW"),
+			wantHours: 40,
+			wantPay:   1600,
+		},
+		{
+			name:      "a week of sick leave pays 80% of its five working days",
+			absences:  week("L4"),
+			wantHours: 40,
+			wantPay:   1280,
+		},
+		{
+			name:      "sick leave on a Saturday is not paid",
+			absences:  []ScheduledAbsence{{EmployeeEmail: email, Date: "2025-03-08", Code: "L4"}},
+			wantHours: 0,
+			wantPay:   0,
+		},
+		{
+			name:      "leave on a public holiday is not paid",
+			absences:  []ScheduledAbsence{{EmployeeEmail: email, Date: "2025-05-01", Code: "UW"}},
+			wantHours: 0,
+			wantPay:   0,
+		},
+		{
+			name:      "a day off is unpaid",
+			absences:  []ScheduledAbsence{{EmployeeEmail: email, Date: "2025-03-05", Code: "DW"}},
+			wantHours: 0,
+			wantPay:   0,
+		},
+	}
+
+	shop := testPayrollShop()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var hours, pay float64
+			for _, absence := range test.absences {
+				dayHours, dayPay := absencePay(shop, shop.Employees[email], absence)
+				hours += dayHours
+				pay += dayPay
+			}
+			if math.Abs(hours-test.wantHours) > 0.005 {
+				t.Errorf("hours = %.2f, want %.2f", hours, test.wantHours)
+			}
+			if math.Abs(pay-test.wantPay) > 0.005 {
+				t.Errorf("pay = %.2f, want %.2f", pay, test.wantPay)
+			}
+		})
+	}
+}
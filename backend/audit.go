@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	auditLogFile        = "audit_log_data.json"
+	maxAuditEntriesKept = 1000
+)
+
+// CellChange is a single schedule cell rewritten by the backend
+type CellChange struct {
+	EmployeeEmail string `json:"employee_email"`
+	Column        string `json:"column,omitempty"` // header of cells not belonging to an employee
+	Date          string `json:"date"`
+	Before        string `json:"before"`
+	After         string `json:"after"`
+}
+
+func sortCellChanges(changes []CellChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Date != changes[j].Date {
+			return changes[i].Date < changes[j].Date
+		}
+		if changes[i].EmployeeEmail != changes[j].EmployeeEmail {
+			return changes[i].EmployeeEmail < changes[j].EmployeeEmail
+		}
+		return changes[i].Column < changes[j].Column
+	})
+}
+
+type AuditEntry struct {
+	ID        string       `json:"id"`
+	ShopID    string       `json:"shop_id"`
+	Action    string       `json:"action"`
+	Actor     string       `json:"actor"`
+	Message   string       `json:"message"`
+	RefID     string       `json:"ref_id,omitempty"`
+	Changes   []CellChange `json:"changes,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+var (
+	auditLog      = make(map[string][]AuditEntry) // shop_id -> entries, oldest first
+	auditLogMutex sync.RWMutex
+)
+
+func saveAuditLogData() error {
+	auditLogMutex.RLock()
+	defer auditLogMutex.RUnlock()
+	return writeJSONFileAtomic(auditLogFile, auditLog)
+}
+
+func loadAuditLogData() error {
+	auditLogMutex.Lock()
+	defer auditLogMutex.Unlock()
+	return readJSONFile(auditLogFile, &auditLog)
+}
+
+// recordAudit appends an entry to the shop's audit log, keeping only the most recent ones
+func recordAudit(entry AuditEntry) AuditEntry {
+	entry.ID = generateRandomString(12)
+	entry.CreatedAt = time.Now()
+
+	auditLogMutex.Lock()
+	list := append(auditLog[entry.ShopID], entry)
+	if len(list) > maxAuditEntriesKept {
+		list = list[len(list)-maxAuditEntriesKept:]
+	}
+	auditLog[entry.ShopID] = list
+	auditLogMutex.Unlock()
+
+	go saveAuditLogData()
+	return entry
+}
+
+func handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view the audit log", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+	if _, exists := getEmployerShop(session.UserInfo.Email, shopID); !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	action := r.URL.Query().Get("action")
+
+	auditLogMutex.RLock()
+	entries := make([]AuditEntry, 0, len(auditLog[shopID]))
+	for _, entry := range auditLog[shopID] {
+		if action == "" || entry.Action == action {
+			entries = append(entries, entry)
+		}
+	}
+	auditLogMutex.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+}
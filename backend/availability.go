@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const availabilityDataFile = "availability_data.json"
+
+const (
+	availabilityAvailable   = "available"
+	availabilityPreferred   = "preferred"
+	availabilityUnavailable = "unavailable"
+)
+
+// AvailabilityEntry is submitted by an employee either for a single date or for a
+// recurring weekday (0 = Sunday ... 6 = Saturday). Empty Start/End cover the whole day.
+type AvailabilityEntry struct {
+	ID        string    `json:"id"`
+	Date      string    `json:"date,omitempty"`
+	Weekday   *int      `json:"weekday,omitempty"`
+	Status    string    `json:"status"`
+	Start     string    `json:"start,omitempty"`
+	End       string    `json:"end,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DayAvailability struct {
+	Date    string              `json:"date"`
+	Entries []AvailabilityEntry `json:"entries"`
+}
+
+// ScheduleWarning is a non-blocking problem reported back when a schedule is saved
+type ScheduleWarning struct {
+	Type          string `json:"type"`
+	EmployeeEmail string `json:"employee_email"`
+	EmployeeName  string `json:"employee_name"`
+	Date          string `json:"date"`
+	Shift         string `json:"shift"`
+	Message       string `json:"message"`
+}
+
+var (
+	availability      = make(map[string][]AvailabilityEntry) // normalized employee email -> entries
+	availabilityMutex sync.RWMutex
+)
+
+func saveAvailabilityData() error {
+	availabilityMutex.RLock()
+	defer availabilityMutex.RUnlock()
+	return writeJSONFileAtomic(availabilityDataFile, availability)
+}
+
+func loadAvailabilityData() error {
+	availabilityMutex.Lock()
+	defer availabilityMutex.Unlock()
+	return readJSONFile(availabilityDataFile, &availability)
+}
+
+func (entry AvailabilityEntry) validate() error {
+	switch entry.Status {
+	case availabilityAvailable, availabilityPreferred, availabilityUnavailable:
+	default:
+		return fmt.Errorf("status must be one of available, preferred, unavailable")
+	}
+
+	if (entry.Date == "") == (entry.Weekday == nil) {
+		return fmt.Errorf("either date or weekday is required")
+	}
+	if entry.Date != "" {
+		if _, err := time.Parse(dateLayout, entry.Date); err != nil {
+			return fmt.Errorf("invalid date, expected YYYY-MM-DD")
+		}
+	}
+	if entry.Weekday != nil && (*entry.Weekday < 0 || *entry.Weekday > 6) {
+		return fmt.Errorf("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	if (entry.Start == "") != (entry.End == "") {
+		return fmt.Errorf("both start and end are required for a time window")
+	}
+	if entry.Start != "" {
+		if _, ok := parseClockMinutes(entry.Start); !ok {
+			return fmt.Errorf("invalid start time, expected HH:MM")
+		}
+		if _, ok := parseClockMinutes(entry.End); !ok {
+			return fmt.Errorf("invalid end time, expected HH:MM")
+		}
+	}
+	return nil
+}
+
+// window returns the time range the entry covers on the given day
+func (entry AvailabilityEntry) window(day time.Time) (time.Time, time.Time) {
+	if entry.Start == "" {
+		return day, day.AddDate(0, 0, 1)
+	}
+	start, _ := parseClockMinutes(entry.Start)
+	end, _ := parseClockMinutes(entry.End)
+	if end <= start {
+		end += 24 * 60
+	}
+	return day.Add(time.Duration(start) * time.Minute), day.Add(time.Duration(end) * time.Minute)
+}
+
+func (entry AvailabilityEntry) describe() string {
+	if entry.Start == "" {
+		return "whole day"
+	}
+	return entry.Start + "-" + entry.End
+}
+
+// availabilityOn returns the entries that apply to a day. Entries for the exact date
+// replace the recurring weekday entries.
+func availabilityOn(email string, day time.Time) []AvailabilityEntry {
+	availabilityMutex.RLock()
+	defer availabilityMutex.RUnlock()
+
+	var dated, recurring []AvailabilityEntry
+	date := day.Format(dateLayout)
+	for _, entry := range availability[normalizeEmail(email)] {
+		if entry.Date == date {
+			dated = append(dated, entry)
+		} else if entry.Weekday != nil && time.Weekday(*entry.Weekday) == day.Weekday() {
+			recurring = append(recurring, entry)
+		}
+	}
+	if len(dated) > 0 {
+		return dated
+	}
+	return recurring
+}
+
+// monthAvailability resolves every employee's availability for each day of the month
+func monthAvailability(employees map[string]Employee, month string, year int) map[string][]DayAvailability {
+	result := make(map[string][]DayAvailability)
+	monthNum := getMonthNumber(month)
+	days := getDaysInMonth(month, year)
+
+	for email := range employees {
+		var resolved []DayAvailability
+		for day := 1; day <= days; day++ {
+			date := time.Date(year, monthNum, day, 0, 0, 0, 0, time.Local)
+			if entries := availabilityOn(email, date); len(entries) > 0 {
+				resolved = append(resolved, DayAvailability{Date: date.Format(dateLayout), Entries: entries})
+			}
+		}
+		if len(resolved) > 0 {
+			result[email] = resolved
+		}
+	}
+	return result
+}
+
+// availabilityWarnings flags shifts that overlap a window the employee marked as unavailable
+func availabilityWarnings(shop Shop, shifts []ScheduledShift) []ScheduleWarning {
+	warnings := make([]ScheduleWarning, 0)
+	for _, shift := range shifts {
+		for _, entry := range availabilityOn(shift.EmployeeEmail, shift.Date) {
+			if entry.Status != availabilityUnavailable {
+				continue
+			}
+			start, end := entry.window(shift.Date)
+			if !shift.Start.Before(end) || !start.Before(shift.End) {
+				continue
+			}
+
+			message := fmt.Sprintf("Employee is unavailable (%s)", entry.describe())
+			if entry.Note != "" {
+				message += ": " + entry.Note
+			}
+			warnings = append(warnings, ScheduleWarning{
+				Type:          "unavailable",
+				EmployeeEmail: shift.EmployeeEmail,
+				EmployeeName:  shop.Employees[shift.EmployeeEmail].Name,
+				Date:          shift.Date.Format(dateLayout),
+				Shift:         shift.Value,
+				Message:       message,
+			})
+			break
+		}
+	}
+	return warnings
+}
+
+func handleAvailability(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	// Employers read the availability of their shop's employees for a month
+	if session.Role == "employer" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Only employees can submit availability", http.StatusForbidden)
+			return
+		}
+
+		shopID := r.URL.Query().Get("shop_id")
+		month := r.URL.Query().Get("month")
+		if shopID == "" || month == "" {
+			http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+			return
+		}
+		if getMonthNumber(month) == 0 {
+			http.Error(w, "Unknown month", http.StatusBadRequest)
+			return
+		}
+
+		shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"availability": monthAvailability(shop.Employees, month, parseYearParam(r)),
+		})
+		return
+	}
+
+	if session.Role != "employee" {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	email := normalizeEmail(session.UserInfo.Email)
+
+	switch r.Method {
+	case http.MethodGet:
+		availabilityMutex.RLock()
+		entries := append([]AvailabilityEntry{}, availability[email]...)
+		availabilityMutex.RUnlock()
+
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Date != entries[j].Date {
+				return entries[i].Date < entries[j].Date
+			}
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"availability": entries})
+
+	case http.MethodPost:
+		var entry AvailabilityEntry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		entry.Status = strings.ToLower(strings.TrimSpace(entry.Status))
+		entry.Note = strings.TrimSpace(entry.Note)
+		if err := entry.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entry.ID = generateRandomString(12)
+		entry.CreatedAt = time.Now()
+
+		availabilityMutex.Lock()
+		availability[email] = append(availability[email], entry)
+		availabilityMutex.Unlock()
+
+		go saveAvailabilityData()
+
+		log.Printf("Employee %s submitted %s availability", email, entry.Status)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "Availability ID is required", http.StatusBadRequest)
+			return
+		}
+
+		removed := false
+		availabilityMutex.Lock()
+		entries := availability[email]
+		for i, entry := range entries {
+			if entry.ID == id {
+				availability[email] = append(entries[:i], entries[i+1:]...)
+				removed = true
+				break
+			}
+		}
+		availabilityMutex.Unlock()
+
+		if !removed {
+			http.Error(w, "Availability entry not found", http.StatusNotFound)
+			return
+		}
+
+		go saveAvailabilityData()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Availability removed successfully"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxCellEditsPerRequest = 500
+
+// CellEdit sets one employee's cell for one day
+type CellEdit struct {
+	EmployeeEmail string `json:"employee_email"`
+	Date          string `json:"date"`
+	Value         string `json:"value"`
+}
+
+// cellPosition is a resolved cell of a month grid
+type cellPosition struct {
+	Row    int
+	Column int
+}
+
+// resolveCellEdits validates every edit against the shop and the current grid and returns
+// the cell each one targets. All problems are reported at once, so nothing is written when
+// any edit is invalid.
+func resolveCellEdits(data [][]interface{}, shop Shop, month string, year int, edits []CellEdit) ([]cellPosition, []string) {
+	positions := make([]cellPosition, len(edits))
+	seen := make(map[cellPosition]int)
+	var problems []string
+
+	for index, edit := range edits {
+		prefix := fmt.Sprintf("edit %d", index+1)
+		shopEmail, _, isEmployee := shopEmployee(shop, edit.EmployeeEmail)
+		if !isEmployee {
+			problems = append(problems, fmt.Sprintf("%s: %s is not an employee of this shop", prefix, edit.EmployeeEmail))
+			continue
+		}
+		date, err := time.ParseInLocation(dateLayout, edit.Date, time.Local)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid date %q, use YYYY-MM-DD", prefix, edit.Date))
+			continue
+		}
+		if date.Year() != year || date.Month() != getMonthNumber(month) {
+			problems = append(problems, fmt.Sprintf("%s: %s is outside %s %d", prefix, edit.Date, month, year))
+			continue
+		}
+
+		value := strings.TrimSpace(edit.Value)
+		if value != "" {
+			_, _, _, isShift := shop.resolveShift(value, date)
+			_, isAbsence := shop.absenceCode(value)
+			if !isShift && !isAbsence {
+				problems = append(problems, fmt.Sprintf("%s: %q is neither a shift (HH:MM-HH:MM), a shift template nor a known absence code", prefix, value))
+				continue
+			}
+		}
+
+		row, column, found := findScheduleCell(data, shop, shopEmail, date.Day())
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: the sheet has no cell for %s on %s", prefix, shopEmail, edit.Date))
+			continue
+		}
+		position := cellPosition{Row: row, Column: column}
+		if previous, duplicate := seen[position]; duplicate {
+			problems = append(problems, fmt.Sprintf("%s: targets the same cell as edit %d", prefix, previous+1))
+			continue
+		}
+		seen[position] = index
+		positions[index] = position
+	}
+	return positions, problems
+}
+
+// setGridCell writes a value into a grid, growing the row when the cell lies beyond it
+func setGridCell(data [][]interface{}, row, column int, value string) {
+	for len(data[row]) <= column {
+		data[row] = append(data[row], "")
+	}
+	data[row][column] = value
+}
+
+// patchMonthCells applies validated edits to a copy of the month's draft grid and
+// recalculates the totals
+func patchMonthCells(shop Shop, month string, year int, current [][]interface{}, edits []CellEdit, positions []cellPosition) ([][]interface{}, []ScheduledShift) {
+	updated := cloneGrid(current)
+	for index, edit := range edits {
+		position := positions[index]
+		setGridCell(updated, position.Row, position.Column, strings.TrimSpace(edit.Value))
+	}
+	updated = applyScheduleTotals(updated, shop, month, year)
+	return updated, parseMonthSchedule(updated, shop, month, year)
+}
+
+// handlePatchScheduleCells edits individual cells of a month's draft, like a full save but
+// without sending the whole grid. The request carries the revision the client last read from
+// GET /api/schedule; a stale revision is answered with 409 and the current draft.
+func handlePatchScheduleCells(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can update schedules", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID   string     `json:"shop_id"`
+		Month    string     `json:"month"`
+		Year     int        `json:"year"`
+		Revision string     `json:"revision"`
+		Edits    []CellEdit `json:"edits"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+	if getMonthNumber(req.Month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	if len(req.Edits) == 0 {
+		http.Error(w, "At least one edit is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Edits) > maxCellEditsPerRequest {
+		http.Error(w, fmt.Sprintf("At most %d edits are allowed per request", maxCellEditsPerRequest), http.StatusBadRequest)
+		return
+	}
+	if req.Revision == "" {
+		http.Error(w, "Revision is required, reload the schedule before saving", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if _, exists := shop.Spreadsheets[req.Year]; !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", req.Year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		log.Printf("Error getting spreadsheet service: %v", err)
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+	defer unlock()
+
+	if !requireMonthOpen(w, shop.ID, req.Month, req.Year) {
+		return
+	}
+
+	// Edits go to the month's draft, the grid GET /api/schedule returns to employers
+	draft, err := workingMonthDraft(r.Context(), spreadsheetService, shop, req.Month, req.Year, session.UserInfo.Email)
+	if err != nil {
+		log.Printf("Error loading draft for %s %d: %v", req.Month, req.Year, err)
+		http.Error(w, fmt.Sprintf("Failed to update schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if revision := scheduleRevision(draft.Data); revision != req.Revision {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    "The schedule was changed by someone else, reload it and apply your edits again",
+			"revision": revision,
+			"data":     draft.Data,
+		})
+		return
+	}
+
+	positions, problems := resolveCellEdits(draft.Data, shop, req.Month, req.Year, req.Edits)
+	if len(problems) > 0 {
+		http.Error(w, "Invalid edits:\n"+strings.Join(problems, "\n"), http.StatusBadRequest)
+		return
+	}
+
+	data, shifts := patchMonthCells(shop, req.Month, req.Year, draft.Data, req.Edits, positions)
+	changes := diffScheduleGrids(shop, req.Month, req.Year, draft.Data, data)
+	draft.Data = data
+	draft.UpdatedBy = session.UserInfo.Email
+	draft.UpdatedAt = time.Now()
+	storeScheduleDraft(draft)
+
+	log.Printf("Patched %d cells of the %s %d draft for shop %s by %s", len(changes), req.Month, req.Year, shop.ID, session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   "Draft saved",
+		"changes":   changes,
+		"data":      data,
+		"revision":  scheduleRevision(data),
+		"conflicts": findShiftConflicts(session.UserInfo.Email, shop, shifts),
+		"warnings":  append(availabilityWarnings(shop, shifts), checkCompliance(shop, req.Month, req.Year, shifts)...),
+		"draft":     true,
+	})
+}
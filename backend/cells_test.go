@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveCellEdits(t *testing.T) {
+	tests := []struct {
+		name         string
+		edits        []CellEdit
+		wantProblems []string
+	}{
+		{
+			name: "valid edits",
+			edits: []CellEdit{
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-01", Value: "08:00-16:00"},
+				{EmployeeEmail: "ewa@example.com", Date: "2025-03-01", Value: "UW"},
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-02", Value: ""},
+			},
+		},
+		{
+			name: "two edits of the same cell",
+			edits: []CellEdit{
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-01", Value: "08:00-16:00"},
+				{EmployeeEmail: "ANNA@example.com", Date: "2025-03-01", Value: "UW"},
+			},
+			wantProblems: []string{"edit 2: targets the same cell as edit 1"},
+		},
+		{
+			name: "a date outside the month",
+			edits: []CellEdit{
+				{EmployeeEmail: "anna@example.com", Date: "2025-04-01", Value: "08:00-16:00"},
+				{EmployeeEmail: "anna@example.com", Date: "2024-03-01", Value: "08:00-16:00"},
+			},
+			wantProblems: []string{"edit 1: 2025-04-01 is outside MARZEC 2025", "edit 2: 2024-03-01 is outside MARZEC 2025"},
+		},
+		{
+			name: "a day the sheet has no row for",
+			edits: []CellEdit{
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-03", Value: "08:00-16:00"},
+			},
+			wantProblems: []string{"edit 1: the sheet has no cell for anna@example.com on 2025-03-03"},
+		},
+		{
+			name: "every problem is reported",
+			edits: []CellEdit{
+				{EmployeeEmail: "jan@example.com", Date: "2025-03-01", Value: "08:00-16:00"},
+				{EmployeeEmail: "anna@example.com", Date: "1.03.2025", Value: "08:00-16:00"},
+				{EmployeeEmail: "ewa@example.com", Date: "2025-03-02", Value: "later"},
+			},
+			wantProblems: []string{
+				"edit 1: jan@example.com is not an employee of this shop",
+				`edit 2: invalid date "1.03.2025"`,
+				`edit 3: "later" is neither`,
+			},
+		},
+	}
+
+	shop := testScheduleShop()
+	grid := testGrid("", "", "", "")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, problems := resolveCellEdits(grid, shop, "MARZEC", 2025, test.edits)
+			if len(problems) != len(test.wantProblems) {
+				t.Fatalf("got problems %q, want %q", problems, test.wantProblems)
+			}
+			for i, problem := range problems {
+				if !strings.HasPrefix(problem, test.wantProblems[i]) {
+					t.Errorf("problem %d = %q, want it to start with %q", i, problem, test.wantProblems[i])
+				}
+			}
+		})
+	}
+}
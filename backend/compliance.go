@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Working time limits from the Polish Labour Code
+const (
+	maxShiftHours      = 12
+	minDailyRestHours  = 11
+	minWeeklyRestHours = 35
+)
+
+// personShifts returns a person's indexed shifts from every shop, leaving out the given
+// shop's month so it can be replaced by the shifts being checked
+func personShifts(personEmail, skipShopID, skipMonth string) []ScheduledShift {
+	shiftIndexMutex.RLock()
+	defer shiftIndexMutex.RUnlock()
+
+	key := normalizeEmail(personEmail)
+	var result []ScheduledShift
+	for shopID, months := range shiftIndex {
+		for month, shifts := range months {
+			if shopID == skipShopID && month == skipMonth {
+				continue
+			}
+			for _, shift := range shifts {
+				if shift.EmployeeEmail == key {
+					result = append(result, shift)
+				}
+			}
+		}
+	}
+	return result
+}
+
+func weekStart(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7 // Monday = 0
+	return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// checkCompliance validates a shop's month against shift length, daily rest and weekly rest
+// limits. Shifts the same people work in other shops are taken into account.
+func checkCompliance(shop Shop, month string, year int, shifts []ScheduledShift) []ScheduleWarning {
+	warnings := make([]ScheduleWarning, 0)
+	skipMonth := monthKey(year, getMonthNumber(month))
+
+	byEmployee := make(map[string][]ScheduledShift)
+	for _, shift := range shifts {
+		byEmployee[shift.EmployeeEmail] = append(byEmployee[shift.EmployeeEmail], shift)
+	}
+
+	for email, own := range byEmployee {
+		name := shop.Employees[email].Name
+		warn := func(warningType string, shift ScheduledShift, message string) {
+			warnings = append(warnings, ScheduleWarning{
+				Type:          warningType,
+				EmployeeEmail: email,
+				EmployeeName:  name,
+				Date:          shift.Date.Format(dateLayout),
+				Shift:         shift.Value,
+				Message:       message,
+			})
+		}
+
+		for _, shift := range own {
+			if shift.Hours() > maxShiftHours {
+				warn("shift_too_long", shift, fmt.Sprintf("%s is scheduled for %.1f hours on %s, more than %d", name, shift.Hours(), shift.Date.Format(dateLayout), maxShiftHours))
+			}
+		}
+
+		all := append(personShifts(email, shop.ID, skipMonth), own...)
+		sort.Slice(all, func(i, j int) bool { return all[i].Start.Before(all[j].Start) })
+
+		ownStarts := make(map[time.Time]bool, len(own))
+		for _, shift := range own {
+			ownStarts[shift.Start] = true
+		}
+		isOwn := func(shift ScheduledShift) bool { return ownStarts[shift.Start] }
+
+		for i := 1; i < len(all); i++ {
+			previous, next := all[i-1], all[i]
+			if !isOwn(next) && !isOwn(previous) {
+				continue
+			}
+			rest := next.Start.Sub(previous.End)
+			// Overlaps are reported as conflicts; a split shift on the same day is not a rest period
+			if rest <= 0 || previous.Date.Equal(next.Date) {
+				continue
+			}
+			if rest < minDailyRestHours*time.Hour {
+				warn("daily_rest", next, fmt.Sprintf("%s has only %.1f hours of rest before the shift on %s (minimum %d)", name, rest.Hours(), next.Date.Format(dateLayout), minDailyRestHours))
+			}
+		}
+
+		// Weekly rest: the longest break inside each Monday-Sunday week touched by this month
+		checked := make(map[time.Time]bool)
+		for _, shift := range own {
+			week := weekStart(shift.Date)
+			if checked[week] {
+				continue
+			}
+			checked[week] = true
+
+			weekEnd := week.AddDate(0, 0, 7)
+			longest := time.Duration(0)
+			cursor := week
+			for _, other := range all {
+				if !other.End.After(week) || !other.Start.Before(weekEnd) {
+					continue
+				}
+				if gap := other.Start.Sub(cursor); gap > longest {
+					longest = gap
+				}
+				if other.End.After(cursor) {
+					cursor = other.End
+				}
+			}
+			if gap := weekEnd.Sub(cursor); gap > longest {
+				longest = gap
+			}
+
+			if longest < minWeeklyRestHours*time.Hour {
+				warnings = append(warnings, ScheduleWarning{
+					Type:          "weekly_rest",
+					EmployeeEmail: email,
+					EmployeeName:  name,
+					Date:          week.Format(dateLayout),
+					Message:       fmt.Sprintf("%s has no %d-hour rest in the week starting %s (longest %.1f hours)", name, minWeeklyRestHours, week.Format(dateLayout), longest.Hours()),
+				})
+			}
+		}
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Date != warnings[j].Date {
+			return warnings[i].Date < warnings[j].Date
+		}
+		return warnings[i].EmployeeName < warnings[j].EmployeeName
+	})
+	return warnings
+}
+
+// newComplianceWarnings returns the warnings of after that were not already present in before
+func newComplianceWarnings(before, after []ScheduleWarning) []ScheduleWarning {
+	existing := make(map[string]bool, len(before))
+	for _, warning := range before {
+		existing[warning.Type+"|"+warning.EmployeeEmail+"|"+warning.Date] = true
+	}
+
+	result := make([]ScheduleWarning, 0)
+	for _, warning := range after {
+		if !existing[warning.Type+"|"+warning.EmployeeEmail+"|"+warning.Date] {
+			result = append(result, warning)
+		}
+	}
+	return result
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	shiftIndexFile   = "shift_index_data.json"
+	absenceIndexFile = "absence_index_data.json"
+)
+
+// ScheduledAbsence is a non-shift code (DW, UW, L4...) found in a month sheet cell
+type ScheduledAbsence struct {
+	EmployeeEmail string `json:"employee_email"`
+	Date          string `json:"date"`
+	Code          string `json:"code"`
+}
+
+// ShiftConflict reports a shift that overlaps with the same person's shift in another shop.
+// Details of the other shop are only filled in when it belongs to the same employer.
+type ShiftConflict struct {
+	EmployeeEmail string    `json:"employee_email"`
+	EmployeeName  string    `json:"employee_name"`
+	Date          string    `json:"date"`
+	Shift         string    `json:"shift"`
+	OverlapStart  time.Time `json:"overlap_start"`
+	OverlapEnd    time.Time `json:"overlap_end"`
+	SameEmployer  bool      `json:"same_employer"`
+	OtherShopID   string    `json:"other_shop_id,omitempty"`
+	OtherShopName string    `json:"other_shop_name,omitempty"`
+	OtherShift    string    `json:"other_shift,omitempty"`
+}
+
+var (
+	// Shifts of every shop as last read or written, so schedules owned by
+	// different employers can be compared without access to each other's sheets.
+	shiftIndex      = make(map[string]map[string][]ScheduledShift) // shop_id -> YYYY-MM -> shifts
+	shiftIndexMutex sync.RWMutex
+
+	absenceIndex      = make(map[string]map[string][]ScheduledAbsence) // shop_id -> YYYY-MM -> absences
+	absenceIndexMutex sync.RWMutex
+)
+
+func monthKey(year int, month time.Month) string {
+	return fmt.Sprintf("%d-%02d", year, int(month))
+}
+
+func saveShiftIndexData() error {
+	shiftIndexMutex.RLock()
+	defer shiftIndexMutex.RUnlock()
+	return writeJSONFileAtomic(shiftIndexFile, shiftIndex)
+}
+
+func loadShiftIndexData() error {
+	shiftIndexMutex.Lock()
+	defer shiftIndexMutex.Unlock()
+	return readJSONFile(shiftIndexFile, &shiftIndex)
+}
+
+func saveAbsenceIndexData() error {
+	absenceIndexMutex.RLock()
+	defer absenceIndexMutex.RUnlock()
+	return writeJSONFileAtomic(absenceIndexFile, absenceIndex)
+}
+
+func loadAbsenceIndexData() error {
+	absenceIndexMutex.Lock()
+	defer absenceIndexMutex.Unlock()
+	return readJSONFile(absenceIndexFile, &absenceIndex)
+}
+
+// parseMonthAbsences extracts the cells holding a code from the shop's absence registry
+func parseMonthAbsences(data [][]interface{}, shop Shop, month string, year int) []ScheduledAbsence {
+	var absences []ScheduledAbsence
+	forEachScheduleCell(data, shop.Employees, month, year, func(email string, date time.Time, value string) {
+		code, known := shop.absenceCode(value)
+		if !known {
+			return
+		}
+		absences = append(absences, ScheduledAbsence{
+			EmployeeEmail: email,
+			Date:          date.Format(dateLayout),
+			Code:          code.Code,
+		})
+	})
+	return absences
+}
+
+// indexMonthSchedule parses a month grid, refreshes both indexes and returns the shifts
+func indexMonthSchedule(shop Shop, month string, year int, data [][]interface{}) []ScheduledShift {
+	shifts := parseMonthSchedule(data, shop, month, year)
+	indexMonthShifts(shop.ID, month, year, shifts)
+
+	absenceIndexMutex.Lock()
+	if absenceIndex[shop.ID] == nil {
+		absenceIndex[shop.ID] = make(map[string][]ScheduledAbsence)
+	}
+	absences := parseMonthAbsences(data, shop, month, year)
+	for i := range absences {
+		absences[i].EmployeeEmail = normalizeEmail(absences[i].EmployeeEmail)
+	}
+	absenceIndex[shop.ID][monthKey(year, getMonthNumber(month))] = absences
+	absenceIndexMutex.Unlock()
+
+	go saveAbsenceIndexData()
+	return shifts
+}
+
+// indexedAbsences returns the absences of a person in a shop for the given year
+func indexedAbsences(shopID, personEmail string, year int) []ScheduledAbsence {
+	absenceIndexMutex.RLock()
+	defer absenceIndexMutex.RUnlock()
+
+	var result []ScheduledAbsence
+	prefix := fmt.Sprintf("%d-", year)
+	key := normalizeEmail(personEmail)
+	for month, absences := range absenceIndex[shopID] {
+		if !strings.HasPrefix(month, prefix) {
+			continue
+		}
+		for _, absence := range absences {
+			if absence.EmployeeEmail == key {
+				result = append(result, absence)
+			}
+		}
+	}
+	return result
+}
+
+// indexMonthShifts replaces the indexed shifts of a shop's month with freshly parsed ones
+func indexMonthShifts(shopID, month string, year int, shifts []ScheduledShift) {
+	indexed := make([]ScheduledShift, len(shifts))
+	for i, shift := range shifts {
+		shift.EmployeeEmail = normalizeEmail(shift.EmployeeEmail)
+		indexed[i] = shift
+	}
+
+	shiftIndexMutex.Lock()
+	if shiftIndex[shopID] == nil {
+		shiftIndex[shopID] = make(map[string][]ScheduledShift)
+	}
+	shiftIndex[shopID][monthKey(year, getMonthNumber(month))] = indexed
+	shiftIndexMutex.Unlock()
+
+	go saveShiftIndexData()
+}
+
+func shopOwner(shopID string) (string, Shop, bool) {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	for employer, shops := range employerShops {
+		if shop, exists := shops[shopID]; exists {
+			return employer, shop, true
+		}
+	}
+	return "", Shop{}, false
+}
+
+// findShiftConflicts compares a shop's shifts with the indexed shifts of every other shop
+func findShiftConflicts(viewerEmail string, shop Shop, shifts []ScheduledShift) []ShiftConflict {
+	conflicts := make([]ShiftConflict, 0)
+	if len(shifts) == 0 {
+		return conflicts
+	}
+
+	type otherShift struct {
+		shopID string
+		shift  ScheduledShift
+	}
+	byEmployee := make(map[string][]otherShift)
+
+	shiftIndexMutex.RLock()
+	for shopID, months := range shiftIndex {
+		if shopID == shop.ID {
+			continue
+		}
+		for _, indexed := range months {
+			for _, shift := range indexed {
+				byEmployee[shift.EmployeeEmail] = append(byEmployee[shift.EmployeeEmail], otherShift{shopID: shopID, shift: shift})
+			}
+		}
+	}
+	shiftIndexMutex.RUnlock()
+
+	for _, shift := range shifts {
+		for _, other := range byEmployee[normalizeEmail(shift.EmployeeEmail)] {
+			if !shift.Start.Before(other.shift.End) || !other.shift.Start.Before(shift.End) {
+				continue
+			}
+
+			overlapStart, overlapEnd := shift.Start, shift.End
+			if other.shift.Start.After(overlapStart) {
+				overlapStart = other.shift.Start
+			}
+			if other.shift.End.Before(overlapEnd) {
+				overlapEnd = other.shift.End
+			}
+
+			conflict := ShiftConflict{
+				EmployeeEmail: shift.EmployeeEmail,
+				EmployeeName:  shop.Employees[shift.EmployeeEmail].Name,
+				Date:          shift.Date.Format(dateLayout),
+				Shift:         shift.Value,
+				OverlapStart:  overlapStart,
+				OverlapEnd:    overlapEnd,
+			}
+			if owner, otherShop, exists := shopOwner(other.shopID); exists && owner == viewerEmail {
+				conflict.SameEmployer = true
+				conflict.OtherShopID = otherShop.ID
+				conflict.OtherShopName = otherShop.Name
+				conflict.OtherShift = other.shift.Value
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if !conflicts[i].OverlapStart.Equal(conflicts[j].OverlapStart) {
+			return conflicts[i].OverlapStart.Before(conflicts[j].OverlapStart)
+		}
+		return conflicts[i].EmployeeEmail < conflicts[j].EmployeeEmail
+	})
+	return conflicts
+}
+
+func handleScheduleConflicts(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can check schedule conflicts", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+	if err != nil {
+		log.Printf("Error reading schedule data for conflicts: %v", err)
+		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
+		return
+	}
+
+	shifts := indexMonthSchedule(shop, month, year, data)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shop_id":   shop.ID,
+		"year":      year,
+		"month":     month,
+		"conflicts": findShiftConflicts(session.UserInfo.Email, shop, shifts),
+	})
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Contract types of an employment
+const (
+	contractEmployment = "umowa_o_prace"  // employment contract, full ZUS
+	contractCivil      = "umowa_zlecenie" // civil-law contract, sickness insurance is voluntary
+	contractStudent    = "student"        // civil-law contract of a student under 26, no ZUS or PIT
+)
+
+// EmploymentContract is the contract of one employment with the flags that change its
+// contributions and tax
+type EmploymentContract struct {
+	Type               string `json:"type"`                 // umowa_o_prace (default) | umowa_zlecenie | student
+	PIT2               bool   `json:"pit2"`                 // the monthly tax-reducing amount is applied
+	YouthRelief        bool   `json:"youth_relief"`         // under 26, income exempt from PIT
+	ElevatedCosts      bool   `json:"elevated_costs"`       // commuting from another town, umowa o pracę only
+	VoluntarySickness  bool   `json:"voluntary_sickness"`   // umowa zlecenie only
+	OtherEmploymentZUS bool   `json:"other_employment_zus"` // umowa zlecenie only; social ZUS is paid from another employment
+}
+
+// PayrollRates holds the employee-side contribution and tax parameters valid from January of
+// Year. Percentages are expressed as whole numbers, amounts are monthly.
+type PayrollRates struct {
+	Year                int     `json:"year"`
+	PensionPercent      float64 `json:"pension_percent"`
+	DisabilityPercent   float64 `json:"disability_percent"`
+	SicknessPercent     float64 `json:"sickness_percent"`
+	HealthPercent       float64 `json:"health_percent"`
+	PITPercent          float64 `json:"pit_percent"`
+	MonthlyTaxReduction float64 `json:"monthly_tax_reduction"`
+	StandardCosts       float64 `json:"standard_costs"`
+	ElevatedCosts       float64 `json:"elevated_costs"`
+	CivilCostsPercent   float64 `json:"civil_costs_percent"`
+}
+
+// payrollRateTables lists the rates by the year they took effect. The annual cap of pension
+// and disability contributions and the second PIT bracket need the year-to-date income and
+// are not applied to monthly amounts.
+var payrollRateTables = []PayrollRates{
+	{Year: 2024, PensionPercent: 9.76, DisabilityPercent: 1.5, SicknessPercent: 2.45, HealthPercent: 9, PITPercent: 12, MonthlyTaxReduction: 300, StandardCosts: 250, ElevatedCosts: 300, CivilCostsPercent: 20},
+	{Year: 2025, PensionPercent: 9.76, DisabilityPercent: 1.5, SicknessPercent: 2.45, HealthPercent: 9, PITPercent: 12, MonthlyTaxReduction: 300, StandardCosts: 250, ElevatedCosts: 300, CivilCostsPercent: 20},
+	{Year: 2026, PensionPercent: 9.76, DisabilityPercent: 1.5, SicknessPercent: 2.45, HealthPercent: 9, PITPercent: 12, MonthlyTaxReduction: 300, StandardCosts: 250, ElevatedCosts: 300, CivilCostsPercent: 20},
+}
+
+// NetPay splits a month's gross pay into the employee's contributions, tax and net pay
+type NetPay struct {
+	Gross                  float64 `json:"gross"`
+	PensionContribution    float64 `json:"pension_contribution"`
+	DisabilityContribution float64 `json:"disability_contribution"`
+	SicknessContribution   float64 `json:"sickness_contribution"`
+	SocialContributions    float64 `json:"social_contributions"` // employee ZUS in total
+	HealthContribution     float64 `json:"health_contribution"`
+	TaxCosts               float64 `json:"tax_costs"`
+	TaxBase                float64 `json:"tax_base"`
+	PITAdvance             float64 `json:"pit_advance"`
+	Net                    float64 `json:"net"`
+}
+
+func (n *NetPay) add(other NetPay) {
+	n.Gross = roundMoney(n.Gross + other.Gross)
+	n.PensionContribution = roundMoney(n.PensionContribution + other.PensionContribution)
+	n.DisabilityContribution = roundMoney(n.DisabilityContribution + other.DisabilityContribution)
+	n.SicknessContribution = roundMoney(n.SicknessContribution + other.SicknessContribution)
+	n.SocialContributions = roundMoney(n.SocialContributions + other.SocialContributions)
+	n.HealthContribution = roundMoney(n.HealthContribution + other.HealthContribution)
+	n.TaxCosts = roundMoney(n.TaxCosts + other.TaxCosts)
+	n.TaxBase = roundMoney(n.TaxBase + other.TaxBase)
+	n.PITAdvance = roundMoney(n.PITAdvance + other.PITAdvance)
+	n.Net = roundMoney(n.Net + other.Net)
+}
+
+func (contract EmploymentContract) contractType() string {
+	if contract.Type == "" {
+		return contractEmployment
+	}
+	return contract.Type
+}
+
+func (contract EmploymentContract) validate() error {
+	switch contract.contractType() {
+	case contractEmployment:
+		if contract.VoluntarySickness || contract.OtherEmploymentZUS {
+			return fmt.Errorf("voluntary sickness and other employment ZUS apply to umowa zlecenie only")
+		}
+	case contractCivil:
+		if contract.ElevatedCosts {
+			return fmt.Errorf("elevated costs apply to umowa o pracę only")
+		}
+	case contractStudent:
+		if contract.ElevatedCosts || contract.VoluntarySickness || contract.OtherEmploymentZUS {
+			return fmt.Errorf("a student under 26 pays neither ZUS nor PIT, flags do not apply")
+		}
+	default:
+		return fmt.Errorf("unknown contract type %q, use %s, %s or %s", contract.Type, contractEmployment, contractCivil, contractStudent)
+	}
+	return nil
+}
+
+// payrollRatesFor returns the table in force in the given year; years before the first table
+// use the first one
+func payrollRatesFor(year int) PayrollRates {
+	tables := append([]PayrollRates(nil), payrollRateTables...)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Year < tables[j].Year })
+
+	rates := tables[0]
+	for _, table := range tables {
+		if table.Year <= year {
+			rates = table
+		}
+	}
+	return rates
+}
+
+// calculateNetPay computes the employee's side of a month's gross pay. sickPay is the part of
+// gross paid for sick leave, which is exempt from social contributions but still carries the
+// health contribution and tax. The tax base and the PIT advance are rounded to full złoty, as
+// on a payslip.
+func calculateNetPay(contract EmploymentContract, gross, sickPay float64, rates PayrollRates) NetPay {
+	pay := NetPay{Gross: roundMoney(gross)}
+	if pay.Gross <= 0 {
+		return pay
+	}
+
+	contractType := contract.contractType()
+	if contractType == contractStudent {
+		pay.Net = pay.Gross
+		return pay
+	}
+
+	socialBase := math.Max(0, pay.Gross-roundMoney(sickPay))
+	socialInsured := contractType == contractEmployment || !contract.OtherEmploymentZUS
+	if socialInsured {
+		pay.PensionContribution = roundMoney(socialBase * rates.PensionPercent / 100)
+		pay.DisabilityContribution = roundMoney(socialBase * rates.DisabilityPercent / 100)
+		if contractType == contractEmployment || contract.VoluntarySickness {
+			pay.SicknessContribution = roundMoney(socialBase * rates.SicknessPercent / 100)
+		}
+	}
+	pay.SocialContributions = roundMoney(pay.PensionContribution + pay.DisabilityContribution + pay.SicknessContribution)
+
+	income := pay.Gross - pay.SocialContributions
+	pay.HealthContribution = roundMoney(income * rates.HealthPercent / 100)
+
+	if contractType == contractEmployment {
+		pay.TaxCosts = rates.StandardCosts
+		if contract.ElevatedCosts {
+			pay.TaxCosts = rates.ElevatedCosts
+		}
+		pay.TaxCosts = math.Min(pay.TaxCosts, income)
+	} else {
+		pay.TaxCosts = roundMoney(income * rates.CivilCostsPercent / 100)
+	}
+	pay.TaxBase = math.Max(0, math.Round(income-pay.TaxCosts))
+
+	if !contract.YouthRelief {
+		advance := pay.TaxBase * rates.PITPercent / 100
+		if contract.PIT2 {
+			advance -= rates.MonthlyTaxReduction
+		}
+		pay.PITAdvance = math.Max(0, math.Round(advance))
+	}
+
+	pay.Net = roundMoney(pay.Gross - pay.SocialContributions - pay.HealthContribution - pay.PITAdvance)
+	return pay
+}
+
+// scaled multiplies every amount of the pay by factor
+func (n NetPay) scaled(factor float64) NetPay {
+	return NetPay{
+		Gross:                  roundMoney(n.Gross * factor),
+		PensionContribution:    roundMoney(n.PensionContribution * factor),
+		DisabilityContribution: roundMoney(n.DisabilityContribution * factor),
+		SicknessContribution:   roundMoney(n.SicknessContribution * factor),
+		SocialContributions:    roundMoney(n.SocialContributions * factor),
+		HealthContribution:     roundMoney(n.HealthContribution * factor),
+		TaxCosts:               roundMoney(n.TaxCosts * factor),
+		TaxBase:                roundMoney(n.TaxBase * factor),
+		PITAdvance:             roundMoney(n.PITAdvance * factor),
+		Net:                    roundMoney(n.Net * factor),
+	}
+}
+
+// splitNetPay divides the employee's side of a combined gross between its parts in proportion
+// to their gross pay. The last part takes the rounding remainder, so the parts add up to pay.
+func splitNetPay(pay NetPay, grosses []float64) []NetPay {
+	parts := make([]NetPay, len(grosses))
+	rest := pay
+	for i, gross := range grosses {
+		if i == len(grosses)-1 {
+			parts[i] = rest
+			break
+		}
+		share := 0.0
+		if pay.Gross > 0 {
+			share = gross / pay.Gross
+		}
+		parts[i] = pay.scaled(share)
+		parts[i].Gross = roundMoney(gross)
+		rest.add(parts[i].scaled(-1))
+	}
+	return parts
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateNetPay(t *testing.T) {
+	employment := EmploymentContract{Type: contractEmployment, PIT2: true}
+	tests := []struct {
+		name     string
+		contract EmploymentContract
+		gross    float64
+		sickPay  float64
+		want     NetPay
+	}{
+		{
+			name:     "minimum wage 2025 with PIT-2",
+			contract: employment,
+			gross:    4666,
+			want:     NetPay{SocialContributions: 639.71, HealthContribution: 362.37, TaxBase: 3776, PITAdvance: 153, Net: 3510.92},
+		},
+		{
+			name:     "minimum wage 2025 without PIT-2",
+			contract: EmploymentContract{Type: contractEmployment},
+			gross:    4666,
+			want:     NetPay{SocialContributions: 639.71, HealthContribution: 362.37, TaxBase: 3776, PITAdvance: 453, Net: 3210.92},
+		},
+		{
+			name:     "5000 with PIT-2",
+			contract: employment,
+			gross:    5000,
+			want:     NetPay{SocialContributions: 685.5, HealthContribution: 388.31, TaxBase: 4065, PITAdvance: 188, Net: 3738.19},
+		},
+		{
+			name:     "youth relief pays no PIT",
+			contract: EmploymentContract{Type: contractEmployment, PIT2: true, YouthRelief: true},
+			gross:    4666,
+			want:     NetPay{SocialContributions: 639.71, HealthContribution: 362.37, TaxBase: 3776, Net: 3663.92},
+		},
+		{
+			name:     "sick pay is left out of social contributions",
+			contract: employment,
+			gross:    4666,
+			sickPay:  1000,
+			want:     NetPay{SocialContributions: 502.61, HealthContribution: 374.71, TaxBase: 3913, PITAdvance: 170, Net: 3618.68},
+		},
+		{
+			name:     "umowa zlecenie without sickness insurance",
+			contract: EmploymentContract{Type: contractCivil, PIT2: true},
+			gross:    4666,
+			want:     NetPay{SocialContributions: 525.39, HealthContribution: 372.65, TaxBase: 3312, PITAdvance: 97, Net: 3670.96},
+		},
+		{
+			name:     "umowa zlecenie with ZUS paid from another employment",
+			contract: EmploymentContract{Type: contractCivil, PIT2: true, OtherEmploymentZUS: true},
+			gross:    4666,
+			want:     NetPay{HealthContribution: 419.94, TaxBase: 3733, PITAdvance: 148, Net: 4098.06},
+		},
+		{
+			name:     "student under 26",
+			contract: EmploymentContract{Type: contractStudent},
+			gross:    4666,
+			want:     NetPay{Net: 4666},
+		},
+	}
+
+	rates := payrollRatesFor(2025)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := calculateNetPay(test.contract, test.gross, test.sickPay, rates)
+
+			checks := []struct {
+				field     string
+				got, want float64
+			}{
+				{"social contributions", got.SocialContributions, test.want.SocialContributions},
+				{"health contribution", got.HealthContribution, test.want.HealthContribution},
+				{"tax base", got.TaxBase, test.want.TaxBase},
+				{"PIT advance", got.PITAdvance, test.want.PITAdvance},
+				{"net", got.Net, test.want.Net},
+			}
+			for _, check := range checks {
+				if math.Abs(check.got-check.want) > 0.005 {
+					t.Errorf("%s = %.2f, want %.2f", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyPayerNetPayTaxesTwoShopsOnce(t *testing.T) {
+	contract := EmploymentContract{Type: contractEmployment, PIT2: true}
+	first := PayrollBreakdown{EmployeeEmail: "anna@example.com", Total: 2333, contract: contract}
+	second := PayrollBreakdown{EmployeeEmail: "Anna@example.com", Total: 2333, contract: contract}
+	other := PayrollBreakdown{EmployeeEmail: "ewa@example.com", Total: 4666, contract: contract}
+	other.Net = calculateNetPay(contract, other.Total, 0, payrollRatesFor(2025))
+	otherNet := other.Net
+
+	applyPayerNetPay([]*PayrollBreakdown{&first, &second, &other}, 2025)
+
+	// Two halves of the minimum wage are paid out like the minimum wage itself
+	if net := roundMoney(first.Net.Net + second.Net.Net); net != 3510.92 {
+		t.Errorf("combined net = %.2f, want 3510.92", net)
+	}
+	if pit := roundMoney(first.Net.PITAdvance + second.Net.PITAdvance); pit != 153 {
+		t.Errorf("combined PIT advance = %.2f, want 153", pit)
+	}
+	if first.Net.Gross != 2333 || second.Net.Gross != 2333 {
+		t.Errorf("gross shares = %.2f and %.2f, want 2333 each", first.Net.Gross, second.Net.Gross)
+	}
+	if other.Net != otherNet {
+		t.Errorf("a person working in one shop should keep their own net pay")
+	}
+}
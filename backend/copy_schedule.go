@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// plannedCell is a value the backend wants to put into an employee's cell on a day
+type plannedCell struct {
+	EmployeeEmail string // key in shop.Employees
+	Date          time.Time
+	Value         string
+}
+
+// cellWriteResult collects what draftPlannedCells did across all touched months
+type cellWriteResult struct {
+	Changes []CellChange
+	Skipped []string
+	Shifts  []ScheduledShift
+}
+
+// draftPlannedCells puts planned values into the month drafts, so they can be reviewed before
+// publishing. Free cells are always filled, shifts are only replaced with overwrite and
+// absences such as approved leave are never replaced. Every touched month is locked and
+// prepared first, so a locked or unreadable month leaves all drafts unchanged.
+func draftPlannedCells(ctx context.Context, service *SpreadsheetService, shop Shop, cells []plannedCell, overwrite bool, actor string) (cellWriteResult, error) {
+	var result cellWriteResult
+
+	byMonth := make(map[string][]plannedCell)
+	var order []string
+	for _, cell := range cells {
+		key := monthKey(cell.Date.Year(), cell.Date.Month())
+		if _, seen := byMonth[key]; !seen {
+			order = append(order, key)
+		}
+		byMonth[key] = append(byMonth[key], cell)
+	}
+	sort.Strings(order)
+
+	// Months are locked in calendar order, so two writers spanning the same months cannot
+	// wait for each other
+	var drafts []ScheduleDraft
+	for _, key := range order {
+		cells := byMonth[key]
+		unlock := lockScheduleMonth(shop.ID, polishMonths[cells[0].Date.Month()-1], cells[0].Date.Year())
+		defer unlock()
+
+		draft, changed, err := draftMonthCells(ctx, service, shop, cells, overwrite, actor, &result)
+		if err != nil {
+			return cellWriteResult{}, err
+		}
+		if changed {
+			drafts = append(drafts, draft)
+		}
+	}
+
+	for _, draft := range drafts {
+		storeScheduleDraft(draft)
+		result.Shifts = append(result.Shifts, parseMonthSchedule(draft.Data, shop, draft.Month, draft.Year)...)
+	}
+	return result, nil
+}
+
+// draftMonthCells applies the planned cells of one month to a copy of its draft and reports
+// whether anything changed. The caller holds the month lock and stores the draft.
+func draftMonthCells(ctx context.Context, service *SpreadsheetService, shop Shop, cells []plannedCell, overwrite bool, actor string, result *cellWriteResult) (ScheduleDraft, bool, error) {
+	year := cells[0].Date.Year()
+	month := polishMonths[cells[0].Date.Month()-1]
+
+	if err := checkMonthOpen(shop.ID, month, year); err != nil {
+		return ScheduleDraft{}, false, err
+	}
+
+	draft, err := workingMonthDraft(ctx, service, shop, month, year, actor)
+	if err != nil {
+		return ScheduleDraft{}, false, err
+	}
+	data := cloneGrid(draft.Data)
+	if len(data) == 0 {
+		return ScheduleDraft{}, false, fmt.Errorf("sheet %s %d has no schedule", month, year)
+	}
+
+	var changes []CellChange
+	for _, cell := range cells {
+		name := shop.Employees[cell.EmployeeEmail].Name
+		date := cell.Date.Format(dateLayout)
+
+		row, column, found := findScheduleCell(data, shop, cell.EmployeeEmail, cell.Date.Day())
+		if !found {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s %s: no column in the %s sheet", date, name, month))
+			continue
+		}
+
+		current := cellString(data[row], column)
+		if current == cell.Value {
+			continue
+		}
+		if !isFreeCell(current) {
+			if _, _, _, isShift := shop.resolveShift(current, cell.Date); !isShift {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s %s: keeps absence %s", date, name, current))
+				continue
+			}
+			if !overwrite {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s %s: already scheduled (%s)", date, name, current))
+				continue
+			}
+		}
+
+		for len(data[row]) <= column {
+			data[row] = append(data[row], "")
+		}
+		data[row][column] = cell.Value
+		changes = append(changes, CellChange{EmployeeEmail: cell.EmployeeEmail, Date: date, Before: current, After: cell.Value})
+	}
+
+	if len(changes) == 0 {
+		return draft, false, nil
+	}
+	draft.Data = applyScheduleTotals(data, shop, month, year)
+	draft.UpdatedBy = actor
+	draft.UpdatedAt = time.Now()
+
+	result.Changes = append(result.Changes, changes...)
+	return draft, true, nil
+}
+
+// alignedSourceDay maps a day of the target month to the same weekday occurrence in the
+// source month (the 2nd Tuesday to the 2nd Tuesday). A 5th occurrence the source month does
+// not have falls back to the last one.
+func alignedSourceDay(sourceYear int, sourceMonth time.Month, target time.Time) time.Time {
+	occurrence := (target.Day() - 1) / 7
+	first := time.Date(sourceYear, sourceMonth, 1, 0, 0, 0, 0, time.Local)
+	offset := (int(target.Weekday()) - int(first.Weekday()) + 7) % 7
+	day := first.AddDate(0, 0, offset+7*occurrence)
+	if day.Month() != sourceMonth {
+		day = day.AddDate(0, 0, -7)
+	}
+	return day
+}
+
+// readScheduleValues reads the cell values of the given days, keyed by shop employee email
+// and date. Months with a draft are read from the draft, as that is what the employer sees.
+func readScheduleValues(ctx context.Context, service *SpreadsheetService, shop Shop, days []time.Time) (map[string]map[string]string, error) {
+	values := make(map[string]map[string]string)
+	grids := make(map[string][][]interface{})
+
+	for _, day := range days {
+		key := monthKey(day.Year(), day.Month())
+		data, loaded := grids[key]
+		if draft, exists := getScheduleDraft(shop.ID, polishMonths[day.Month()-1], day.Year()); !loaded && exists {
+			data, loaded = draft.Data, true
+			grids[key] = data
+		}
+		if !loaded {
+			spreadsheetID, exists := shop.Spreadsheets[day.Year()]
+			if !exists {
+				return nil, fmt.Errorf("no spreadsheet found for year %d", day.Year())
+			}
+			var err error
+			data, err = service.ReadMonthSchedule(ctx, spreadsheetID, polishMonths[day.Month()-1])
+			if err != nil {
+				return nil, err
+			}
+			grids[key] = data
+		}
+
+		for email := range shop.Employees {
+			row, column, found := findScheduleCell(data, shop, email, day.Day())
+			if !found {
+				continue
+			}
+			if values[email] == nil {
+				values[email] = make(map[string]string)
+			}
+			values[email][day.Format(dateLayout)] = cellString(data[row], column)
+		}
+	}
+	return values, nil
+}
+
+// scheduleWriteResponse reports a multi-cell write together with the usual warnings
+func scheduleWriteResponse(viewerEmail string, shop Shop, message string, result cellWriteResult) map[string]interface{} {
+	changes := result.Changes
+	if changes == nil {
+		changes = []CellChange{}
+	}
+	skipped := result.Skipped
+	if skipped == nil {
+		skipped = []string{}
+	}
+	return map[string]interface{}{
+		"message":   message,
+		"changes":   changes,
+		"skipped":   skipped,
+		"conflicts": findShiftConflicts(viewerEmail, shop, result.Shifts),
+		"warnings":  append(availabilityWarnings(shop, result.Shifts), checkComplianceForShifts(shop, result.Shifts)...),
+	}
+}
+
+// checkComplianceForShifts runs the compliance checks for every month the shifts belong to
+func checkComplianceForShifts(shop Shop, shifts []ScheduledShift) []ScheduleWarning {
+	byMonth := make(map[string][]ScheduledShift)
+	for _, shift := range shifts {
+		key := monthKey(shift.Date.Year(), shift.Date.Month())
+		byMonth[key] = append(byMonth[key], shift)
+	}
+
+	warnings := make([]ScheduleWarning, 0)
+	for _, monthShifts := range byMonth {
+		date := monthShifts[0].Date
+		warnings = append(warnings, checkCompliance(shop, polishMonths[date.Month()-1], date.Year(), monthShifts)...)
+	}
+	return warnings
+}
+
+// handleCopySchedule copies a week or a month of a shop's schedule to another week or month.
+// Employees are matched by identity and days by weekday, so Monday's shifts land on Monday.
+func handleCopySchedule(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can copy schedules", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID     string `json:"shop_id"`
+		Period     string `json:"period"`      // week | month
+		SourceDate string `json:"source_date"` // any day of the source week or month
+		TargetDate string `json:"target_date"` // any day of the target week or month
+		Overwrite  bool   `json:"overwrite"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	source, sourceErr := time.ParseInLocation(dateLayout, req.SourceDate, time.Local)
+	target, targetErr := time.ParseInLocation(dateLayout, req.TargetDate, time.Local)
+	if sourceErr != nil || targetErr != nil {
+		http.Error(w, "Source and target dates must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	// Pairs of source and target days
+	var sourceDays, targetDays []time.Time
+	switch req.Period {
+	case "week":
+		sourceStart, targetStart := weekStart(source), weekStart(target)
+		if sourceStart.Equal(targetStart) {
+			http.Error(w, "Source and target are the same week", http.StatusBadRequest)
+			return
+		}
+		for offset := 0; offset < 7; offset++ {
+			sourceDays = append(sourceDays, sourceStart.AddDate(0, 0, offset))
+			targetDays = append(targetDays, targetStart.AddDate(0, 0, offset))
+		}
+	case "month":
+		if source.Year() == target.Year() && source.Month() == target.Month() {
+			http.Error(w, "Source and target are the same month", http.StatusBadRequest)
+			return
+		}
+		for day := time.Date(target.Year(), target.Month(), 1, 0, 0, 0, 0, time.Local); day.Month() == target.Month(); day = day.AddDate(0, 0, 1) {
+			sourceDays = append(sourceDays, alignedSourceDay(source.Year(), source.Month(), day))
+			targetDays = append(targetDays, day)
+		}
+	default:
+		http.Error(w, "Period must be week or month", http.StatusBadRequest)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		log.Printf("Error getting spreadsheet service: %v", err)
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	values, err := readScheduleValues(r.Context(), spreadsheetService, shop, sourceDays)
+	if err != nil {
+		log.Printf("Error reading source schedule for shop %s: %v", shop.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to read source schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var cells []plannedCell
+	var skipped []string
+	for index, targetDay := range targetDays {
+		sourceDay := sourceDays[index]
+		for email, employee := range shop.Employees {
+			value, found := values[email][sourceDay.Format(dateLayout)]
+			if !found {
+				continue
+			}
+			// Only shifts and days off are copied; leave and sickness belong to their own dates
+			if _, _, _, isShift := shop.resolveShift(value, sourceDay); !isShift && !isFreeCell(value) {
+				continue
+			}
+			if isFreeCell(value) && !req.Overwrite {
+				continue
+			}
+			if !employee.employedOn(targetDay) {
+				if !isFreeCell(value) {
+					skipped = append(skipped, fmt.Sprintf("%s %s: not employed on that day", targetDay.Format(dateLayout), employee.Name))
+				}
+				continue
+			}
+			cells = append(cells, plannedCell{EmployeeEmail: email, Date: targetDay, Value: value})
+		}
+	}
+
+	result, err := draftPlannedCells(r.Context(), spreadsheetService, shop, cells, req.Overwrite, session.UserInfo.Email)
+	result.Skipped = append(skipped, result.Skipped...)
+	if respondMonthLocked(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error copying schedule for shop %s: %v", shop.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to copy schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	message := fmt.Sprintf("Copied %s of %s to the draft of %s", req.Period, source.Format(dateLayout), target.Format(dateLayout))
+	if len(result.Changes) > 0 {
+		recordAudit(AuditEntry{
+			ShopID:  shop.ID,
+			Action:  "schedule_copied",
+			Actor:   session.UserInfo.Email,
+			Message: message,
+			Changes: result.Changes,
+		})
+	}
+
+	log.Printf("%s in shop %s: %d cells changed, %d skipped", message, shop.ID, len(result.Changes), len(result.Skipped))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduleWriteResponse(session.UserInfo.Email, shop, message, result))
+}
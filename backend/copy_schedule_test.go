@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlignedSourceDay(t *testing.T) {
+	tests := []struct {
+		name        string
+		sourceYear  int
+		sourceMonth time.Month
+		target      string
+		want        string
+	}{
+		{"the 2nd Tuesday maps to the 2nd Tuesday", 2025, time.February, "2025-03-11", "2025-02-11"},
+		{"the 1st Saturday maps to the 1st Saturday", 2025, time.February, "2025-03-01", "2025-02-01"},
+		{"a 5th Monday falls back to the last Monday", 2025, time.February, "2025-03-31", "2025-02-24"},
+		{"a 5th Saturday the source month has is kept", 2025, time.May, "2025-03-29", "2025-05-31"},
+		{"the source month may lie in the previous year", 2024, time.December, "2025-01-31", "2024-12-27"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, _ := time.ParseInLocation(dateLayout, test.target, time.Local)
+			got := alignedSourceDay(test.sourceYear, test.sourceMonth, target).Format(dateLayout)
+			if got != test.want {
+				t.Errorf("alignedSourceDay(%d-%02d, %s) = %s, want %s", test.sourceYear, test.sourceMonth, test.target, got, test.want)
+			}
+		})
+	}
+}
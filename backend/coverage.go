@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CoverageRequirement is the number of people a shop needs during a time slot on a weekday
+// (0 = Sunday ... 6 = Saturday), optionally limited to one position
+type CoverageRequirement struct {
+	Weekday  int    `json:"weekday"`
+	Start    string `json:"start"` // HH:MM
+	End      string `json:"end"`   // HH:MM
+	Count    int    `json:"count"`
+	Position string `json:"position,omitempty"`
+}
+
+func (requirement CoverageRequirement) validate() error {
+	if requirement.Weekday < 0 || requirement.Weekday > 6 {
+		return fmt.Errorf("weekday must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if _, _, ok := parseShiftTimes(requirement.Start + "-" + requirement.End); !ok {
+		return fmt.Errorf("invalid slot %s-%s, expected HH:MM times", requirement.Start, requirement.End)
+	}
+	if requirement.Count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	return nil
+}
+
+// window returns the slot's time range on the given day
+func (requirement CoverageRequirement) window(day time.Time) (time.Time, time.Time) {
+	start, end, _ := parseShiftTimes(requirement.Start + "-" + requirement.End)
+	return day.Add(time.Duration(start) * time.Minute), day.Add(time.Duration(end) * time.Minute)
+}
+
+// coverageOn returns the shop's requirements for a day, earliest slot first. Days the shop
+// is closed need no coverage.
+func (shop Shop) coverageOn(day time.Time) []CoverageRequirement {
+	var result []CoverageRequirement
+	if shop.isClosedOn(day) {
+		return result
+	}
+	for _, requirement := range shop.Coverage {
+		if time.Weekday(requirement.Weekday) == day.Weekday() {
+			result = append(result, requirement)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start < result[j].Start })
+	return result
+}
+
+func handleCoverage(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage coverage requirements", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		coverage := shop.Coverage
+		if coverage == nil {
+			coverage = []CoverageRequirement{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"coverage": coverage})
+
+	case http.MethodPut:
+		var req struct {
+			Coverage []CoverageRequirement `json:"coverage"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		for i := range req.Coverage {
+			req.Coverage[i].Position = strings.TrimSpace(req.Coverage[i].Position)
+			if err := req.Coverage[i].validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][shopID]
+		shop.Coverage = req.Coverage
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][shopID] = shop
+		employerShopsMutex.Unlock()
+
+		go saveShopsData()
+
+		log.Printf("Updated coverage requirements for shop %s by employer %s", shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"coverage": req.Coverage})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	scheduleDraftsFile       = "schedule_drafts_data.json"
+	schedulePublicationsFile = "schedule_publications_data.json"
+)
+
+// ScheduleDraft is a month grid kept by the backend for review instead of being written to
+// the shared sheet. Base is the published grid the draft started from, so changes made to
+// the sheet in the meantime (approved swaps, leave) survive publishing.
+type ScheduleDraft struct {
+	ShopID    string            `json:"shop_id"`
+	Month     string            `json:"month"`
+	Year      int               `json:"year"`
+	Data      [][]interface{}   `json:"data"`
+	Base      [][]interface{}   `json:"base,omitempty"`
+	Unfilled  []UnfilledSlot    `json:"unfilled,omitempty"`
+	Warnings  []ScheduleWarning `json:"warnings,omitempty"`
+	CreatedBy string            `json:"created_by"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedBy string            `json:"updated_by,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SchedulePublication stamps the last time a month was published to the shared sheet
+type SchedulePublication struct {
+	ShopID      string    `json:"shop_id"`
+	Month       string    `json:"month"`
+	Year        int       `json:"year"`
+	PublishedBy string    `json:"published_by"`
+	PublishedAt time.Time `json:"published_at"`
+	Changes     int       `json:"changes"`
+}
+
+var (
+	scheduleDrafts      = make(map[string]map[string]ScheduleDraft) // shop_id -> YYYY-MM -> draft
+	scheduleDraftsMutex sync.RWMutex
+
+	schedulePublications      = make(map[string]map[string]SchedulePublication) // shop_id -> YYYY-MM -> last publication
+	schedulePublicationsMutex sync.RWMutex
+)
+
+func saveScheduleDraftsData() error {
+	scheduleDraftsMutex.RLock()
+	defer scheduleDraftsMutex.RUnlock()
+	return writeJSONFileAtomic(scheduleDraftsFile, scheduleDrafts)
+}
+
+func loadScheduleDraftsData() error {
+	scheduleDraftsMutex.Lock()
+	defer scheduleDraftsMutex.Unlock()
+	return readJSONFile(scheduleDraftsFile, &scheduleDrafts)
+}
+
+func saveSchedulePublicationsData() error {
+	schedulePublicationsMutex.RLock()
+	defer schedulePublicationsMutex.RUnlock()
+	return writeJSONFileAtomic(schedulePublicationsFile, schedulePublications)
+}
+
+func loadSchedulePublicationsData() error {
+	schedulePublicationsMutex.Lock()
+	defer schedulePublicationsMutex.Unlock()
+	return readJSONFile(schedulePublicationsFile, &schedulePublications)
+}
+
+func getScheduleDraft(shopID, month string, year int) (ScheduleDraft, bool) {
+	scheduleDraftsMutex.RLock()
+	defer scheduleDraftsMutex.RUnlock()
+	draft, exists := scheduleDrafts[shopID][monthKey(year, getMonthNumber(month))]
+	return draft, exists
+}
+
+func storeScheduleDraft(draft ScheduleDraft) {
+	scheduleDraftsMutex.Lock()
+	if scheduleDrafts[draft.ShopID] == nil {
+		scheduleDrafts[draft.ShopID] = make(map[string]ScheduleDraft)
+	}
+	scheduleDrafts[draft.ShopID][monthKey(draft.Year, getMonthNumber(draft.Month))] = draft
+	scheduleDraftsMutex.Unlock()
+
+	go saveScheduleDraftsData()
+}
+
+func deleteScheduleDraft(shopID, month string, year int) {
+	scheduleDraftsMutex.Lock()
+	delete(scheduleDrafts[shopID], monthKey(year, getMonthNumber(month)))
+	scheduleDraftsMutex.Unlock()
+
+	go saveScheduleDraftsData()
+}
+
+func getSchedulePublication(shopID, month string, year int) (SchedulePublication, bool) {
+	schedulePublicationsMutex.RLock()
+	defer schedulePublicationsMutex.RUnlock()
+	publication, exists := schedulePublications[shopID][monthKey(year, getMonthNumber(month))]
+	return publication, exists
+}
+
+func storeSchedulePublication(publication SchedulePublication) {
+	schedulePublicationsMutex.Lock()
+	if schedulePublications[publication.ShopID] == nil {
+		schedulePublications[publication.ShopID] = make(map[string]SchedulePublication)
+	}
+	schedulePublications[publication.ShopID][monthKey(publication.Year, getMonthNumber(publication.Month))] = publication
+	schedulePublicationsMutex.Unlock()
+
+	go saveSchedulePublicationsData()
+}
+
+func cloneGrid(data [][]interface{}) [][]interface{} {
+	clone := make([][]interface{}, len(data))
+	for index, row := range data {
+		clone[index] = append([]interface{}(nil), row...)
+	}
+	return clone
+}
+
+// workingMonthDraft returns the month's draft, starting a new one from the published sheet
+// when there is none yet
+func workingMonthDraft(ctx context.Context, service *SpreadsheetService, shop Shop, month string, year int, actor string) (ScheduleDraft, error) {
+	if draft, exists := getScheduleDraft(shop.ID, month, year); exists {
+		return draft, nil
+	}
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		return ScheduleDraft{}, fmt.Errorf("no spreadsheet found for year %d", year)
+	}
+	published, err := service.ReadMonthSchedule(ctx, spreadsheetID, month)
+	if err != nil {
+		return ScheduleDraft{}, err
+	}
+
+	now := time.Now()
+	return ScheduleDraft{
+		ShopID:    shop.ID,
+		Month:     month,
+		Year:      year,
+		Data:      cloneGrid(published),
+		Base:      published,
+		CreatedBy: actor,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// gridCellPositions locates the editable cells of a month grid by day label and column
+// header, so grids with a different column order can be compared
+func gridCellPositions(data [][]interface{}) map[string][2]int {
+	positions := make(map[string][2]int)
+	if len(data) == 0 {
+		return positions
+	}
+	for rowIndex := 1; rowIndex < len(data); rowIndex++ {
+		label := cellString(data[rowIndex], 0)
+		if label == "SUMA GODZIN" || label == "WYPŁATA" {
+			break
+		}
+		if _, isDay := parseDayCell(label); !isDay {
+			continue
+		}
+		for column := 1; column < len(data[0]); column++ {
+			if header := strings.ToUpper(cellString(data[0], column)); header != "" {
+				positions[header+"|"+label] = [2]int{rowIndex, column}
+			}
+		}
+	}
+	return positions
+}
+
+func gridCellValue(data [][]interface{}, positions map[string][2]int, key string) string {
+	position, exists := positions[key]
+	if !exists {
+		return ""
+	}
+	return cellString(data[position[0]], position[1])
+}
+
+// headerEmails maps the column headers of grids to shop employee emails
+func headerEmails(shop Shop, grids ...[][]interface{}) map[string]string {
+	emails := make(map[string]string)
+	for _, grid := range grids {
+		if len(grid) == 0 {
+			continue
+		}
+		for column, email := range scheduleColumns(grid[0], shop.Employees) {
+			emails[strings.ToUpper(cellString(grid[0], column))] = email
+		}
+	}
+	return emails
+}
+
+// gridChange describes a cell addressed by gridCellPositions key. Columns not belonging to an
+// employee (such as TAGI) are reported by their header.
+func gridChange(emails map[string]string, month string, year int, key, before, after string) CellChange {
+	parts := strings.SplitN(key, "|", 2)
+	day, _ := parseDayCell(parts[1])
+	change := CellChange{
+		EmployeeEmail: emails[parts[0]],
+		Date:          time.Date(year, getMonthNumber(month), day, 0, 0, 0, 0, time.Local).Format(dateLayout),
+		Before:        before,
+		After:         after,
+	}
+	if change.EmployeeEmail == "" {
+		change.Column = parts[0]
+	}
+	return change
+}
+
+// diffScheduleGrids lists the cells whose values differ between two grids of the same month
+func diffScheduleGrids(shop Shop, month string, year int, before, after [][]interface{}) []CellChange {
+	beforePositions := gridCellPositions(before)
+	afterPositions := gridCellPositions(after)
+	emails := headerEmails(shop, before, after)
+
+	keys := make(map[string]bool, len(afterPositions))
+	for key := range beforePositions {
+		keys[key] = true
+	}
+	for key := range afterPositions {
+		keys[key] = true
+	}
+
+	changes := make([]CellChange, 0)
+	for key := range keys {
+		beforeValue := gridCellValue(before, beforePositions, key)
+		afterValue := gridCellValue(after, afterPositions, key)
+		if beforeValue != afterValue {
+			changes = append(changes, gridChange(emails, month, year, key, beforeValue, afterValue))
+		}
+	}
+	sortCellChanges(changes)
+	return changes
+}
+
+// mergeDraft takes the draft and brings in cells changed on the sheet since the draft was
+// started. Cells changed on both sides to different values are returned as conflicts, with
+// Before holding the sheet value and After the draft value.
+func mergeDraft(shop Shop, draft ScheduleDraft, current [][]interface{}) ([][]interface{}, []CellChange) {
+	merged := cloneGrid(draft.Data)
+	basePositions := gridCellPositions(draft.Base)
+	draftPositions := gridCellPositions(merged)
+	currentPositions := gridCellPositions(current)
+	emails := headerEmails(shop, current, merged)
+
+	conflicts := make([]CellChange, 0)
+	for key := range currentPositions {
+		baseValue := gridCellValue(draft.Base, basePositions, key)
+		currentValue := gridCellValue(current, currentPositions, key)
+		draftValue := gridCellValue(merged, draftPositions, key)
+		if currentValue == baseValue || currentValue == draftValue {
+			continue
+		}
+
+		position, inDraft := draftPositions[key]
+		if draftValue != baseValue || !inDraft {
+			conflicts = append(conflicts, gridChange(emails, draft.Month, draft.Year, key, currentValue, draftValue))
+			continue
+		}
+		for len(merged[position[0]]) <= position[1] {
+			merged[position[0]] = append(merged[position[0]], "")
+		}
+		merged[position[0]][position[1]] = currentValue
+	}
+	sortCellChanges(conflicts)
+	return merged, conflicts
+}
+
+// publishResult describes a publication attempt. Conflicts are set instead of the other
+// fields when the draft was not written.
+type publishResult struct {
+	Publication SchedulePublication
+	Changes     []CellChange
+	Shifts      []ScheduledShift
+	Data        [][]interface{}
+	Conflicts   []CellChange
+}
+
+// publishScheduleDraft writes the draft to the shared sheet and stamps the publication.
+// Unless force is set, nothing is written when the sheet changed the same cells meanwhile.
+func publishScheduleDraft(ctx context.Context, service *SpreadsheetService, shop Shop, draft ScheduleDraft, actor string, force bool) (publishResult, error) {
+	spreadsheetID, exists := shop.Spreadsheets[draft.Year]
+	if !exists {
+		return publishResult{}, fmt.Errorf("no spreadsheet found for year %d", draft.Year)
+	}
+	current, err := service.ReadMonthSchedule(ctx, spreadsheetID, draft.Month)
+	if err != nil {
+		return publishResult{}, err
+	}
+
+	merged, conflicts := mergeDraft(shop, draft, current)
+	if len(conflicts) > 0 && !force {
+		return publishResult{Conflicts: conflicts}, nil
+	}
+
+	changes := diffScheduleGrids(shop, draft.Month, draft.Year, current, merged)
+	written, shifts, err := saveMonthSchedule(ctx, service, shop, spreadsheetID, draft.Month, draft.Year, merged)
+	if err != nil {
+		return publishResult{}, err
+	}
+	recordScheduleVersion(shop, current, written, ScheduleVersion{Month: draft.Month, Year: draft.Year, Action: "publish", Actor: actor})
+	deleteScheduleDraft(shop.ID, draft.Month, draft.Year)
+
+	publication := SchedulePublication{
+		ShopID:      shop.ID,
+		Month:       draft.Month,
+		Year:        draft.Year,
+		PublishedBy: actor,
+		PublishedAt: time.Now(),
+		Changes:     len(changes),
+	}
+	storeSchedulePublication(publication)
+
+	recordAudit(AuditEntry{
+		ShopID:  shop.ID,
+		Action:  "schedule_published",
+		Actor:   actor,
+		Message: fmt.Sprintf("Published %s %d with %d changed cells", draft.Month, draft.Year, len(changes)),
+		Changes: changes,
+	})
+	return publishResult{Publication: publication, Changes: changes, Shifts: shifts, Data: written}, nil
+}
+
+func handleScheduleDraft(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage schedule drafts", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	draft, exists := getScheduleDraft(shopID, month, year)
+	if !exists {
+		http.Error(w, "No draft for this month", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		// Review the draft against what employees currently see
+		published := draft.Base
+		if spreadsheetID, exists := shop.Spreadsheets[year]; exists {
+			if spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token); err == nil {
+				if current, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month); err == nil {
+					published = current
+				} else {
+					log.Printf("Error reading published %s for draft diff: %v", month, err)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			ScheduleDraft
+			Revision string       `json:"revision"`
+			Changes  []CellChange `json:"changes"`
+		}{draft, scheduleRevision(draft.Data), diffScheduleGrids(shop, month, year, published, draft.Data)})
+
+	case http.MethodDelete:
+		deleteScheduleDraft(shopID, month, year)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Draft discarded"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handlePublishSchedule(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can publish schedules", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID string `json:"shop_id"`
+		Month  string `json:"month"`
+		Year   int    `json:"year"`
+		Force  bool   `json:"force"` // publish even if the sheet changed the same cells
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		log.Printf("Error getting spreadsheet service: %v", err)
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	// The draft is read under the month lock, so an edit saved meanwhile is published or waits
+	unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+	defer unlock()
+
+	if !requireMonthOpen(w, shop.ID, req.Month, req.Year) {
+		return
+	}
+	draft, exists := getScheduleDraft(req.ShopID, req.Month, req.Year)
+	if !exists {
+		http.Error(w, "No draft for this month", http.StatusNotFound)
+		return
+	}
+
+	result, err := publishScheduleDraft(r.Context(), spreadsheetService, shop, draft, session.UserInfo.Email, req.Force)
+	if err != nil {
+		log.Printf("Error publishing %s %d for shop %s: %v", req.Month, req.Year, req.ShopID, err)
+		http.Error(w, fmt.Sprintf("Failed to publish schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(result.Conflicts) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":     "The published schedule changed the same cells since the draft was started",
+			"conflicts": result.Conflicts,
+		})
+		return
+	}
+
+	log.Printf("Published %s %d for shop %s by %s: %d changed cells", req.Month, req.Year, req.ShopID, session.UserInfo.Email, len(result.Changes))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Schedule published",
+		"publication": result.Publication,
+		"changes":     result.Changes,
+		"data":        result.Data,
+		"revision":    scheduleRevision(result.Data),
+		"conflicts":   findShiftConflicts(session.UserInfo.Email, shop, result.Shifts),
+		"warnings":    append(availabilityWarnings(shop, result.Shifts), checkCompliance(shop, req.Month, req.Year, result.Shifts)...),
+	})
+}
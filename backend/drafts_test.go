@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+func testScheduleShop() Shop {
+	return Shop{
+		ID:   "schedule-test-shop",
+		Name: "Test",
+		Employees: map[string]Employee{
+			"anna@example.com": {Email: "anna@example.com", Name: "Anna"},
+			"ewa@example.com":  {Email: "ewa@example.com", Name: "Ewa"},
+		},
+	}
+}
+
+// testGrid builds a March grid with Anna's and Ewa's cells for days 1 and 2
+func testGrid(anna1, ewa1, anna2, ewa2 string) [][]interface{} {
+	return [][]interface{}{
+		{"", "Anna", "Ewa"},
+		{"Sobota 1", anna1, ewa1},
+		{"Niedziela 2", anna2, ewa2},
+		{"SUMA GODZIN", "", ""},
+	}
+}
+
+func TestMergeDraft(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          [][]interface{}
+		draft         [][]interface{}
+		current       [][]interface{}
+		wantMerged    [][]interface{}
+		wantConflicts []CellChange
+	}{
+		{
+			name:       "a cell changed only on the sheet is brought into the draft",
+			base:       testGrid("", "", "", ""),
+			draft:      testGrid("08:00-16:00", "", "", ""),
+			current:    testGrid("", "UW", "", ""),
+			wantMerged: testGrid("08:00-16:00", "UW", "", ""),
+		},
+		{
+			name:       "the same change on both sides is not a conflict",
+			base:       testGrid("", "", "", ""),
+			draft:      testGrid("08:00-16:00", "", "", ""),
+			current:    testGrid("08:00-16:00", "", "", ""),
+			wantMerged: testGrid("08:00-16:00", "", "", ""),
+		},
+		{
+			name:       "a cell changed on both sides to different values is a conflict",
+			base:       testGrid("", "", "", ""),
+			draft:      testGrid("08:00-16:00", "", "", ""),
+			current:    testGrid("L4", "", "12:00-20:00", ""),
+			wantMerged: testGrid("08:00-16:00", "", "12:00-20:00", ""),
+			wantConflicts: []CellChange{
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-01", Before: "L4", After: "08:00-16:00"},
+			},
+		},
+		{
+			name:       "a cell cleared in the draft and changed on the sheet is a conflict",
+			base:       testGrid("08:00-16:00", "", "", ""),
+			draft:      testGrid("", "", "", ""),
+			current:    testGrid("10:00-18:00", "", "", ""),
+			wantMerged: testGrid("", "", "", ""),
+			wantConflicts: []CellChange{
+				{EmployeeEmail: "anna@example.com", Date: "2025-03-01", Before: "10:00-18:00", After: ""},
+			},
+		},
+	}
+
+	shop := testScheduleShop()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			draft := ScheduleDraft{ShopID: shop.ID, Month: "MARZEC", Year: 2025, Data: test.draft, Base: test.base}
+			merged, conflicts := mergeDraft(shop, draft, test.current)
+			if scheduleRevision(merged) != scheduleRevision(test.wantMerged) {
+				t.Errorf("merged = %v, want %v", merged, test.wantMerged)
+			}
+			if len(conflicts) != len(test.wantConflicts) {
+				t.Fatalf("got %d conflicts %v, want %v", len(conflicts), conflicts, test.wantConflicts)
+			}
+			for i, conflict := range conflicts {
+				if conflict != test.wantConflicts[i] {
+					t.Errorf("conflict %d = %+v, want %+v", i, conflict, test.wantConflicts[i])
+				}
+			}
+		})
+	}
+}
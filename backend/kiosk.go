@@ -0,0 +1,409 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const kioskTokensFile = "kiosk_tokens_data.json"
+
+const (
+	timeSourceKiosk = "kiosk"
+
+	kioskCodePeriod = time.Minute
+	kioskCodeSkew   = 1 // previous periods still accepted, for a scan right after the code rotated
+)
+
+var (
+	kioskKey     []byte
+	kioskKeyOnce sync.Once
+
+	kioskScans      = make(map[string]time.Time) // code|employee -> when the code stops being valid
+	kioskScansMutex sync.Mutex
+
+	kioskTokens      = make(map[string]KioskToken) // token_id -> token
+	kioskTokensMutex sync.RWMutex
+)
+
+// KioskToken lets the device in a shop show its kiosk codes without anyone's session. It can
+// do nothing else, so losing the device does not expose payroll or schedules. Only a hash of
+// the secret is kept; the full token is shown once, when it is created.
+type KioskToken struct {
+	ID         string    `json:"id"`
+	ShopID     string    `json:"shop_id"`
+	Name       string    `json:"name"`
+	SecretHash string    `json:"secret_hash"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func saveKioskTokensData() error {
+	kioskTokensMutex.RLock()
+	defer kioskTokensMutex.RUnlock()
+	return writeJSONFileAtomic(kioskTokensFile, kioskTokens)
+}
+
+func loadKioskTokensData() error {
+	kioskTokensMutex.Lock()
+	defer kioskTokensMutex.Unlock()
+	return readJSONFile(kioskTokensFile, &kioskTokens)
+}
+
+func hashKioskSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// createKioskToken issues a token for the shop's kiosk device, in the form id.secret
+func createKioskToken(shopID, name, createdBy string) (KioskToken, string) {
+	secret := generateRandomString(32)
+	token := KioskToken{
+		ID:         generateRandomString(12),
+		ShopID:     shopID,
+		Name:       name,
+		SecretHash: hashKioskSecret(secret),
+		CreatedBy:  createdBy,
+		CreatedAt:  time.Now(),
+	}
+	kioskTokensMutex.Lock()
+	kioskTokens[token.ID] = token
+	kioskTokensMutex.Unlock()
+
+	go saveKioskTokensData()
+	return token, token.ID + "." + secret
+}
+
+// verifyKioskToken returns the shop a kiosk token was issued for
+func verifyKioskToken(value string) (string, bool) {
+	id, secret, found := strings.Cut(value, ".")
+	if !found {
+		return "", false
+	}
+	kioskTokensMutex.RLock()
+	token, exists := kioskTokens[id]
+	kioskTokensMutex.RUnlock()
+	if !exists || !hmac.Equal([]byte(token.SecretHash), []byte(hashKioskSecret(secret))) {
+		return "", false
+	}
+	return token.ShopID, true
+}
+
+// kioskSigningKey reads KIOSK_SIGNING_KEY. Without it a random key is used, which is fine for
+// a single backend but invalidates displayed codes on restart.
+func kioskSigningKey() []byte {
+	kioskKeyOnce.Do(func() {
+		if key := os.Getenv("KIOSK_SIGNING_KEY"); key != "" {
+			kioskKey = []byte(key)
+			return
+		}
+		log.Printf("KIOSK_SIGNING_KEY is not set, using a random kiosk signing key")
+		kioskKey = make([]byte, 32)
+		if _, err := rand.Read(kioskKey); err != nil {
+			log.Fatalf("Error generating kiosk signing key: %v", err)
+		}
+	})
+	return kioskKey
+}
+
+// frontendURL reads FRONTEND_URL, the address the app is served from, without a trailing slash
+func frontendURL() string {
+	if value := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/"); value != "" {
+		return value
+	}
+	return "http://localhost:3000"
+}
+
+func kioskSignature(shopID string, period int64) string {
+	mac := hmac.New(sha256.New, kioskSigningKey())
+	fmt.Fprintf(mac, "kiosk|%s|%d", shopID, period)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// kioskCode returns the code a shop's kiosk shows during the period containing now. The code
+// is shop_id.period.signature, so a scan can be checked without any stored state.
+func kioskCode(shopID string, now time.Time) (string, time.Time) {
+	period := now.Unix() / int64(kioskCodePeriod/time.Second)
+	expires := time.Unix((period+1)*int64(kioskCodePeriod/time.Second), 0)
+	return fmt.Sprintf("%s.%d.%s", shopID, period, kioskSignature(shopID, period)), expires
+}
+
+// verifyKioskCode checks the signature and age of a scanned code and returns its shop ID
+func verifyKioskCode(code string, now time.Time) (string, error) {
+	// Split from the right, the shop ID is the only part that could contain a dot
+	signatureAt := strings.LastIndex(code, ".")
+	if signatureAt == -1 {
+		return "", fmt.Errorf("invalid kiosk code")
+	}
+	periodAt := strings.LastIndex(code[:signatureAt], ".")
+	if periodAt == -1 {
+		return "", fmt.Errorf("invalid kiosk code")
+	}
+	shopID, signature := code[:periodAt], code[signatureAt+1:]
+	period, err := strconv.ParseInt(code[periodAt+1:signatureAt], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid kiosk code")
+	}
+	if !hmac.Equal([]byte(signature), []byte(kioskSignature(shopID, period))) {
+		return "", fmt.Errorf("invalid kiosk code")
+	}
+
+	current := now.Unix() / int64(kioskCodePeriod/time.Second)
+	if period > current || current-period > kioskCodeSkew {
+		return "", fmt.Errorf("kiosk code expired, scan the current code")
+	}
+	return shopID, nil
+}
+
+// claimKioskScan makes every code usable once per employee, so a double scan does not clock
+// in and straight out again. The code is only used up when punch succeeds; punch runs while
+// the scan is held, so a second scan of the same code waits for its outcome.
+func claimKioskScan(code, employeeEmail string, now time.Time, punch func() error) (bool, error) {
+	kioskScansMutex.Lock()
+	defer kioskScansMutex.Unlock()
+
+	for key, validUntil := range kioskScans {
+		if now.After(validUntil) {
+			delete(kioskScans, key)
+		}
+	}
+
+	key := code + "|" + normalizeEmail(employeeEmail)
+	if _, used := kioskScans[key]; used {
+		return false, nil
+	}
+	if err := punch(); err != nil {
+		return true, err
+	}
+	kioskScans[key] = now.Add(kioskCodePeriod * (kioskCodeSkew + 1))
+	return true, nil
+}
+
+// handleKioskCode serves the current code of a shop's kiosk. The kiosk screen polls it and
+// renders scan_url as a QR code. The device in the shop authenticates with its kiosk token
+// (Authorization: Kiosk <token>); an employer can also preview the code of their own shop.
+func handleKioskCode(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var shop Shop
+	if value, isKiosk := strings.CutPrefix(r.Header.Get("Authorization"), "Kiosk "); isKiosk {
+		shopID, valid := verifyKioskToken(strings.TrimSpace(value))
+		if !valid {
+			http.Error(w, "Invalid kiosk token", http.StatusUnauthorized)
+			return
+		}
+		_, owned, exists := shopOwner(shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		shop = owned
+	} else {
+		session, ok := requireSession(w, r)
+		if !ok {
+			return
+		}
+
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can open the shop kiosk", http.StatusForbidden)
+			return
+		}
+
+		owned, exists := getEmployerShop(session.UserInfo.Email, r.URL.Query().Get("shop_id"))
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		shop = owned
+	}
+
+	now := time.Now()
+	code, expires := kioskCode(shop.ID, now)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shop_id":    shop.ID,
+		"shop_name":  shop.Name,
+		"code":       code,
+		"scan_url":   frontendURL() + "/?kiosk_code=" + url.QueryEscape(code),
+		"expires_at": expires,
+		"refresh_in": int(expires.Sub(now).Seconds()) + 1,
+	})
+}
+
+// handleKioskScan clocks the logged-in employee in or out of the shop whose kiosk code they
+// scanned
+func handleKioskScan(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employee" {
+		http.Error(w, "Only employees can clock in", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Code   string `json:"code"`
+		Action string `json:"action"` // in | out, empty toggles
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	code := strings.TrimSpace(req.Code)
+	shopID, err := verifyKioskCode(code, now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, shop, exists := findShopForEmployee(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "You do not work in this shop", http.StatusForbidden)
+		return
+	}
+
+	var entry TimeEntry
+	fresh, err := claimKioskScan(code, session.UserInfo.Email, now, func() error {
+		var err error
+		entry, err = clockPunch(shop, session.UserInfo.Email, req.Action, timeSourceKiosk, session.UserInfo.Email, now)
+		return err
+	})
+	if !fresh {
+		http.Error(w, "This code was already scanned, wait for the next one", http.StatusConflict)
+		return
+	}
+	if respondMonthLocked(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Employee %s clocked %s at the kiosk of shop %s", session.UserInfo.Email, clockDirection(entry), shop.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   fmt.Sprintf("Clocked %s at %s", clockDirection(entry), shop.Name),
+		"shop_name": shop.Name,
+		"entry":     entry,
+	})
+}
+
+// handleKioskTokens lets an employer list, create and revoke the kiosk tokens of a shop. A
+// new token is returned once, together with the address to open on the kiosk device.
+func handleKioskTokens(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage kiosk devices", http.StatusForbidden)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, r.URL.Query().Get("shop_id"))
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		tokens := []KioskToken{}
+		kioskTokensMutex.RLock()
+		for _, token := range kioskTokens {
+			if token.ShopID == shop.ID {
+				token.SecretHash = ""
+				tokens = append(tokens, token)
+			}
+		}
+		kioskTokensMutex.RUnlock()
+		sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"tokens": tokens})
+
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = shop.Name + " kiosk"
+		}
+
+		token, value := createKioskToken(shop.ID, name, session.UserInfo.Email)
+		token.SecretHash = ""
+		log.Printf("Employer %s created kiosk token %s for shop %s", session.UserInfo.Email, token.ID, shop.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":     value,
+			"kiosk":     token,
+			"kiosk_url": frontendURL() + "/?kiosk_token=" + url.QueryEscape(value),
+		})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		kioskTokensMutex.Lock()
+		token, exists := kioskTokens[id]
+		if exists && token.ShopID == shop.ID {
+			delete(kioskTokens, id)
+		}
+		kioskTokensMutex.Unlock()
+		if !exists || token.ShopID != shop.ID {
+			http.Error(w, "Kiosk token not found", http.StatusNotFound)
+			return
+		}
+		go saveKioskTokensData()
+
+		log.Printf("Employer %s revoked kiosk token %s of shop %s", session.UserInfo.Email, id, shop.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Kiosk token revoked"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClaimKioskScanOnlyAfterPunch(t *testing.T) {
+	now := time.Now()
+	code, _ := kioskCode("kiosk-test-shop", now)
+	const email = "anna@example.com"
+
+	fresh, err := claimKioskScan(code, email, now, func() error { return errors.New("not clocked in") })
+	if !fresh || err == nil {
+		t.Fatalf("got fresh %v with error %v, want the punch error", fresh, err)
+	}
+
+	punches := 0
+	punch := func() error {
+		punches++
+		return nil
+	}
+	if fresh, err := claimKioskScan(code, email, now, punch); !fresh || err != nil {
+		t.Fatalf("a rejected punch should not use up the code, got fresh %v with error %v", fresh, err)
+	}
+	if fresh, _ := claimKioskScan(code, email, now, punch); fresh {
+		t.Errorf("a code should be used only once per employee")
+	}
+	if punches != 1 {
+		t.Errorf("punched %d times, want 1", punches)
+	}
+}
+
+func TestVerifyKioskToken(t *testing.T) {
+	token, value := createKioskToken("kiosk-test-shop", "Till", "owner@example.com")
+	defer func() {
+		kioskTokensMutex.Lock()
+		delete(kioskTokens, token.ID)
+		kioskTokensMutex.Unlock()
+	}()
+
+	if shopID, ok := verifyKioskToken(value); !ok || shopID != "kiosk-test-shop" {
+		t.Errorf("got shop %q, %v, want the token's shop", shopID, ok)
+	}
+	for _, forged := range []string{token.ID, token.ID + ".wrong", "unknown." + value, ""} {
+		if _, ok := verifyKioskToken(forged); ok {
+			t.Errorf("token %q should not be accepted", forged)
+		}
+	}
+}
+
+func TestVerifyKioskCode(t *testing.T) {
+	now := time.Now()
+	code, _ := kioskCode("kiosk-test-shop", now)
+	previous, _ := kioskCode("kiosk-test-shop", now.Add(-kioskCodePeriod))
+	stale, _ := kioskCode("kiosk-test-shop", now.Add(-kioskCodePeriod*(kioskCodeSkew+1)))
+	future, _ := kioskCode("kiosk-test-shop", now.Add(kioskCodePeriod))
+	other, _ := kioskCode("other-shop", now)
+	signature := other[strings.LastIndex(other, ".")+1:]
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr bool
+	}{
+		{"the current code", code, false},
+		{"the previous code is still accepted", previous, false},
+		{"an expired code", stale, true},
+		{"a code from the future", future, true},
+		{"another shop's signature", code[:strings.LastIndex(code, ".")+1] + signature, true},
+		{"a changed period", strings.Replace(code, ".", ".1", 1), true},
+		{"no signature", "kiosk-test-shop.123", true},
+		{"garbage", "kiosk", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shopID, err := verifyKioskCode(test.code, now)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, want error: %v", err, test.wantErr)
+			}
+			if err == nil && shopID != "kiosk-test-shop" {
+				t.Errorf("shop = %s, want kiosk-test-shop", shopID)
+			}
+		})
+	}
+}
@@ -0,0 +1,606 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const leaveRequestsFile = "leave_requests_data.json"
+
+const (
+	leaveStatusPending   = "pending"
+	leaveStatusApproved  = "approved"
+	leaveStatusRejected  = "rejected"
+	leaveStatusCancelled = "cancelled"
+
+	leaveSyncWritten = "written"
+	leaveSyncPending = "pending"
+	leaveSyncFailed  = "failed"
+)
+
+// leaveTypeCodes maps each leave type to the code written into the schedule cells
+var leaveTypeCodes = map[string]string{
+	"annual":     "UW",
+	"on_demand":  "UŻ",
+	"sick":       "L4",
+	"unpaid":     "UB",
+	"occasional": "UO",
+}
+
+type LeaveEvent struct {
+	At      time.Time `json:"at"`
+	By      string    `json:"by"`
+	Action  string    `json:"action"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+type LeaveRequest struct {
+	ID            string            `json:"id"`
+	EmployeeEmail string            `json:"employee_email"`
+	EmployeeName  string            `json:"employee_name"`
+	StartDate     string            `json:"start_date"`
+	EndDate       string            `json:"end_date"`
+	Type          string            `json:"type"`
+	Comment       string            `json:"comment,omitempty"`
+	Status        string            `json:"status"`
+	DecidedBy     string            `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time        `json:"decided_at,omitempty"`
+	ShopSync      map[string]string `json:"shop_sync,omitempty"` // shop_id -> written | pending | failed
+	History       []LeaveEvent      `json:"history"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+type LeaveDecisionRequest struct {
+	ID       string `json:"id"`
+	Decision string `json:"decision"` // approve | reject
+	Comment  string `json:"comment"`
+}
+
+var (
+	leaveRequests      = make(map[string]LeaveRequest) // request_id -> request
+	leaveSyncing       = make(map[string]bool)         // request_id -> sheets are being written
+	leaveRequestsMutex sync.RWMutex
+)
+
+func saveLeaveRequestsData() error {
+	leaveRequestsMutex.RLock()
+	defer leaveRequestsMutex.RUnlock()
+	return writeJSONFileAtomic(leaveRequestsFile, leaveRequests)
+}
+
+func loadLeaveRequestsData() error {
+	leaveRequestsMutex.Lock()
+	defer leaveRequestsMutex.Unlock()
+	return readJSONFile(leaveRequestsFile, &leaveRequests)
+}
+
+// clone copies the request's map and slice, so the copy can be read or changed outside the lock
+func (request LeaveRequest) clone() LeaveRequest {
+	if request.ShopSync != nil {
+		shopSync := make(map[string]string, len(request.ShopSync))
+		for shopID, status := range request.ShopSync {
+			shopSync[shopID] = status
+		}
+		request.ShopSync = shopSync
+	}
+	request.History = append([]LeaveEvent(nil), request.History...)
+	return request
+}
+
+func getLeaveRequest(id string) (LeaveRequest, bool) {
+	leaveRequestsMutex.RLock()
+	defer leaveRequestsMutex.RUnlock()
+	request, exists := leaveRequests[id]
+	return request.clone(), exists
+}
+
+func storeLeaveRequest(request LeaveRequest) {
+	leaveRequestsMutex.Lock()
+	leaveRequests[request.ID] = request.clone()
+	leaveRequestsMutex.Unlock()
+
+	go saveLeaveRequestsData()
+}
+
+// updatePendingLeaveRequest applies a decision or cancellation to the stored request, only if it
+// is still pending. A request being approved is marked as syncing until its sheets are written.
+func updatePendingLeaveRequest(id string, update func(*LeaveRequest)) (LeaveRequest, error) {
+	leaveRequestsMutex.Lock()
+	request, exists := leaveRequests[id]
+	if !exists {
+		leaveRequestsMutex.Unlock()
+		return LeaveRequest{}, fmt.Errorf("leave request %s not found", id)
+	}
+	if request.Status != leaveStatusPending {
+		leaveRequestsMutex.Unlock()
+		return request.clone(), fmt.Errorf("leave request is already %s", request.Status)
+	}
+	request = request.clone()
+	update(&request)
+	leaveRequests[id] = request
+	if request.Status == leaveStatusApproved {
+		leaveSyncing[id] = true
+	}
+	leaveRequestsMutex.Unlock()
+
+	go saveLeaveRequestsData()
+	return request.clone(), nil
+}
+
+// setLeaveShopSync records the sheet status of one shop on the stored request. The map is
+// replaced rather than changed, so earlier copies of the request are left untouched.
+func setLeaveShopSync(id, shopID, status string) {
+	leaveRequestsMutex.Lock()
+	request, exists := leaveRequests[id]
+	if !exists || request.Status != leaveStatusApproved {
+		leaveRequestsMutex.Unlock()
+		return
+	}
+	request = request.clone()
+	if request.ShopSync == nil {
+		request.ShopSync = make(map[string]string)
+	}
+	request.ShopSync[shopID] = status
+	leaveRequests[id] = request
+	leaveRequestsMutex.Unlock()
+
+	go saveLeaveRequestsData()
+}
+
+// releaseLeaveSync lets the background sync pick the request up again
+func releaseLeaveSync(id string) {
+	leaveRequestsMutex.Lock()
+	delete(leaveSyncing, id)
+	leaveRequestsMutex.Unlock()
+}
+
+func (request LeaveRequest) dates() (time.Time, time.Time) {
+	start, _ := time.ParseInLocation(dateLayout, request.StartDate, time.Local)
+	end, _ := time.ParseInLocation(dateLayout, request.EndDate, time.Local)
+	return start, end
+}
+
+// leaveRequestsFor lists requests visible to the session, newest first
+func leaveRequestsFor(session Session, employeeFilter string) []LeaveRequest {
+	leaveRequestsMutex.RLock()
+	defer leaveRequestsMutex.RUnlock()
+
+	result := make([]LeaveRequest, 0)
+	for _, request := range leaveRequests {
+		if session.Role == "employee" {
+			if normalizeEmail(request.EmployeeEmail) != normalizeEmail(session.UserInfo.Email) {
+				continue
+			}
+		} else if len(employerEmploymentsFor(session.UserInfo.Email, request.EmployeeEmail)) == 0 {
+			continue
+		}
+		if employeeFilter != "" && normalizeEmail(request.EmployeeEmail) != normalizeEmail(employeeFilter) {
+			continue
+		}
+		result = append(result, request.clone())
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result
+}
+
+// writeColumnSegment writes one column of a month grid between two row indexes (inclusive)
+func writeColumnSegment(ctx context.Context, service *SpreadsheetService, spreadsheetID, month string, data [][]interface{}, column, fromRow, toRow int) error {
+	values := make([][]interface{}, 0, toRow-fromRow+1)
+	for rowIndex := fromRow; rowIndex <= toRow; rowIndex++ {
+		values = append(values, []interface{}{cellString(data[rowIndex], column)})
+	}
+	letter := columnName(column)
+	sheetRange := fmt.Sprintf("%s!%s%d:%s%d", month, letter, fromRow+1, letter, toRow+1)
+	return service.WriteSpreadsheetData(ctx, spreadsheetID, sheetRange, values)
+}
+
+// writeLeaveToShop writes the leave code into the employee's column for every working day of
+// the range. Days already marked as DW stay days off. Totals are recalculated for each month.
+func writeLeaveToShop(ctx context.Context, service *SpreadsheetService, shop Shop, employeeEmail string, start, end time.Time, code, actor string) error {
+	shopEmail, _, exists := shopEmployee(shop, employeeEmail)
+	if !exists {
+		return fmt.Errorf("employee %s not found in shop %s", employeeEmail, shop.ID)
+	}
+
+	for monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local); !monthStart.After(end); monthStart = monthStart.AddDate(0, 1, 0) {
+		if err := writeLeaveToMonth(ctx, service, shop, shopEmail, monthStart, start, end, code, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLeaveToMonth writes the leave days falling into one month, holding the month's lock
+func writeLeaveToMonth(ctx context.Context, service *SpreadsheetService, shop Shop, shopEmail string, monthStart, start, end time.Time, code, actor string) error {
+	year := monthStart.Year()
+	month := polishMonths[monthStart.Month()-1]
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		log.Printf("Shop %s has no spreadsheet for %d, skipping leave for %s", shop.ID, year, month)
+		return nil
+	}
+
+	unlock := lockScheduleMonth(shop.ID, month, year)
+	defer unlock()
+
+	if err := checkMonthOpen(shop.ID, month, year); err != nil {
+		return err
+	}
+
+	data, err := service.ReadMonthSchedule(ctx, spreadsheetID, month)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	before := cloneGrid(data)
+
+	column := -1
+	for index, email := range scheduleColumns(data[0], shop.Employees) {
+		if email == shopEmail {
+			column = index
+		}
+	}
+	if column == -1 {
+		return fmt.Errorf("no column for %s in %s %d", shopEmail, month, year)
+	}
+
+	firstRow, lastRow := -1, -1
+	for rowIndex := 1; rowIndex < len(data); rowIndex++ {
+		day, ok := parseDayCell(cellString(data[rowIndex], 0))
+		if !ok {
+			continue
+		}
+		date := time.Date(year, monthStart.Month(), day, 0, 0, 0, 0, time.Local)
+		// Weekends and public holidays are not taken as leave, like in computeLeaveBalance
+		if date.Before(start) || date.After(end) || !isWorkingDay(date) {
+			continue
+		}
+		for len(data[rowIndex]) <= column {
+			data[rowIndex] = append(data[rowIndex], "")
+		}
+		if strings.ToUpper(cellString(data[rowIndex], column)) == "DW" {
+			continue
+		}
+		data[rowIndex][column] = code
+		if firstRow == -1 {
+			firstRow = rowIndex
+		}
+		lastRow = rowIndex
+	}
+	if firstRow == -1 {
+		return nil
+	}
+
+	data = applyScheduleTotals(data, shop, month, year)
+	for rowIndex := lastRow + 1; rowIndex < len(data); rowIndex++ {
+		if first := cellString(data[rowIndex], 0); first == "SUMA GODZIN" || first == "WYPŁATA" {
+			lastRow = rowIndex
+		}
+	}
+
+	if err := writeColumnSegment(ctx, service, spreadsheetID, month, data, column, firstRow, lastRow); err != nil {
+		return err
+	}
+	indexMonthSchedule(shop, month, year, data)
+	recordScheduleVersion(shop, before, data, ScheduleVersion{Month: month, Year: year, Action: "leave", Actor: actor})
+	return nil
+}
+
+// applyLeaveToShops writes approved leave into the approver's shops. Shops of other employers
+// are queued for the background sync, which writes them with their owner's own Google session.
+func applyLeaveToShops(ctx context.Context, session Session, request LeaveRequest) {
+	defer releaseLeaveSync(request.ID)
+
+	start, end := request.dates()
+	code := leaveTypeCodes[request.Type]
+
+	for _, owned := range personShops(request.EmployeeEmail) {
+		if owned.Owner != session.UserInfo.Email {
+			setLeaveShopSync(request.ID, owned.Shop.ID, leaveSyncPending)
+			notify([]string{owned.Owner}, "leave_sync_pending",
+				fmt.Sprintf("Approved leave of %s (%s - %s) will be written to %s while you are signed in", request.EmployeeName, request.StartDate, request.EndDate, owned.Shop.Name),
+				request.ID)
+			continue
+		}
+
+		service, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+		if err != nil {
+			log.Printf("Error getting spreadsheet service for leave %s: %v", request.ID, err)
+			setLeaveShopSync(request.ID, owned.Shop.ID, leaveSyncPending)
+			continue
+		}
+		if err := writeLeaveToShop(ctx, service, owned.Shop, request.EmployeeEmail, start, end, code, request.DecidedBy); err != nil {
+			log.Printf("Error writing leave %s to shop %s: %v", request.ID, owned.Shop.ID, err)
+			setLeaveShopSync(request.ID, owned.Shop.ID, leaveSyncFailed)
+			continue
+		}
+		setLeaveShopSync(request.ID, owned.Shop.ID, leaveSyncWritten)
+	}
+}
+
+// syncPendingLeave writes approved leave still missing from a shop's sheet. Every shop is
+// written with its owner's Google session, shops of signed-out owners wait for the next run.
+func syncPendingLeave(ctx context.Context) {
+	leaveRequestsMutex.Lock()
+	var pending []LeaveRequest
+	for id, request := range leaveRequests {
+		if request.Status != leaveStatusApproved || leaveSyncing[id] {
+			continue
+		}
+		for _, status := range request.ShopSync {
+			if status != leaveSyncWritten {
+				leaveSyncing[id] = true
+				pending = append(pending, request.clone())
+				break
+			}
+		}
+	}
+	leaveRequestsMutex.Unlock()
+
+	for _, request := range pending {
+		start, end := request.dates()
+		for shopID, status := range request.ShopSync {
+			if status == leaveSyncWritten {
+				continue
+			}
+			owner, shop, exists := shopOwner(shopID)
+			if !exists {
+				continue
+			}
+			service, ok := cachedSpreadsheetService(owner)
+			if !ok {
+				continue
+			}
+			if err := writeLeaveToShop(ctx, service, shop, request.EmployeeEmail, start, end, leaveTypeCodes[request.Type], request.DecidedBy); err != nil {
+				log.Printf("Error syncing leave %s to shop %s: %v", request.ID, shopID, err)
+				setLeaveShopSync(request.ID, shopID, leaveSyncFailed)
+				continue
+			}
+			setLeaveShopSync(request.ID, shopID, leaveSyncWritten)
+			log.Printf("Synced approved leave %s for %s to shop %s", request.ID, request.EmployeeEmail, shopID)
+		}
+		releaseLeaveSync(request.ID)
+	}
+}
+
+func handleLeave(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status := r.URL.Query().Get("status")
+		requests := leaveRequestsFor(session, r.URL.Query().Get("employee_email"))
+		filtered := make([]LeaveRequest, 0, len(requests))
+		for _, request := range requests {
+			if status == "" || request.Status == status {
+				filtered = append(filtered, request)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"requests": filtered})
+
+	case http.MethodPost:
+		if session.Role != "employee" {
+			http.Error(w, "Only employees can file leave requests", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			StartDate string `json:"start_date"`
+			EndDate   string `json:"end_date"`
+			Type      string `json:"type"`
+			Comment   string `json:"comment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if _, known := leaveTypeCodes[req.Type]; !known {
+			http.Error(w, "Unknown leave type", http.StatusBadRequest)
+			return
+		}
+		start, err := time.Parse(dateLayout, req.StartDate)
+		if err != nil {
+			http.Error(w, "Invalid start date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		end, err := time.Parse(dateLayout, req.EndDate)
+		if err != nil {
+			http.Error(w, "Invalid end date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if end.Before(start) {
+			http.Error(w, "End date cannot be before the start date", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		request := LeaveRequest{
+			ID:            generateRandomString(16),
+			EmployeeEmail: normalizeEmail(session.UserInfo.Email),
+			EmployeeName:  session.UserInfo.Name,
+			StartDate:     req.StartDate,
+			EndDate:       req.EndDate,
+			Type:          req.Type,
+			Comment:       strings.TrimSpace(req.Comment),
+			Status:        leaveStatusPending,
+			History: []LeaveEvent{{
+				At:      now,
+				By:      session.UserInfo.Email,
+				Action:  "filed",
+				Comment: strings.TrimSpace(req.Comment),
+			}},
+			CreatedAt: now,
+		}
+		if person, exists := getPerson(session.UserInfo.Email); exists && person.Name != "" {
+			request.EmployeeName = person.Name
+		}
+		storeLeaveRequest(request)
+
+		notify(findEmployersForEmployee(session.UserInfo.Email), "leave_requested",
+			fmt.Sprintf("%s requested leave (%s) from %s to %s", request.EmployeeName, request.Type, request.StartDate, request.EndDate),
+			request.ID)
+
+		log.Printf("Employee %s filed leave request %s", session.UserInfo.Email, request.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(request)
+
+	case http.MethodDelete:
+		if session.Role != "employee" {
+			http.Error(w, "Only employees can cancel their leave requests", http.StatusForbidden)
+			return
+		}
+
+		request, exists := getLeaveRequest(r.URL.Query().Get("id"))
+		if !exists || normalizeEmail(request.EmployeeEmail) != normalizeEmail(session.UserInfo.Email) {
+			http.Error(w, "Leave request not found", http.StatusNotFound)
+			return
+		}
+		request, err := updatePendingLeaveRequest(request.ID, func(request *LeaveRequest) {
+			request.Status = leaveStatusCancelled
+			request.History = append(request.History, LeaveEvent{At: time.Now(), By: session.UserInfo.Email, Action: "cancelled"})
+		})
+		if err != nil {
+			http.Error(w, "Only pending leave requests can be cancelled", http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(request)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleLeaveDecision(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can decide on leave requests", http.StatusForbidden)
+		return
+	}
+
+	var req LeaveDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Decision != "approve" && req.Decision != "reject" {
+		http.Error(w, "Decision must be approve or reject", http.StatusBadRequest)
+		return
+	}
+
+	request, exists := getLeaveRequest(req.ID)
+	if !exists || len(employerEmploymentsFor(session.UserInfo.Email, request.EmployeeEmail)) == 0 {
+		http.Error(w, "Leave request not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	request, err := updatePendingLeaveRequest(request.ID, func(request *LeaveRequest) {
+		request.DecidedBy = session.UserInfo.Email
+		request.DecidedAt = &now
+		if req.Decision == "approve" {
+			request.Status = leaveStatusApproved
+		} else {
+			request.Status = leaveStatusRejected
+		}
+		request.History = append(request.History, LeaveEvent{
+			At:      now,
+			By:      session.UserInfo.Email,
+			Action:  request.Status,
+			Comment: strings.TrimSpace(req.Comment),
+		})
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Leave request is already %s", request.Status), http.StatusConflict)
+		return
+	}
+
+	if request.Status == leaveStatusApproved {
+		applyLeaveToShops(r.Context(), session, request)
+		request, _ = getLeaveRequest(request.ID)
+	}
+
+	message := fmt.Sprintf("Your leave from %s to %s was %s", request.StartDate, request.EndDate, request.Status)
+	if comment := strings.TrimSpace(req.Comment); comment != "" {
+		message += ": " + comment
+	}
+	notify([]string{request.EmployeeEmail}, "leave_"+request.Status, message, request.ID)
+
+	log.Printf("Leave request %s %s by %s", request.ID, request.Status, session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// handleLeaveHistory returns every leave request with its full event history
+func handleLeaveHistory(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" && session.Role != "employee" {
+		http.Error(w, "Unauthorized", http.StatusForbidden)
+		return
+	}
+
+	requests := leaveRequestsFor(session, r.URL.Query().Get("employee_email"))
+	if year := r.URL.Query().Get("year"); year != "" {
+		filtered := make([]LeaveRequest, 0, len(requests))
+		for _, request := range requests {
+			if strings.HasPrefix(request.StartDate, year) || strings.HasPrefix(request.EndDate, year) {
+				filtered = append(filtered, request)
+			}
+		}
+		requests = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"requests": requests})
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const leaveBalancesFile = "leave_balances_data.json"
+
+const (
+	annualLeaveCode   = "UW"
+	onDemandLeaveCode = "UŻ"
+)
+
+// LeaveEntitlement is the leave an employer grants an employee for one year, in days
+type LeaveEntitlement struct {
+	AnnualDays      float64   `json:"annual_days"`
+	OnDemandDays    float64   `json:"on_demand_days"`
+	CarriedOverDays float64   `json:"carried_over_days"`
+	UpdatedBy       string    `json:"updated_by,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type LeaveDay struct {
+	Date   string `json:"date"`
+	Code   string `json:"code"`
+	Source string `json:"source"` // request | schedule
+}
+
+// LeaveBalance shows how much of a year's entitlement is left. On-demand leave (UŻ) is
+// part of annual leave, so it counts against both limits.
+type LeaveBalance struct {
+	Employer          string     `json:"employer"`
+	EmployeeEmail     string     `json:"employee_email"`
+	Year              int        `json:"year"`
+	AnnualDays        float64    `json:"annual_days"`
+	CarriedOverDays   float64    `json:"carried_over_days"`
+	AnnualUsed        float64    `json:"annual_used"`
+	AnnualRemaining   float64    `json:"annual_remaining"`
+	OnDemandDays      float64    `json:"on_demand_days"`
+	OnDemandUsed      float64    `json:"on_demand_used"`
+	OnDemandRemaining float64    `json:"on_demand_remaining"`
+	Days              []LeaveDay `json:"days"`
+}
+
+// LeaveEntitlementRequest sets a year's entitlement. Annual and on-demand days left out of the
+// body get the statutory defaults, an explicit 0 is kept.
+type LeaveEntitlementRequest struct {
+	EmployeeEmail   string   `json:"employee_email"`
+	Year            int      `json:"year"`
+	AnnualDays      *float64 `json:"annual_days"`
+	OnDemandDays    *float64 `json:"on_demand_days"`
+	CarriedOverDays float64  `json:"carried_over_days"`
+	SeniorityYears  int      `json:"seniority_years"`
+}
+
+var (
+	leaveEntitlements      = make(map[string]map[string]map[int]LeaveEntitlement) // employer -> employee -> year -> entitlement
+	leaveEntitlementsMutex sync.RWMutex
+)
+
+func saveLeaveBalancesData() error {
+	leaveEntitlementsMutex.RLock()
+	defer leaveEntitlementsMutex.RUnlock()
+	return writeJSONFileAtomic(leaveBalancesFile, leaveEntitlements)
+}
+
+func loadLeaveBalancesData() error {
+	leaveEntitlementsMutex.Lock()
+	defer leaveEntitlementsMutex.Unlock()
+	return readJSONFile(leaveBalancesFile, &leaveEntitlements)
+}
+
+// statutoryAnnualLeaveDays is 20 days below 10 years of seniority and 26 days from then on
+func statutoryAnnualLeaveDays(seniorityYears int) float64 {
+	if seniorityYears >= 10 {
+		return 26
+	}
+	return 20
+}
+
+func getLeaveEntitlement(employerEmail, employeeEmail string, year int) LeaveEntitlement {
+	leaveEntitlementsMutex.RLock()
+	defer leaveEntitlementsMutex.RUnlock()
+
+	if entitlement, exists := leaveEntitlements[employerEmail][normalizeEmail(employeeEmail)][year]; exists {
+		return entitlement
+	}
+	return LeaveEntitlement{AnnualDays: statutoryAnnualLeaveDays(0), OnDemandDays: 4}
+}
+
+func setLeaveEntitlement(employerEmail, employeeEmail string, year int, entitlement LeaveEntitlement) {
+	leaveEntitlementsMutex.Lock()
+	key := normalizeEmail(employeeEmail)
+	if leaveEntitlements[employerEmail] == nil {
+		leaveEntitlements[employerEmail] = make(map[string]map[int]LeaveEntitlement)
+	}
+	if leaveEntitlements[employerEmail][key] == nil {
+		leaveEntitlements[employerEmail][key] = make(map[int]LeaveEntitlement)
+	}
+	leaveEntitlements[employerEmail][key][year] = entitlement
+	leaveEntitlementsMutex.Unlock()
+
+	go saveLeaveBalancesData()
+}
+
+func isWorkingDay(date time.Time) bool {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	return !isPublicHoliday(date)
+}
+
+// computeLeaveBalance counts working days of approved annual and on-demand leave, plus leave
+// codes found in the employer's schedules on days not already covered by a request
+func computeLeaveBalance(employerEmail, employeeEmail string, year int) LeaveBalance {
+	entitlement := getLeaveEntitlement(employerEmail, employeeEmail, year)
+	used := make(map[string]LeaveDay)
+
+	leaveRequestsMutex.RLock()
+	for _, request := range leaveRequests {
+		if request.Status != leaveStatusApproved || normalizeEmail(request.EmployeeEmail) != normalizeEmail(employeeEmail) {
+			continue
+		}
+		code := leaveTypeCodes[request.Type]
+		if code != annualLeaveCode && code != onDemandLeaveCode {
+			continue
+		}
+		start, end := request.dates()
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if day.Year() == year && isWorkingDay(day) {
+				used[day.Format(dateLayout)] = LeaveDay{Date: day.Format(dateLayout), Code: code, Source: "request"}
+			}
+		}
+	}
+	leaveRequestsMutex.RUnlock()
+
+	for _, shop := range employerEmploymentsFor(employerEmail, employeeEmail) {
+		for _, absence := range indexedAbsences(shop.ID, employeeEmail, year) {
+			if absence.Code != annualLeaveCode && absence.Code != onDemandLeaveCode {
+				continue
+			}
+			if _, counted := used[absence.Date]; counted {
+				continue
+			}
+			date, err := time.ParseInLocation(dateLayout, absence.Date, time.Local)
+			if err != nil || !isWorkingDay(date) {
+				continue
+			}
+			used[absence.Date] = LeaveDay{Date: absence.Date, Code: absence.Code, Source: "schedule"}
+		}
+	}
+
+	balance := LeaveBalance{
+		Employer:        employerEmail,
+		EmployeeEmail:   normalizeEmail(employeeEmail),
+		Year:            year,
+		AnnualDays:      entitlement.AnnualDays,
+		CarriedOverDays: entitlement.CarriedOverDays,
+		OnDemandDays:    entitlement.OnDemandDays,
+		Days:            make([]LeaveDay, 0, len(used)),
+	}
+	for _, day := range used {
+		balance.AnnualUsed++
+		if day.Code == onDemandLeaveCode {
+			balance.OnDemandUsed++
+		}
+		balance.Days = append(balance.Days, day)
+	}
+	sort.Slice(balance.Days, func(i, j int) bool { return balance.Days[i].Date < balance.Days[j].Date })
+
+	balance.AnnualRemaining = balance.AnnualDays + balance.CarriedOverDays - balance.AnnualUsed
+	balance.OnDemandRemaining = balance.OnDemandDays - balance.OnDemandUsed
+	if balance.OnDemandRemaining > balance.AnnualRemaining {
+		balance.OnDemandRemaining = balance.AnnualRemaining
+	}
+	if balance.OnDemandRemaining < 0 {
+		balance.OnDemandRemaining = 0
+	}
+	return balance
+}
+
+// employerPeople lists the distinct employees across all shops of an employer
+func employerPeople(employerEmail string) []string {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	seen := make(map[string]bool)
+	var emails []string
+	for _, shop := range employerShops[employerEmail] {
+		for email := range shop.Employees {
+			key := normalizeEmail(email)
+			if !seen[key] {
+				seen[key] = true
+				emails = append(emails, key)
+			}
+		}
+	}
+	sort.Strings(emails)
+	return emails
+}
+
+func handleLeaveBalance(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		year := parseYearParam(r)
+		balances := make([]LeaveBalance, 0)
+
+		switch session.Role {
+		case "employer":
+			employees := employerPeople(session.UserInfo.Email)
+			if filter := r.URL.Query().Get("employee_email"); filter != "" {
+				if len(employerEmploymentsFor(session.UserInfo.Email, filter)) == 0 {
+					http.Error(w, "Employee not found in your shops", http.StatusNotFound)
+					return
+				}
+				employees = []string{normalizeEmail(filter)}
+			}
+			for _, email := range employees {
+				balances = append(balances, computeLeaveBalance(session.UserInfo.Email, email, year))
+			}
+		case "employee":
+			for _, employer := range uniqueStrings(findEmployersForEmployee(session.UserInfo.Email)) {
+				balances = append(balances, computeLeaveBalance(employer, session.UserInfo.Email, year))
+			}
+		default:
+			http.Error(w, "Unauthorized", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"balances": balances})
+
+	case http.MethodPut:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can set leave entitlements", http.StatusForbidden)
+			return
+		}
+
+		var req LeaveEntitlementRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.EmployeeEmail == "" || req.Year == 0 {
+			http.Error(w, "Employee email and year are required", http.StatusBadRequest)
+			return
+		}
+		if (req.AnnualDays != nil && *req.AnnualDays < 0) || (req.OnDemandDays != nil && *req.OnDemandDays < 0) || req.CarriedOverDays < 0 {
+			http.Error(w, "Leave days cannot be negative", http.StatusBadRequest)
+			return
+		}
+		if len(employerEmploymentsFor(session.UserInfo.Email, req.EmployeeEmail)) == 0 {
+			http.Error(w, "Employee not found in your shops", http.StatusNotFound)
+			return
+		}
+
+		entitlement := LeaveEntitlement{
+			AnnualDays:      statutoryAnnualLeaveDays(req.SeniorityYears),
+			OnDemandDays:    4,
+			CarriedOverDays: req.CarriedOverDays,
+			UpdatedBy:       session.UserInfo.Email,
+			UpdatedAt:       time.Now(),
+		}
+		if req.AnnualDays != nil {
+			entitlement.AnnualDays = *req.AnnualDays
+		}
+		if req.OnDemandDays != nil {
+			entitlement.OnDemandDays = *req.OnDemandDays
+		}
+		setLeaveEntitlement(session.UserInfo.Email, req.EmployeeEmail, req.Year, entitlement)
+
+		log.Printf("Set %d leave entitlement for %s by %s", req.Year, req.EmployeeEmail, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(computeLeaveBalance(session.UserInfo.Email, req.EmployeeEmail, req.Year))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLeaveCarryOver moves unused annual leave of a year into the next year's entitlement
+func handleLeaveCarryOver(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can carry over leave", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		EmployeeEmail string `json:"employee_email"`
+		Year          int    `json:"year"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Year == 0 {
+		http.Error(w, "Year is required", http.StatusBadRequest)
+		return
+	}
+
+	employees := employerPeople(session.UserInfo.Email)
+	if req.EmployeeEmail != "" {
+		if len(employerEmploymentsFor(session.UserInfo.Email, req.EmployeeEmail)) == 0 {
+			http.Error(w, "Employee not found in your shops", http.StatusNotFound)
+			return
+		}
+		employees = []string{normalizeEmail(req.EmployeeEmail)}
+	}
+
+	balances := make([]LeaveBalance, 0, len(employees))
+	for _, email := range employees {
+		remaining := computeLeaveBalance(session.UserInfo.Email, email, req.Year).AnnualRemaining
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		next := getLeaveEntitlement(session.UserInfo.Email, email, req.Year+1)
+		next.CarriedOverDays = remaining
+		next.UpdatedBy = session.UserInfo.Email
+		next.UpdatedAt = time.Now()
+		setLeaveEntitlement(session.UserInfo.Email, email, req.Year+1, next)
+
+		balances = append(balances, computeLeaveBalance(session.UserInfo.Email, email, req.Year+1))
+	}
+
+	log.Printf("Carried over %d leave for %d employees by %s", req.Year, len(balances), session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"balances": balances})
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -67,19 +67,39 @@ type UserWithRole struct {
 	Role string `json:"role"`
 }
 
+// Employee is the per-shop employment record, linked to the global Person by email
 type Employee struct {
-	Email      string  `json:"email"`
-	Name       string  `json:"name"`
-	HourlyRate float64 `json:"hourly_rate"`
+	Email           string             `json:"email"`
+	Name            string             `json:"name"`
+	HourlyRate      float64            `json:"hourly_rate"`
+	Position        string             `json:"position,omitempty"`
+	StartDate       string             `json:"start_date,omitempty"`       // YYYY-MM-DD
+	EndDate         string             `json:"end_date,omitempty"`         // YYYY-MM-DD
+	ContractedHours float64            `json:"contracted_hours,omitempty"` // monthly, used by the schedule generator
+	Contract        EmploymentContract `json:"contract"`
 }
 
 type Shop struct {
-	ID           string              `json:"id"`
-	Name         string              `json:"name"`
-	Employees    map[string]Employee `json:"employees"`
-	Spreadsheets map[int]string      `json:"spreadsheets"` // year -> spreadsheet_id
-	CreatedAt    time.Time           `json:"created_at"`
-	UpdatedAt    time.Time           `json:"updated_at"`
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Employees      map[string]Employee   `json:"employees"`
+	Spreadsheets   map[int]string        `json:"spreadsheets"` // year -> spreadsheet_id
+	PremiumRules   *PremiumRules         `json:"premium_rules,omitempty"`
+	AbsenceCodes   []AbsenceCode         `json:"absence_codes,omitempty"`
+	Settings       ShopSettings          `json:"settings"`
+	Coverage       []CoverageRequirement `json:"coverage,omitempty"`
+	Hours          *ShopHours            `json:"opening_hours,omitempty"`
+	ShiftTemplates []ShiftTemplate       `json:"shift_templates,omitempty"` // every version of every code
+	Rotations      []RotationPattern     `json:"rotations,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// ShopSettings holds per-shop workflow options
+type ShopSettings struct {
+	AutoApproveSwaps bool   `json:"auto_approve_swaps"`
+	OpenShiftMode    string `json:"open_shift_mode,omitempty"` // first_come (default) | approval
+	KioskOnly        bool   `json:"kiosk_only"`                // employees clock in only by scanning the shop's kiosk
 }
 
 type ShopRequest struct {
@@ -87,10 +107,15 @@ type ShopRequest struct {
 }
 
 type EmployeeManagementRequest struct {
-	ShopID        string  `json:"shop_id"`
-	EmployeeEmail string  `json:"employee_email"`
-	EmployeeName  string  `json:"employee_name"`
-	HourlyRate    float64 `json:"hourly_rate"`
+	ShopID          string              `json:"shop_id"`
+	EmployeeEmail   string              `json:"employee_email"`
+	EmployeeName    string              `json:"employee_name"`
+	HourlyRate      float64             `json:"hourly_rate"`
+	Position        string              `json:"position"`
+	StartDate       string              `json:"start_date"`
+	EndDate         string              `json:"end_date"`
+	ContractedHours float64             `json:"contracted_hours"`
+	Contract        *EmploymentContract `json:"contract,omitempty"` // nil keeps the current contract
 }
 
 type SpreadsheetService struct {
@@ -220,6 +245,45 @@ func loadEmployeeShopsData() error {
 	return json.Unmarshal(data, &employeeShops)
 }
 
+// Generic atomic JSON persistence used by the newer data files
+func writeJSONFileAtomic(fileName string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %v", fileName, err)
+	}
+
+	tempFile := fileName + ".tmp"
+	if err := ioutil.WriteFile(tempFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temp file: %v", err)
+	}
+
+	if err := os.Rename(tempFile, fileName); err != nil {
+		os.Remove(tempFile) // Cleanup on failure
+		return fmt.Errorf("failed to rename temp file: %v", err)
+	}
+
+	return nil
+}
+
+func readJSONFile(fileName string, v interface{}) error {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		log.Printf("No existing %s file found, starting with empty data", fileName)
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", fileName, err)
+	}
+
+	if len(data) == 0 {
+		log.Printf("Empty %s file, starting with empty data", fileName)
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
 func generateRandomString(length int) string {
 	bytes := make([]byte, length)
 	if _, err := rand.Read(bytes); err != nil {
@@ -319,6 +383,65 @@ func getCurrentMonth() string {
 	return months[time.Now().Month()-1]
 }
 
+// requireSession resolves the session cookie and refreshes its last used time.
+// It writes the error response itself and returns false when the request is not authenticated.
+func requireSession(w http.ResponseWriter, r *http.Request) (Session, bool) {
+	cookie, err := r.Cookie("session_id")
+	if err != nil {
+		http.Error(w, "Not authenticated", http.StatusUnauthorized)
+		return Session{}, false
+	}
+
+	sessionsMutex.Lock()
+	defer sessionsMutex.Unlock()
+
+	session, exists := sessions[cookie.Value]
+	if !exists {
+		http.Error(w, "Invalid session", http.StatusUnauthorized)
+		return Session{}, false
+	}
+
+	session.LastUsed = time.Now()
+	sessions[cookie.Value] = session
+	return session, true
+}
+
+func getEmployerShop(employerEmail, shopID string) (Shop, bool) {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	if employerShops[employerEmail] == nil {
+		return Shop{}, false
+	}
+	shop, exists := employerShops[employerEmail][shopID]
+	return shop, exists && shop.ID != ""
+}
+
+// findShopForEmployee returns the shop and its owner if the employee works there
+func findShopForEmployee(employeeEmail, shopID string) (string, Shop, bool) {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	for employer, shops := range employerShops {
+		if shopData, exists := shops[shopID]; exists {
+			if _, hasAccess := shopData.Employees[employeeEmail]; hasAccess {
+				return employer, shopData, true
+			}
+		}
+	}
+	return "", Shop{}, false
+}
+
+func parseYearParam(r *http.Request) int {
+	year := time.Now().Year()
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		if parsedYear, err := strconv.Atoi(yearParam); err == nil {
+			year = parsedYear
+		}
+	}
+	return year
+}
+
 // Session cleanup routine
 func cleanupExpiredSessions() {
 	sessionsMutex.Lock()
@@ -346,6 +469,21 @@ func startSessionCleanup() {
 	}()
 }
 
+// startPendingSheetSync periodically writes approved leave and first-come open shifts that
+// could not reach a shop's sheet yet
+func startPendingSheetSync() {
+	const interval = 5 * time.Minute
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			syncPendingLeave(ctx)
+			syncPendingOpenShifts(ctx)
+			cancel()
+		}
+	}()
+}
+
 func init() {
 	oauthStateString = generateRandomString(32)
 	googleOauthConfig = &oauth2.Config{
@@ -368,6 +506,60 @@ func init() {
 	if err := loadEmployeeShopsData(); err != nil {
 		log.Printf("Error loading employee shops data: %v", err)
 	}
+	if err := loadRateHistoryData(); err != nil {
+		log.Printf("Error loading rate history data: %v", err)
+	}
+	if err := loadPeopleData(); err != nil {
+		log.Printf("Error loading people data: %v", err)
+	}
+	if err := loadShiftIndexData(); err != nil {
+		log.Printf("Error loading shift index data: %v", err)
+	}
+	if err := loadAbsenceIndexData(); err != nil {
+		log.Printf("Error loading absence index data: %v", err)
+	}
+	if err := loadAvailabilityData(); err != nil {
+		log.Printf("Error loading availability data: %v", err)
+	}
+	if err := loadLeaveRequestsData(); err != nil {
+		log.Printf("Error loading leave requests data: %v", err)
+	}
+	if err := loadNotificationsData(); err != nil {
+		log.Printf("Error loading notifications data: %v", err)
+	}
+	if err := loadLeaveBalancesData(); err != nil {
+		log.Printf("Error loading leave balances data: %v", err)
+	}
+	if err := loadAuditLogData(); err != nil {
+		log.Printf("Error loading audit log data: %v", err)
+	}
+	if err := loadShiftSwapsData(); err != nil {
+		log.Printf("Error loading shift swaps data: %v", err)
+	}
+	if err := loadOpenShiftsData(); err != nil {
+		log.Printf("Error loading open shifts data: %v", err)
+	}
+	if err := loadScheduleDraftsData(); err != nil {
+		log.Printf("Error loading schedule drafts data: %v", err)
+	}
+	if err := loadSchedulePublicationsData(); err != nil {
+		log.Printf("Error loading schedule publications data: %v", err)
+	}
+	if err := loadScheduleVersionsData(); err != nil {
+		log.Printf("Error loading schedule versions data: %v", err)
+	}
+	if err := loadMonthClosesData(); err != nil {
+		log.Printf("Error loading month closes data: %v", err)
+	}
+	if err := loadPayrollCorrectionsData(); err != nil {
+		log.Printf("Error loading payroll corrections data: %v", err)
+	}
+	if err := loadTimeEntriesData(); err != nil {
+		log.Printf("Error loading time entries data: %v", err)
+	}
+	if err := loadKioskTokensData(); err != nil {
+		log.Printf("Error loading kiosk tokens data: %v", err)
+	}
 
 	// Initialize with default data if no data exists
 	if len(employerShops) == 0 {
@@ -391,11 +583,15 @@ func init() {
 		saveEmployeeShopsData()
 	}
 
+	syncPeopleFromShops()
+	followMinimumWageTable()
+
 	log.Printf("Loaded shops data: %+v", employerShops)
 	log.Printf("Loaded employee shops data: %+v", employeeShops)
 
 	// Start session cleanup
 	startSessionCleanup()
+	startPendingSheetSync()
 }
 
 func NewSpreadsheetService(userEmail string) *SpreadsheetService {
@@ -443,6 +639,15 @@ func getOrCreateSpreadsheetService(userEmail string, token *oauth2.Token) (*Spre
 	return service, nil
 }
 
+// cachedSpreadsheetService returns the service of a user with an active Google session, if any
+func cachedSpreadsheetService(userEmail string) (*SpreadsheetService, bool) {
+	serviceCacheMutex.RLock()
+	defer serviceCacheMutex.RUnlock()
+
+	service, exists := serviceCache[userEmail]
+	return service, exists && service.initialized
+}
+
 func (s *SpreadsheetService) InitializeServices(ctx context.Context, token *oauth2.Token) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -574,7 +779,7 @@ func (s *SpreadsheetService) CreateWorkScheduleSpreadsheet(ctx context.Context,
 	log.Printf("Successfully created work schedule spreadsheet: %s", resp.SpreadsheetId)
 
 	// Initialize management sheet
-	if err := s.initializeManagementSheetUnsafe(ctx, resp.SpreadsheetId, employerEmail, shopID); err != nil {
+	if err := s.initializeManagementSheetUnsafe(ctx, resp.SpreadsheetId, employerEmail, shopID, year); err != nil {
 		log.Printf("Error initializing management sheet: %v", err)
 	}
 
@@ -603,7 +808,7 @@ func (s *SpreadsheetService) findSpreadsheetByNameUnsafe(ctx context.Context, fi
 	return spreadsheet, nil
 }
 
-func (s *SpreadsheetService) initializeManagementSheetUnsafe(ctx context.Context, spreadsheetID, employerEmail, shopID string) error {
+func (s *SpreadsheetService) initializeManagementSheetUnsafe(ctx context.Context, spreadsheetID, employerEmail, shopID string, year int) error {
 	employerShopsMutex.RLock()
 	shop := employerShops[employerEmail][shopID]
 	employerShopsMutex.RUnlock()
@@ -611,24 +816,19 @@ func (s *SpreadsheetService) initializeManagementSheetUnsafe(ctx context.Context
 	managementData := [][]interface{}{
 		{fmt.Sprintf("ZARZĄDZANIE PRACOWNIKAMI - GrafikZabka-%s", shop.Name)},
 		{""},
-		{"Email", "Imię i Nazwisko", "Stawka godzinowa (PLN)"},
+		{"Email", "Imię i Nazwisko", "Stawka godzinowa (PLN)", "Obowiązuje od", "Uwagi"},
 	}
 
-	for _, employee := range shop.Employees {
-		managementData = append(managementData, []interface{}{
-			employee.Email,
-			employee.Name,
-			employee.HourlyRate,
-		})
-	}
+	// A rate change within the year adds a row starting on its effective date
+	managementData = append(managementData, managementRateRows(shop, year)...)
 
-	return s.WriteSpreadsheetDataUnsafe(ctx, spreadsheetID, "MANAGEMENT!A1:C20", managementData)
+	return s.writeSheetGridUnsafe(ctx, spreadsheetID, "MANAGEMENT", managementData, true)
 }
 
-func (s *SpreadsheetService) initializeManagementSheet(ctx context.Context, spreadsheetID, employerEmail, shopID string) error {
+func (s *SpreadsheetService) initializeManagementSheet(ctx context.Context, spreadsheetID, employerEmail, shopID string, year int) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	return s.initializeManagementSheetUnsafe(ctx, spreadsheetID, employerEmail, shopID)
+	return s.initializeManagementSheetUnsafe(ctx, spreadsheetID, employerEmail, shopID, year)
 }
 
 func (s *SpreadsheetService) GetSpreadsheetById(ctx context.Context, spreadsheetID string) (*sheets.Spreadsheet, error) {
@@ -733,11 +933,113 @@ func (s *SpreadsheetService) WriteSpreadsheetDataUnsafe(ctx context.Context, spr
 	return nil
 }
 
+// Size of a sheet created without explicit grid properties
+const (
+	defaultSheetRows    = 1000
+	defaultSheetColumns = 26
+)
+
+// gridRange returns the A1 range covering a grid written from the top-left cell of a sheet
+func gridRange(title string, data [][]interface{}) string {
+	rows, columns := gridSize(data)
+	if columns == 0 {
+		columns = 1
+	}
+	return fmt.Sprintf("%s!A1:%s%d", title, columnName(columns-1), rows)
+}
+
+// sheetPropertiesUnsafe looks a sheet of a spreadsheet up by its title
+func (s *SpreadsheetService) sheetPropertiesUnsafe(ctx context.Context, spreadsheetID, title string) (*sheets.SheetProperties, error) {
+	spreadsheet, err := s.sheetsService.Spreadsheets.Get(spreadsheetID).Fields("sheets.properties").Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get spreadsheet details: %v", err)
+	}
+	for _, sheet := range spreadsheet.Sheets {
+		if sheet.Properties != nil && strings.EqualFold(sheet.Properties.Title, title) {
+			return sheet.Properties, nil
+		}
+	}
+	return nil, fmt.Errorf("sheet %s not found", title)
+}
+
+// ensureSheetSizeUnsafe grows a sheet so a grid of the given size fits in it. New sheets
+// stop at column Z, which a shop with more than 24 employees outgrows.
+func (s *SpreadsheetService) ensureSheetSizeUnsafe(ctx context.Context, spreadsheetID, title string, rows, columns int) error {
+	if rows <= defaultSheetRows && columns <= defaultSheetColumns {
+		return nil
+	}
+
+	properties, err := s.sheetPropertiesUnsafe(ctx, spreadsheetID, title)
+	if err != nil {
+		return err
+	}
+
+	var requests []*sheets.Request
+	if grid := properties.GridProperties; grid != nil {
+		if missing := int64(columns) - grid.ColumnCount; missing > 0 {
+			requests = append(requests, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+				SheetId:         properties.SheetId,
+				Dimension:       "COLUMNS",
+				Length:          missing,
+				ForceSendFields: []string{"SheetId"},
+			}})
+		}
+		if missing := int64(rows) - grid.RowCount; missing > 0 {
+			requests = append(requests, &sheets.Request{AppendDimension: &sheets.AppendDimensionRequest{
+				SheetId:         properties.SheetId,
+				Dimension:       "ROWS",
+				Length:          missing,
+				ForceSendFields: []string{"SheetId"},
+			}})
+		}
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+
+	_, err = s.sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, &sheets.BatchUpdateSpreadsheetRequest{Requests: requests}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("unable to resize sheet %s: %v", title, err)
+	}
+	return nil
+}
+
+// WriteSheetGrid writes a grid from the top-left cell of a sheet, growing the sheet first
+// when the grid is wider or longer than it
+func (s *SpreadsheetService) WriteSheetGrid(ctx context.Context, spreadsheetID, title string, data [][]interface{}) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.writeSheetGridUnsafe(ctx, spreadsheetID, title, data, false)
+}
+
+// writeSheetGridUnsafe writes a grid from the top-left cell of a sheet. With replace set the
+// sheet is cleared first, so nothing of a larger previous layout is left behind.
+func (s *SpreadsheetService) writeSheetGridUnsafe(ctx context.Context, spreadsheetID, title string, data [][]interface{}, replace bool) error {
+	rows, columns := gridSize(data)
+	if err := s.ensureSheetSizeUnsafe(ctx, spreadsheetID, title, rows, columns); err != nil {
+		return err
+	}
+	if replace {
+		_, err := s.sheetsService.Spreadsheets.Values.Clear(spreadsheetID, title, &sheets.ClearValuesRequest{}).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("unable to clear sheet %s: %v", title, err)
+		}
+	}
+	return s.WriteSpreadsheetDataUnsafe(ctx, spreadsheetID, gridRange(title, data), data)
+}
+
 // IMPROVED: Better handling of empty employees
 func (s *SpreadsheetService) CreateMonthlySchedule(ctx context.Context, spreadsheetID, month string, employees map[string]Employee, year int) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	scheduleData := buildMonthGrid(month, year, employees)
+	return s.writeSheetGridUnsafe(ctx, spreadsheetID, month, scheduleData, true)
+}
+
+// buildMonthGrid lays out an empty month: header with employee names, one row per day,
+// a spacer row and the SUMA GODZIN / WYPŁATA summary rows
+func buildMonthGrid(month string, year int, employees map[string]Employee) [][]interface{} {
 	daysInMonth := getDaysInMonth(month, year)
 
 	// Create header row
@@ -813,17 +1115,21 @@ func (s *SpreadsheetService) CreateMonthlySchedule(ctx context.Context, spreadsh
 	wagesRow = append(wagesRow, "") // Empty tags column
 	scheduleData = append(scheduleData, wagesRow)
 
-	sheetRange := fmt.Sprintf("%s!A1:Z%d", month, len(scheduleData))
-	return s.WriteSpreadsheetDataUnsafe(ctx, spreadsheetID, sheetRange, scheduleData)
+	return scheduleData
 }
 
-func (s *SpreadsheetService) RegenerateAllMonthlySchedules(ctx context.Context, spreadsheetID string, employees map[string]Employee, year int) error {
+func (s *SpreadsheetService) RegenerateAllMonthlySchedules(ctx context.Context, spreadsheetID, shopID string, employees map[string]Employee, year int) error {
 	log.Printf("Regenerating all monthly schedules for spreadsheet %s", spreadsheetID)
 
 	months := []string{"STYCZEŃ", "LUTY", "MARZEC", "KWIECIEŃ", "MAJ", "CZERWIEC",
 		"LIPIEC", "SIERPIEŃ", "WRZESIEŃ", "PAŹDZIERNIK", "LISTOPAD", "GRUDZIEŃ"}
 
 	for _, month := range months {
+		// Months closed for payroll keep their sheet as it is
+		if err := checkMonthOpen(shopID, month, year); err != nil {
+			log.Printf("Skipping regeneration of %s: %v", month, err)
+			continue
+		}
 		if err := s.CreateMonthlySchedule(ctx, spreadsheetID, month, employees, year); err != nil {
 			log.Printf("Error regenerating month %s: %v", month, err)
 			return err
@@ -833,6 +1139,20 @@ func (s *SpreadsheetService) RegenerateAllMonthlySchedules(ctx context.Context,
 	return nil
 }
 
+// polishMonths lists the month sheet names in calendar order
+var polishMonths = []string{"STYCZEŃ", "LUTY", "MARZEC", "KWIECIEŃ", "MAJ", "CZERWIEC",
+	"LIPIEC", "SIERPIEŃ", "WRZESIEŃ", "PAŹDZIERNIK", "LISTOPAD", "GRUDZIEŃ"}
+
+// columnName converts a zero-based column index to its A1 letters (0 -> A, 26 -> AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
 func getDaysInMonth(month string, year int) int {
 	monthNum := getMonthNumber(month)
 	return time.Date(year, monthNum+1, 0, 0, 0, 0, 0, time.UTC).Day()
@@ -870,8 +1190,8 @@ func getPolishDayName(weekday time.Weekday) string {
 }
 
 func enableCors(w *http.ResponseWriter) {
-	(*w).Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+	(*w).Header().Set("Access-Control-Allow-Origin", frontendURL())
+	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, PATCH, DELETE")
 	(*w).Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Authorization")
 	(*w).Header().Set("Access-Control-Allow-Credentials", "true")
 }
@@ -966,7 +1286,7 @@ func handleGoogleCallback(w http.ResponseWriter, r *http.Request) {
 		Expires:  now.Add(sessionTimeout),
 	})
 
-	http.Redirect(w, r, "http://localhost:3000", http.StatusTemporaryRedirect)
+	http.Redirect(w, r, frontendURL(), http.StatusTemporaryRedirect)
 }
 
 func handleUser(w http.ResponseWriter, r *http.Request) {
@@ -1373,7 +1693,7 @@ func handleSpreadsheet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read management data
-	data, err := spreadsheetService.ReadSpreadsheetData(r.Context(), spreadsheet.SpreadsheetId, "MANAGEMENT!A1:C20")
+	data, err := spreadsheetService.ReadSpreadsheetData(r.Context(), spreadsheet.SpreadsheetId, "MANAGEMENT")
 	if err != nil {
 		log.Printf("Error reading management data: %v", err)
 		data = [][]interface{}{}
@@ -1466,8 +1786,29 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if req.HourlyRate <= 0 {
-			req.HourlyRate = 30.0 // Default rate
+		for _, date := range []string{req.StartDate, req.EndDate} {
+			if date == "" {
+				continue
+			}
+			if _, err := time.Parse(dateLayout, date); err != nil {
+				http.Error(w, "Invalid employment date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.ContractedHours < 0 {
+			http.Error(w, "Contracted hours cannot be negative", http.StatusBadRequest)
+			return
+		}
+		if req.StartDate != "" && req.EndDate != "" && req.EndDate < req.StartDate {
+			http.Error(w, "Employment end date cannot be before the start date", http.StatusBadRequest)
+			return
+		}
+		if req.Contract != nil {
+			if err := req.Contract.validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.Contract.Type = req.Contract.contractType()
 		}
 
 		// Check if shop exists
@@ -1480,18 +1821,77 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		existing, _ := getEmployerShop(session.UserInfo.Email, req.ShopID)
+		current, isEmployed := existing.Employees[req.EmployeeEmail]
+		contract := current.Contract
+		if req.Contract != nil {
+			contract = *req.Contract
+		}
+
+		// The rate applies from the start of the employment, or from today for someone already working
+		rateDate := time.Now()
+		startDate := req.StartDate
+		if startDate == "" {
+			startDate = current.StartDate
+		}
+		if start, err := time.ParseInLocation(dateLayout, startDate, time.Local); err == nil && start.After(rateDate) {
+			rateDate = start
+		}
+
+		if req.HourlyRate <= 0 {
+			if isEmployed && current.HourlyRate > 0 {
+				req.HourlyRate = current.HourlyRate
+			} else {
+				req.HourlyRate = minimumRateFor(contract, rateDate) // Default rate
+			}
+		}
+		warning, err := checkMinimumRate(contract, req.HourlyRate, rateDate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		// Add employee to shop
 		employerShopsMutex.Lock()
 		shop := employerShops[session.UserInfo.Email][req.ShopID]
-		shop.Employees[req.EmployeeEmail] = Employee{
-			Email:      strings.TrimSpace(req.EmployeeEmail),
-			Name:       strings.TrimSpace(req.EmployeeName),
-			HourlyRate: req.HourlyRate,
+		previous := shop.Employees[req.EmployeeEmail]
+		employee := Employee{
+			Email:           strings.TrimSpace(req.EmployeeEmail),
+			Name:            strings.TrimSpace(req.EmployeeName),
+			HourlyRate:      req.HourlyRate,
+			Position:        strings.TrimSpace(req.Position),
+			StartDate:       req.StartDate,
+			EndDate:         req.EndDate,
+			ContractedHours: req.ContractedHours,
+		}
+		// Keep employment details the form didn't send
+		if employee.Position == "" {
+			employee.Position = previous.Position
+		}
+		if employee.StartDate == "" {
+			employee.StartDate = previous.StartDate
+		}
+		if employee.EndDate == "" {
+			employee.EndDate = previous.EndDate
+		}
+		if employee.ContractedHours == 0 {
+			employee.ContractedHours = previous.ContractedHours
+		}
+		if req.Contract != nil {
+			employee.Contract = *req.Contract
+		} else {
+			employee.Contract = previous.Contract
 		}
+		shop.Employees[req.EmployeeEmail] = employee
 		shop.UpdatedAt = time.Now()
 		employerShops[session.UserInfo.Email][req.ShopID] = shop
 		employerShopsMutex.Unlock()
 
+		upsertPerson(req.EmployeeEmail, req.EmployeeName)
+
+		// A changed rate starts a new history entry instead of re-pricing past months
+		recordRateChange(req.ShopID, req.EmployeeEmail, previous.HourlyRate, req.HourlyRate, session.UserInfo.Email)
+
 		// Add shop to employee's shop list
 		employeeShopsMutex.Lock()
 		if employeeShops[req.EmployeeEmail] == nil {
@@ -1515,6 +1915,7 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 		go func() {
 			saveShopsData()
 			saveEmployeeShopsData()
+			savePeopleData()
 		}()
 
 		// Share existing spreadsheets with new employee (all years for this shop)
@@ -1529,14 +1930,14 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 					}
 
 					// Regenerate schedules with new employee structure
-					if err := spreadsheetService.RegenerateAllMonthlySchedules(r.Context(), spreadsheetID, shop.Employees, year); err != nil {
+					if err := spreadsheetService.RegenerateAllMonthlySchedules(r.Context(), spreadsheetID, req.ShopID, shop.Employees, year); err != nil {
 						log.Printf("Error regenerating schedules for year %d: %v", year, err)
 					} else {
 						log.Printf("Regenerated schedules for shop %s, year %d after adding employee %s", req.ShopID, year, req.EmployeeEmail)
 					}
 
 					// Update management sheet
-					if err := spreadsheetService.initializeManagementSheet(r.Context(), spreadsheetID, session.UserInfo.Email, req.ShopID); err != nil {
+					if err := spreadsheetService.initializeManagementSheet(r.Context(), spreadsheetID, session.UserInfo.Email, req.ShopID, year); err != nil {
 						log.Printf("Error updating management sheet for year %d: %v", year, err)
 					}
 				}
@@ -1545,7 +1946,11 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("Added employee %s to shop %s for employer %s", req.EmployeeEmail, req.ShopID, session.UserInfo.Email)
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"message": "Employee added successfully"})
+		response := map[string]string{"message": "Employee added successfully"}
+		if warning != "" {
+			response["warning"] = warning
+		}
+		json.NewEncoder(w).Encode(response)
 
 	case http.MethodDelete:
 		var req EmployeeManagementRequest
@@ -1598,14 +2003,14 @@ func handleEmployees(w http.ResponseWriter, r *http.Request) {
 					}
 
 					// Regenerate all monthly schedules with updated employee structure
-					if err := spreadsheetService.RegenerateAllMonthlySchedules(r.Context(), spreadsheetID, shop.Employees, year); err != nil {
+					if err := spreadsheetService.RegenerateAllMonthlySchedules(r.Context(), spreadsheetID, req.ShopID, shop.Employees, year); err != nil {
 						log.Printf("Error regenerating schedules for year %d: %v", year, err)
 					} else {
 						log.Printf("Regenerated schedules for shop %s, year %d after removing employee %s", req.ShopID, year, req.EmployeeEmail)
 					}
 
 					// Update management sheet
-					if err := spreadsheetService.initializeManagementSheet(r.Context(), spreadsheetID, session.UserInfo.Email, req.ShopID); err != nil {
+					if err := spreadsheetService.initializeManagementSheet(r.Context(), spreadsheetID, session.UserInfo.Email, req.ShopID, year); err != nil {
 						log.Printf("Error updating management sheet for year %d: %v", year, err)
 					}
 				}
@@ -1733,19 +2138,58 @@ func handleScheduleData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sheetRange := fmt.Sprintf("%s!A1:Z50", month)
-	data, err := spreadsheetService.ReadSpreadsheetData(r.Context(), spreadsheetID, sheetRange)
+	data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
 	if err != nil {
 		log.Printf("Error reading schedule data: %v", err)
 		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
 		return
 	}
 
+	// Keep the cross-shop index fresh even when the sheet was edited directly
+	indexMonthSchedule(shop, month, year, data)
+
+	response := map[string]interface{}{
+		"data":            data,
+		"employees":       shop.Employees,
+		"shift_templates": shop.ShiftTemplates,
+		"draft":           false,
+		"locked":          false,
+	}
+	if publication, published := getSchedulePublication(shopID, month, year); published {
+		response["published"] = publication
+	}
+	if monthClose, closed := getMonthClose(shopID, month, year); closed {
+		response["locked"] = monthClose.Locked
+		response["month_close"] = monthClose
+	}
+	if session.Role == "employer" {
+		response["availability"] = monthAvailability(shop.Employees, month, year)
+
+		// Employers work on the draft unless they ask for what employees see
+		if draft, exists := getScheduleDraft(shopID, month, year); exists && r.URL.Query().Get("view") != "published" {
+			response["data"] = draft.Data
+			response["draft"] = true
+			response["draft_updated_at"] = draft.UpdatedAt
+		}
+	}
+	response["revision"] = scheduleRevision(response["data"].([][]interface{}))
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":      data,
-		"employees": shop.Employees,
-	})
+	json.NewEncoder(w).Encode(response)
+}
+
+// saveMonthSchedule recalculates the totals of a month grid, writes it to the month sheet and
+// refreshes the shift index. It is the write path shared by every whole-month update.
+func saveMonthSchedule(ctx context.Context, service *SpreadsheetService, shop Shop, spreadsheetID, month string, year int, data [][]interface{}) ([][]interface{}, []ScheduledShift, error) {
+	// The SUMA GODZIN and WYPŁATA rows sent by the client are not trusted
+	data = applyScheduleTotals(data, shop, month, year)
+
+	log.Printf("Writing to range: %s", gridRange(month, data))
+	if err := service.WriteSheetGrid(ctx, spreadsheetID, month, data); err != nil {
+		return nil, nil, err
+	}
+
+	return data, indexMonthSchedule(shop, month, year, data), nil
 }
 
 func handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
@@ -1786,10 +2230,12 @@ func handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
 	sessionsMutex.Unlock()
 
 	var updateReq struct {
-		Month  string          `json:"month"`
-		Year   int             `json:"year"`
-		ShopID string          `json:"shop_id"`
-		Data   [][]interface{} `json:"data"`
+		Month    string          `json:"month"`
+		Year     int             `json:"year"`
+		ShopID   string          `json:"shop_id"`
+		Data     [][]interface{} `json:"data"`
+		Revision string          `json:"revision"` // revision of the grid the edit started from
+		Publish  bool            `json:"publish"`  // publish the draft right away
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
@@ -1807,6 +2253,11 @@ func handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if updateReq.Revision == "" {
+		http.Error(w, "Revision is required, reload the schedule before saving", http.StatusBadRequest)
+		return
+	}
+
 	log.Printf("Received update request for month %s, year %d, shop %s with %d rows", updateReq.Month, updateReq.Year, updateReq.ShopID, len(updateReq.Data))
 
 	employerShopsMutex.RLock()
@@ -1827,8 +2278,7 @@ func handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	spreadsheetID, exists := shop.Spreadsheets[updateReq.Year]
-	if !exists {
+	if _, exists := shop.Spreadsheets[updateReq.Year]; !exists {
 		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", updateReq.Year), http.StatusNotFound)
 		return
 	}
@@ -1846,24 +2296,90 @@ func handleUpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	endColumn := string(rune('A' + len(updateReq.Data[0]) - 1))
-	if len(updateReq.Data[0]) > 26 {
-		endColumn = "Z"
+	if problems := validateScheduleCells(updateReq.Data, shop, updateReq.Month, updateReq.Year); len(problems) > 0 {
+		http.Error(w, "Invalid schedule cells: "+strings.Join(problems, "; "), http.StatusBadRequest)
+		return
 	}
-	sheetRange := fmt.Sprintf("%s!A1:%s%d", updateReq.Month, endColumn, len(updateReq.Data))
 
-	log.Printf("Writing to range: %s", sheetRange)
+	unlock := lockScheduleMonth(shop.ID, updateReq.Month, updateReq.Year)
+	defer unlock()
+
+	if !requireMonthOpen(w, shop.ID, updateReq.Month, updateReq.Year) {
+		return
+	}
 
-	err = spreadsheetService.WriteSpreadsheetData(r.Context(), spreadsheetID, sheetRange, updateReq.Data)
+	// Edits go to the month's draft; employees only see them once the draft is published
+	draft, err := workingMonthDraft(r.Context(), spreadsheetService, shop, updateReq.Month, updateReq.Year, session.UserInfo.Email)
 	if err != nil {
-		log.Printf("Error updating schedule: %v", err)
+		log.Printf("Error loading draft for %s %d: %v", updateReq.Month, updateReq.Year, err)
 		http.Error(w, fmt.Sprintf("Failed to update schedule: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// Someone else saved the month since this client read it
+	if current := scheduleRevision(draft.Data); current != updateReq.Revision {
+		log.Printf("Rejected stale update of %s %d in shop %s (revision %s, current %s)", updateReq.Month, updateReq.Year, shop.ID, updateReq.Revision, current)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":    "The schedule was changed by someone else since you loaded it",
+			"revision": current,
+			"data":     draft.Data,
+		})
+		return
+	}
+	draft.Data = applyScheduleTotals(updateReq.Data, shop, updateReq.Month, updateReq.Year)
+	draft.UpdatedBy = session.UserInfo.Email
+	draft.UpdatedAt = time.Now()
+	storeScheduleDraft(draft)
+	updateReq.Data = draft.Data
+
+	shifts := parseMonthSchedule(updateReq.Data, shop, updateReq.Month, updateReq.Year)
+	conflicts := findShiftConflicts(session.UserInfo.Email, shop, shifts)
+	if len(conflicts) > 0 {
+		log.Printf("Schedule for shop %s, %s %d has %d cross-shop conflicts", updateReq.ShopID, updateReq.Month, updateReq.Year, len(conflicts))
+	}
+
+	warnings := append(availabilityWarnings(shop, shifts), checkCompliance(shop, updateReq.Month, updateReq.Year, shifts)...)
+
+	response := map[string]interface{}{
+		"message":   "Draft saved",
+		"data":      updateReq.Data,
+		"revision":  scheduleRevision(updateReq.Data),
+		"conflicts": conflicts,
+		"warnings":  warnings,
+		"draft":     true,
+	}
+
+	if updateReq.Publish {
+		result, err := publishScheduleDraft(r.Context(), spreadsheetService, shop, draft, session.UserInfo.Email, false)
+		if err != nil {
+			log.Printf("Error updating schedule: %v", err)
+			http.Error(w, fmt.Sprintf("Failed to update schedule: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if len(result.Conflicts) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":     "Draft saved, but the published schedule changed the same cells since the draft was started",
+				"conflicts": result.Conflicts,
+				"revision":  scheduleRevision(draft.Data),
+			})
+			return
+		}
+		response["message"] = "Schedule updated successfully"
+		response["draft"] = false
+		response["publication"] = result.Publication
+		response["changes"] = result.Changes
+		response["data"] = result.Data
+		response["revision"] = scheduleRevision(result.Data)
+		response["conflicts"] = findShiftConflicts(session.UserInfo.Email, shop, result.Shifts)
+	}
+
 	log.Printf("Successfully updated schedule for month %s, year %d, shop %s", updateReq.Month, updateReq.Year, updateReq.ShopID)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Schedule updated successfully"})
+	json.NewEncoder(w).Encode(response)
 }
 
 func main() {
@@ -1882,8 +2398,55 @@ func main() {
 	http.HandleFunc("/api/shops", withTimeout(handleShops))
 	http.HandleFunc("/api/spreadsheet", withTimeout(handleSpreadsheet))
 	http.HandleFunc("/api/employees", withTimeout(handleEmployees))
+	http.HandleFunc("/api/employees/rates", withTimeout(handleRateHistory))
+	http.HandleFunc("/api/employees/minimum-wage", withTimeout(handleMinimumWage))
 	http.HandleFunc("/api/schedule", withTimeout(handleScheduleData))
 	http.HandleFunc("/api/schedule/update", withTimeout(handleUpdateSchedule))
+	http.HandleFunc("/api/schedule/conflicts", withTimeout(handleScheduleConflicts))
+	http.HandleFunc("/api/availability", withTimeout(handleAvailability))
+	http.HandleFunc("/api/leave", withTimeout(handleLeave))
+	http.HandleFunc("/api/leave/decision", withTimeout(handleLeaveDecision))
+	http.HandleFunc("/api/leave/history", withTimeout(handleLeaveHistory))
+	http.HandleFunc("/api/leave/balance", withTimeout(handleLeaveBalance))
+	http.HandleFunc("/api/leave/balance/carryover", withTimeout(handleLeaveCarryOver))
+	http.HandleFunc("/api/absence-codes", withTimeout(handleAbsenceCodes))
+	http.HandleFunc("/api/shops/settings", withTimeout(handleShopSettings))
+	http.HandleFunc("/api/audit", withTimeout(handleAuditLog))
+	http.HandleFunc("/api/swaps", withTimeout(handleShiftSwaps))
+	http.HandleFunc("/api/swaps/respond", withTimeout(handleShiftSwapResponse))
+	http.HandleFunc("/api/swaps/decision", withTimeout(handleShiftSwapDecision))
+	http.HandleFunc("/api/open-shifts", withTimeout(handleOpenShifts))
+	http.HandleFunc("/api/open-shifts/claim", withTimeout(handleOpenShiftClaim))
+	http.HandleFunc("/api/open-shifts/decision", withTimeout(handleOpenShiftDecision))
+	http.HandleFunc("/api/coverage", withTimeout(handleCoverage))
+	http.HandleFunc("/api/schedule/generate", withTimeout(handleGenerateSchedule))
+	http.HandleFunc("/api/schedule/draft", withTimeout(handleScheduleDraft))
+	http.HandleFunc("/api/schedule/publish", withTimeout(handlePublishSchedule))
+	http.HandleFunc("/api/schedule/cells", withTimeout(handlePatchScheduleCells))
+	http.HandleFunc("/api/schedule/close", withTimeout(handleMonthClose))
+	http.HandleFunc("/api/schedule/reopen", withTimeout(handleMonthReopen))
+	http.HandleFunc("/api/schedule/corrections", withTimeout(handlePayrollCorrections))
+	http.HandleFunc("/api/time/clock", withTimeout(handleClock))
+	http.HandleFunc("/api/time/entries", withTimeout(handleTimeEntries))
+	http.HandleFunc("/api/time/report", withTimeout(handleTimeReport))
+	http.HandleFunc("/api/kiosk/code", withTimeout(handleKioskCode))
+	http.HandleFunc("/api/kiosk/scan", withTimeout(handleKioskScan))
+	http.HandleFunc("/api/kiosk/tokens", withTimeout(handleKioskTokens))
+	http.HandleFunc("/api/schedule/versions", withTimeout(handleScheduleVersions))
+	http.HandleFunc("/api/schedule/versions/diff", withTimeout(handleScheduleVersionDiff))
+	http.HandleFunc("/api/schedule/versions/revert", withTimeout(handleRevertSchedule))
+	http.HandleFunc("/api/opening-hours", withTimeout(handleOpeningHours))
+	http.HandleFunc("/api/schedule/gaps", withTimeout(handleScheduleGaps))
+	http.HandleFunc("/api/shift-templates", withTimeout(handleShiftTemplates))
+	http.HandleFunc("/api/schedule/copy", withTimeout(handleCopySchedule))
+	http.HandleFunc("/api/rotations", withTimeout(handleRotations))
+	http.HandleFunc("/api/rotations/apply", withTimeout(handleApplyRotation))
+	http.HandleFunc("/api/notifications", withTimeout(handleNotifications))
+	http.HandleFunc("/api/people", withTimeout(handlePeople))
+	http.HandleFunc("/api/people/summary", withTimeout(handlePersonSummary))
+	http.HandleFunc("/api/payroll", withTimeout(handlePayroll))
+	http.HandleFunc("/api/payroll/rules", withTimeout(handlePremiumRules))
+	http.HandleFunc("/api/payroll/export", withTimeout(handlePayrollExport))
 
 	fmt.Println("Server starting on :8080...")
 	fmt.Printf("Configured employer emails: %v\n", getEmployerEmails())
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MinimumWage is the statutory minimum valid from EffectiveFrom until the next entry. The
+// hourly rate binds civil-law contracts, the monthly wage a full-time umowa o pracę.
+type MinimumWage struct {
+	EffectiveFrom string  `json:"effective_from"` // YYYY-MM-DD
+	HourlyRate    float64 `json:"hourly_rate"`
+	MonthlyWage   float64 `json:"monthly_wage"`
+}
+
+// minimumWageTable is sorted by EffectiveFrom. Add the next year's entry once it is announced.
+var minimumWageTable = []MinimumWage{
+	{EffectiveFrom: "2024-01-01", HourlyRate: 27.70, MonthlyWage: 4242},
+	{EffectiveFrom: "2024-07-01", HourlyRate: 28.10, MonthlyWage: 4300},
+	{EffectiveFrom: "2025-01-01", HourlyRate: 30.50, MonthlyWage: 4666},
+	{EffectiveFrom: "2026-01-01", HourlyRate: 31.40, MonthlyWage: 4806},
+}
+
+// MinimumWageIssue is an employee whose rate is below the minimum taking effect on a date
+type MinimumWageIssue struct {
+	ShopID        string      `json:"shop_id"`
+	ShopName      string      `json:"shop_name"`
+	EmployeeEmail string      `json:"employee_email"`
+	EmployeeName  string      `json:"employee_name"`
+	ContractType  string      `json:"contract_type"`
+	Rate          float64     `json:"rate"` // rate valid on the effective date, including scheduled changes
+	MinimumRate   float64     `json:"minimum_rate"`
+	Shortfall     float64     `json:"shortfall"`
+	Minimum       MinimumWage `json:"minimum"`
+}
+
+// minimumWageOn returns the minimum in force on the given date; dates before the table use its
+// first entry
+func minimumWageOn(date time.Time) MinimumWage {
+	minimum := minimumWageTable[0]
+	day := date.Format(dateLayout)
+	for _, entry := range minimumWageTable {
+		if entry.EffectiveFrom > day {
+			break
+		}
+		minimum = entry
+	}
+	return minimum
+}
+
+// minimumWagesIn lists the table entries taking effect during the year
+func minimumWagesIn(year int) []MinimumWage {
+	prefix := strconv.Itoa(year) + "-"
+	var entries []MinimumWage
+	for _, entry := range minimumWageTable {
+		if strings.HasPrefix(entry.EffectiveFrom, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// legacyDefaultMinimumWage is the 2025 minimum wage that used to be saved as the default of
+// every shop's premium rules
+const legacyDefaultMinimumWage = 4666
+
+// followMinimumWageTable resets premium rules saved with the old default to zero, so those
+// shops pick up each year's minimum from the table instead of staying at 2025's
+func followMinimumWageTable() {
+	employerShopsMutex.Lock()
+	migrated := 0
+	for _, shops := range employerShops {
+		for id, shop := range shops {
+			if shop.PremiumRules == nil || shop.PremiumRules.MinimumMonthlyWage != legacyDefaultMinimumWage {
+				continue
+			}
+			rules := *shop.PremiumRules
+			rules.MinimumMonthlyWage = 0
+			shop.PremiumRules = &rules
+			shops[id] = shop
+			migrated++
+		}
+	}
+	employerShopsMutex.Unlock()
+
+	if migrated > 0 {
+		log.Printf("Premium rules of %d shops now follow the minimum wage table", migrated)
+		if err := saveShopsData(); err != nil {
+			log.Printf("Error saving shops data: %v", err)
+		}
+	}
+}
+
+// minimumMonthlyWage returns the shop's configured minimum wage, or the table's when the
+// rules leave it at zero
+func (rules PremiumRules) minimumMonthlyWage(date time.Time) float64 {
+	if rules.MinimumMonthlyWage > 0 {
+		return rules.MinimumMonthlyWage
+	}
+	return minimumWageOn(date).MonthlyWage
+}
+
+// minimumRateFor returns the lowest hourly rate allowed for the contract on the date. For umowa
+// o pracę it is the monthly minimum spread over the month's full-time working hours.
+func minimumRateFor(contract EmploymentContract, date time.Time) float64 {
+	minimum := minimumWageOn(date)
+	if contract.contractType() != contractEmployment {
+		return minimum.HourlyRate
+	}
+	norm := nominalWorkingHours(date.Month(), date.Year())
+	if norm <= 0 {
+		return minimum.HourlyRate
+	}
+	return roundMoney(minimum.MonthlyWage / norm)
+}
+
+// checkMinimumRate rejects civil-law rates below the statutory hourly minimum. An umowa o pracę
+// rate is only flagged, the employer has to top up the month's pay to the minimum wage.
+func checkMinimumRate(contract EmploymentContract, rate float64, date time.Time) (string, error) {
+	minimum := minimumRateFor(contract, date)
+	if rate >= minimum {
+		return "", nil
+	}
+	if contract.contractType() != contractEmployment {
+		return "", fmt.Errorf("hourly rate %.2f is below the minimum of %.2f for %s on %s", rate, minimum, contract.contractType(), date.Format(dateLayout))
+	}
+	return fmt.Sprintf("Hourly rate %.2f gives less than the minimum wage of %.2f in a full-time month of %s, the difference has to be paid out", rate, minimumWageOn(date).MonthlyWage, date.Format("2006-01")), nil
+}
+
+// minimumWageIssues lists the employees of the shops whose rate on the day a minimum takes
+// effect is below it. Employments ending before that day are skipped.
+func minimumWageIssues(shops []Shop, minimums []MinimumWage) []MinimumWageIssue {
+	issues := []MinimumWageIssue{}
+	for _, minimum := range minimums {
+		date, err := time.ParseInLocation(dateLayout, minimum.EffectiveFrom, time.Local)
+		if err != nil {
+			continue
+		}
+		for _, shop := range shops {
+			for email, employee := range shop.Employees {
+				if employee.EndDate != "" && employee.EndDate < minimum.EffectiveFrom {
+					continue
+				}
+				rate := employeeRateOn(shop.ID, employee, date)
+				minimumRate := minimumRateFor(employee.Contract, date)
+				if rate >= minimumRate {
+					continue
+				}
+				issues = append(issues, MinimumWageIssue{
+					ShopID:        shop.ID,
+					ShopName:      shop.Name,
+					EmployeeEmail: email,
+					EmployeeName:  employee.Name,
+					ContractType:  employee.Contract.contractType(),
+					Rate:          rate,
+					MinimumRate:   minimumRate,
+					Shortfall:     roundMoney(minimumRate - rate),
+					Minimum:       minimum,
+				})
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Minimum.EffectiveFrom != issues[j].Minimum.EffectiveFrom {
+			return issues[i].Minimum.EffectiveFrom < issues[j].Minimum.EffectiveFrom
+		}
+		if issues[i].ShopName != issues[j].ShopName {
+			return issues[i].ShopName < issues[j].ShopName
+		}
+		return issues[i].EmployeeName < issues[j].EmployeeName
+	})
+	return issues
+}
+
+// handleMinimumWage reports the employees of the employer's shops whose rates fall below the
+// minimum taking effect in the given year (next year by default)
+func handleMinimumWage(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view the minimum wage report", http.StatusForbidden)
+		return
+	}
+
+	year := time.Now().Year() + 1
+	if value := r.URL.Query().Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		year = parsed
+	}
+
+	minimums := minimumWagesIn(year)
+	if len(minimums) == 0 {
+		http.Error(w, fmt.Sprintf("No minimum wage is known for %d yet", year), http.StatusNotFound)
+		return
+	}
+
+	var shops []Shop
+	if shopID := r.URL.Query().Get("shop_id"); shopID != "" {
+		shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		shops = append(shops, shop)
+	} else {
+		employerShopsMutex.RLock()
+		for _, shop := range employerShops[session.UserInfo.Email] {
+			shops = append(shops, shop)
+		}
+		employerShopsMutex.RUnlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"year":      year,
+		"minimums":  minimums,
+		"employees": minimumWageIssues(shops, minimums),
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMinimumWageBoundaries(t *testing.T) {
+	civil := EmploymentContract{Type: contractCivil}
+	tests := []struct {
+		date       string
+		wantHourly float64
+		rate       float64
+		wantErr    bool
+	}{
+		{"2023-12-31", 27.70, 27.70, false},
+		{"2024-06-30", 27.70, 27.70, false},
+		{"2024-07-01", 28.10, 27.70, true},
+		{"2024-07-01", 28.10, 28.10, false},
+		{"2025-12-31", 30.50, 30.50, false},
+		{"2026-01-01", 31.40, 30.50, true},
+		{"2026-01-01", 31.40, 31.40, false},
+	}
+	for _, test := range tests {
+		date, _ := time.ParseInLocation(dateLayout, test.date, time.Local)
+		if got := minimumWageOn(date).HourlyRate; got != test.wantHourly {
+			t.Errorf("minimum hourly rate on %s = %.2f, want %.2f", test.date, got, test.wantHourly)
+		}
+		if _, err := checkMinimumRate(civil, test.rate, date); (err != nil) != test.wantErr {
+			t.Errorf("rate %.2f on %s: error = %v, want error: %v", test.rate, test.date, err, test.wantErr)
+		}
+	}
+
+	t.Run("an umowa o pracę rate below the minimum is only flagged", func(t *testing.T) {
+		date, _ := time.ParseInLocation(dateLayout, "2026-01-01", time.Local)
+		warning, err := checkMinimumRate(EmploymentContract{Type: contractEmployment}, 25, date)
+		if err != nil || warning == "" {
+			t.Errorf("got warning %q with error %v, want a warning only", warning, err)
+		}
+	})
+}
@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+const (
+	monthClosesFile        = "month_closes_data.json"
+	payrollCorrectionsFile = "payroll_corrections_data.json"
+)
+
+// MonthClose records that payroll for a shop month is done. While Locked is set nothing may
+// change the month; a month that was closed once keeps its record after being reopened, so
+// later writes are recorded as corrections.
+type MonthClose struct {
+	ShopID           string     `json:"shop_id"`
+	Month            string     `json:"month"`
+	Year             int        `json:"year"`
+	Locked           bool       `json:"locked"`
+	ClosedBy         string     `json:"closed_by"`
+	ClosedAt         time.Time  `json:"closed_at"`
+	UnlockedBy       string     `json:"unlocked_by,omitempty"`
+	UnlockedAt       *time.Time `json:"unlocked_at,omitempty"`
+	UnlockReason     string     `json:"unlock_reason,omitempty"`
+	ProtectedRangeID int64      `json:"protected_range_id,omitempty"`
+}
+
+// PayrollCorrection is a change written to a month after its payroll was closed
+type PayrollCorrection struct {
+	ID            string       `json:"id"`
+	ShopID        string       `json:"shop_id"`
+	Month         string       `json:"month"`
+	Year          int          `json:"year"`
+	VersionNumber int          `json:"version_number"`
+	Action        string       `json:"action"`
+	Actor         string       `json:"actor,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
+	Changes       []CellChange `json:"changes"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+var (
+	monthCloses      = make(map[string]map[string]MonthClose) // shop_id -> YYYY-MM -> close record
+	monthClosesMutex sync.RWMutex
+
+	payrollCorrections      = make(map[string][]PayrollCorrection) // shop_id -> corrections, oldest first
+	payrollCorrectionsMutex sync.RWMutex
+)
+
+func saveMonthClosesData() error {
+	monthClosesMutex.RLock()
+	defer monthClosesMutex.RUnlock()
+	return writeJSONFileAtomic(monthClosesFile, monthCloses)
+}
+
+func loadMonthClosesData() error {
+	monthClosesMutex.Lock()
+	defer monthClosesMutex.Unlock()
+	return readJSONFile(monthClosesFile, &monthCloses)
+}
+
+func savePayrollCorrectionsData() error {
+	payrollCorrectionsMutex.RLock()
+	defer payrollCorrectionsMutex.RUnlock()
+	return writeJSONFileAtomic(payrollCorrectionsFile, payrollCorrections)
+}
+
+func loadPayrollCorrectionsData() error {
+	payrollCorrectionsMutex.Lock()
+	defer payrollCorrectionsMutex.Unlock()
+	return readJSONFile(payrollCorrectionsFile, &payrollCorrections)
+}
+
+func getMonthClose(shopID, month string, year int) (MonthClose, bool) {
+	monthClosesMutex.RLock()
+	defer monthClosesMutex.RUnlock()
+	record, exists := monthCloses[shopID][monthKey(year, getMonthNumber(month))]
+	return record, exists
+}
+
+func storeMonthClose(record MonthClose) {
+	monthClosesMutex.Lock()
+	if monthCloses[record.ShopID] == nil {
+		monthCloses[record.ShopID] = make(map[string]MonthClose)
+	}
+	monthCloses[record.ShopID][monthKey(record.Year, getMonthNumber(record.Month))] = record
+	monthClosesMutex.Unlock()
+
+	go saveMonthClosesData()
+}
+
+// monthLockedError is returned when a write targets a month whose payroll is closed
+type monthLockedError struct {
+	Close MonthClose
+}
+
+func (e *monthLockedError) Error() string {
+	return fmt.Sprintf("%s %d is closed for payroll, reopen it before changing it", e.Close.Month, e.Close.Year)
+}
+
+// checkMonthOpen returns a *monthLockedError when the month is locked
+func checkMonthOpen(shopID, month string, year int) error {
+	if record, exists := getMonthClose(shopID, month, year); exists && record.Locked {
+		return &monthLockedError{Close: record}
+	}
+	return nil
+}
+
+// respondMonthLocked answers 423 when err is a monthLockedError and reports whether it did
+func respondMonthLocked(w http.ResponseWriter, err error) bool {
+	var locked *monthLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusLocked)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":       locked.Error(),
+		"month_close": locked.Close,
+	})
+	return true
+}
+
+// requireMonthOpen answers 423 and returns false when the month is locked
+func requireMonthOpen(w http.ResponseWriter, shopID, month string, year int) bool {
+	if err := checkMonthOpen(shopID, month, year); err != nil {
+		respondMonthLocked(w, err)
+		return false
+	}
+	return true
+}
+
+// recordCorrection keeps a write to a reopened month as a correction of its closed payroll
+func recordCorrection(shopID string, version ScheduleVersion) {
+	record, exists := getMonthClose(shopID, version.Month, version.Year)
+	if !exists || len(version.Changes) == 0 {
+		return
+	}
+
+	payrollCorrectionsMutex.Lock()
+	payrollCorrections[shopID] = append(payrollCorrections[shopID], PayrollCorrection{
+		ID:            generateRandomString(12),
+		ShopID:        shopID,
+		Month:         version.Month,
+		Year:          version.Year,
+		VersionNumber: version.Number,
+		Action:        version.Action,
+		Actor:         version.Actor,
+		Reason:        record.UnlockReason,
+		Changes:       version.Changes,
+		CreatedAt:     version.CreatedAt,
+	})
+	payrollCorrectionsMutex.Unlock()
+
+	go savePayrollCorrectionsData()
+}
+
+// ProtectMonthSheet protects a whole month sheet so only the given editors can change it in
+// Google Sheets, and returns the protected range ID
+func (s *SpreadsheetService) ProtectMonthSheet(ctx context.Context, spreadsheetID, month, description string, editors []string) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	properties, err := s.sheetPropertiesUnsafe(ctx, spreadsheetID, month)
+	if err != nil {
+		return 0, err
+	}
+
+	request := &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{{
+		AddProtectedRange: &sheets.AddProtectedRangeRequest{
+			ProtectedRange: &sheets.ProtectedRange{
+				Range:       &sheets.GridRange{SheetId: properties.SheetId, ForceSendFields: []string{"SheetId"}},
+				Description: description,
+				Editors:     &sheets.Editors{Users: editors},
+			},
+		},
+	}}}
+	resp, err := s.sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, request).Context(ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("unable to protect sheet %s: %v", month, err)
+	}
+	if len(resp.Replies) == 0 || resp.Replies[0].AddProtectedRange == nil || resp.Replies[0].AddProtectedRange.ProtectedRange == nil {
+		return 0, fmt.Errorf("unable to protect sheet %s: empty response", month)
+	}
+	return resp.Replies[0].AddProtectedRange.ProtectedRange.ProtectedRangeId, nil
+}
+
+// RemoveProtectedRange lifts a protection added by ProtectMonthSheet
+func (s *SpreadsheetService) RemoveProtectedRange(ctx context.Context, spreadsheetID string, protectedRangeID int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	request := &sheets.BatchUpdateSpreadsheetRequest{Requests: []*sheets.Request{{
+		DeleteProtectedRange: &sheets.DeleteProtectedRangeRequest{
+			ProtectedRangeId: protectedRangeID,
+			ForceSendFields:  []string{"ProtectedRangeId"},
+		},
+	}}}
+	if _, err := s.sheetsService.Spreadsheets.BatchUpdate(spreadsheetID, request).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("unable to remove sheet protection: %v", err)
+	}
+	return nil
+}
+
+// monthCloseRequest is the body of the close and reopen endpoints
+type monthCloseRequest struct {
+	ShopID string `json:"shop_id"`
+	Month  string `json:"month"`
+	Year   int    `json:"year"`
+	Reason string `json:"reason"`
+}
+
+// decodeMonthCloseRequest reads the body and the employer's shop shared by close and reopen
+func decodeMonthCloseRequest(w http.ResponseWriter, r *http.Request, session Session) (monthCloseRequest, Shop, bool) {
+	var req monthCloseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, Shop{}, false
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+	if getMonthNumber(req.Month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return req, Shop{}, false
+	}
+	req.Month = polishMonths[getMonthNumber(req.Month)-1]
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return req, Shop{}, false
+	}
+	return req, shop, true
+}
+
+// handleMonthClose lists the closed months of a shop year (GET) or closes a month (POST)
+func handleMonthClose(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can close payroll months", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shopID := r.URL.Query().Get("shop_id")
+		if _, exists := getEmployerShop(session.UserInfo.Email, shopID); !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		prefix := fmt.Sprintf("%04d-", parseYearParam(r))
+
+		monthClosesMutex.RLock()
+		closes := make([]MonthClose, 0)
+		for key, record := range monthCloses[shopID] {
+			if strings.HasPrefix(key, prefix) {
+				closes = append(closes, record)
+			}
+		}
+		monthClosesMutex.RUnlock()
+		sort.Slice(closes, func(i, j int) bool { return getMonthNumber(closes[i].Month) < getMonthNumber(closes[j].Month) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"closes": closes})
+
+	case http.MethodPost:
+		req, shop, ok := decodeMonthCloseRequest(w, r, session)
+		if !ok {
+			return
+		}
+
+		// Wait for writes to the month that are already running
+		unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+		defer unlock()
+
+		if !requireMonthOpen(w, shop.ID, req.Month, req.Year) {
+			return
+		}
+
+		record, _ := getMonthClose(shop.ID, req.Month, req.Year)
+		record.ShopID = shop.ID
+		record.Month = req.Month
+		record.Year = req.Year
+		record.Locked = true
+		record.ClosedBy = session.UserInfo.Email
+		record.ClosedAt = time.Now()
+		record.ProtectedRangeID = 0
+
+		// The backend refuses writes either way; protecting the sheet also stops direct edits
+		protected := false
+		warning := ""
+		if spreadsheetID, exists := shop.Spreadsheets[req.Year]; exists {
+			if spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token); err != nil {
+				log.Printf("Error getting spreadsheet service: %v", err)
+				warning = "The month is closed, but the sheet could not be protected against direct edits"
+			} else {
+				description := fmt.Sprintf("Payroll closed for %s %d", req.Month, req.Year)
+				rangeID, err := spreadsheetService.ProtectMonthSheet(r.Context(), spreadsheetID, req.Month, description, []string{session.UserInfo.Email})
+				if err != nil {
+					log.Printf("Error protecting %s %d for shop %s: %v", req.Month, req.Year, shop.ID, err)
+					warning = fmt.Sprintf("The month is closed, but the sheet could not be protected against direct edits: %v", err)
+				} else {
+					record.ProtectedRangeID = rangeID
+					protected = true
+				}
+			}
+		}
+		storeMonthClose(record)
+
+		recordAudit(AuditEntry{
+			ShopID:  shop.ID,
+			Action:  "month_closed",
+			Actor:   session.UserInfo.Email,
+			Message: fmt.Sprintf("Closed payroll for %s %d", req.Month, req.Year),
+		})
+
+		log.Printf("Closed %s %d for shop %s by %s (protected: %v)", req.Month, req.Year, shop.ID, session.UserInfo.Email, protected)
+		response := map[string]interface{}{
+			"message":     "Month closed",
+			"month_close": record,
+			"protected":   protected,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if warning != "" {
+			// Closing worked and is not repeated; the status tells the client only part of it did
+			response["warning"] = warning
+			w.WriteHeader(http.StatusMultiStatus)
+		}
+		json.NewEncoder(w).Encode(response)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMonthReopen unlocks a closed month. A reason is required and kept with every
+// correction written until the month is closed again.
+func handleMonthReopen(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can reopen payroll months", http.StatusForbidden)
+		return
+	}
+
+	req, shop, ok := decodeMonthCloseRequest(w, r, session)
+	if !ok {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "A reason is required to reopen a closed month", http.StatusBadRequest)
+		return
+	}
+
+	unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+	defer unlock()
+
+	record, exists := getMonthClose(shop.ID, req.Month, req.Year)
+	if !exists || !record.Locked {
+		http.Error(w, "This month is not closed", http.StatusConflict)
+		return
+	}
+
+	if record.ProtectedRangeID != 0 {
+		spreadsheetID := shop.Spreadsheets[req.Year]
+		spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+		if err == nil {
+			err = spreadsheetService.RemoveProtectedRange(r.Context(), spreadsheetID, record.ProtectedRangeID)
+		}
+		if err != nil {
+			log.Printf("Error removing protection of %s %d for shop %s: %v", req.Month, req.Year, shop.ID, err)
+			http.Error(w, "Failed to remove sheet protection", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	now := time.Now()
+	record.Locked = false
+	record.UnlockedBy = session.UserInfo.Email
+	record.UnlockedAt = &now
+	record.UnlockReason = req.Reason
+	record.ProtectedRangeID = 0
+	storeMonthClose(record)
+
+	recordAudit(AuditEntry{
+		ShopID:  shop.ID,
+		Action:  "month_reopened",
+		Actor:   session.UserInfo.Email,
+		Message: fmt.Sprintf("Reopened %s %d: %s", req.Month, req.Year, req.Reason),
+	})
+
+	log.Printf("Reopened %s %d for shop %s by %s", req.Month, req.Year, shop.ID, session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Month reopened, changes will be recorded as corrections",
+		"month_close": record,
+	})
+}
+
+// handlePayrollCorrections lists the corrections written to a month after it was closed
+func handlePayrollCorrections(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view payroll corrections", http.StatusForbidden)
+		return
+	}
+
+	shop, month, year, ok := versionParams(w, r, session)
+	if !ok {
+		return
+	}
+	monthNumber := getMonthNumber(month)
+
+	payrollCorrectionsMutex.RLock()
+	corrections := make([]PayrollCorrection, 0)
+	for _, correction := range payrollCorrections[shop.ID] {
+		if correction.Year == year && getMonthNumber(correction.Month) == monthNumber {
+			corrections = append(corrections, correction)
+		}
+	}
+	payrollCorrectionsMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"corrections": corrections})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	notificationsDataFile = "notifications_data.json"
+	maxNotificationsKept  = 200
+)
+
+type Notification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Message   string    `json:"message"`
+	RefID     string    `json:"ref_id,omitempty"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var (
+	notifications      = make(map[string][]Notification) // normalized recipient email -> notifications
+	notificationsMutex sync.RWMutex
+)
+
+func saveNotificationsData() error {
+	notificationsMutex.RLock()
+	defer notificationsMutex.RUnlock()
+	return writeJSONFileAtomic(notificationsDataFile, notifications)
+}
+
+func loadNotificationsData() error {
+	notificationsMutex.Lock()
+	defer notificationsMutex.Unlock()
+	return readJSONFile(notificationsDataFile, &notifications)
+}
+
+// notify stores a notification for each recipient, keeping only the most recent ones
+func notify(recipients []string, notificationType, message, refID string) {
+	now := time.Now()
+
+	notificationsMutex.Lock()
+	for _, recipient := range recipients {
+		key := normalizeEmail(recipient)
+		list := append(notifications[key], Notification{
+			ID:        generateRandomString(12),
+			Type:      notificationType,
+			Message:   message,
+			RefID:     refID,
+			CreatedAt: now,
+		})
+		if len(list) > maxNotificationsKept {
+			list = list[len(list)-maxNotificationsKept:]
+		}
+		notifications[key] = list
+	}
+	notificationsMutex.Unlock()
+
+	go saveNotificationsData()
+}
+
+func handleNotifications(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	email := normalizeEmail(session.UserInfo.Email)
+
+	switch r.Method {
+	case http.MethodGet:
+		notificationsMutex.RLock()
+		list := append([]Notification{}, notifications[email]...)
+		notificationsMutex.RUnlock()
+
+		unreadOnly := r.URL.Query().Get("unread") == "true"
+		result := make([]Notification, 0, len(list))
+		unread := 0
+		for _, notification := range list {
+			if !notification.Read {
+				unread++
+			} else if unreadOnly {
+				continue
+			}
+			result = append(result, notification)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"notifications": result,
+			"unread":        unread,
+		})
+
+	case http.MethodPost:
+		// Marks the given notifications as read, or all of them when no IDs are sent
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		ids := make(map[string]bool, len(req.IDs))
+		for _, id := range req.IDs {
+			ids[id] = true
+		}
+
+		notificationsMutex.Lock()
+		for i := range notifications[email] {
+			if len(ids) == 0 || ids[notifications[email][i].ID] {
+				notifications[email][i].Read = true
+			}
+		}
+		notificationsMutex.Unlock()
+
+		go saveNotificationsData()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Notifications marked as read"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,707 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const openShiftsFile = "open_shifts_data.json"
+
+const (
+	openShiftFirstCome = "first_come"
+	openShiftApproval  = "approval"
+
+	openShiftStatusOpen      = "open"
+	openShiftStatusFilling   = "filling" // the employer's choice is being written to the sheet
+	openShiftStatusFilled    = "filled"
+	openShiftStatusCancelled = "cancelled"
+)
+
+type OpenShiftClaim struct {
+	EmployeeEmail string    `json:"employee_email"`
+	EmployeeName  string    `json:"employee_name"`
+	ClaimedAt     time.Time `json:"claimed_at"`
+}
+
+// OpenShift is a shift published by the employer for any suitable employee of the shop to take
+type OpenShift struct {
+	ID           string            `json:"id"`
+	ShopID       string            `json:"shop_id"`
+	ShopName     string            `json:"shop_name"`
+	Date         string            `json:"date"`
+	Start        string            `json:"start"`
+	End          string            `json:"end"`
+	Position     string            `json:"position,omitempty"`
+	Note         string            `json:"note,omitempty"`
+	Mode         string            `json:"mode"` // first_come | approval
+	Status       string            `json:"status"`
+	Claims       []OpenShiftClaim  `json:"claims"`
+	FilledBy     string            `json:"filled_by,omitempty"`
+	FilledAt     *time.Time        `json:"filled_at,omitempty"`
+	SheetWritten bool              `json:"sheet_written"`
+	Issues       []ScheduleWarning `json:"issues,omitempty"` // why the last fill attempt failed
+	CreatedBy    string            `json:"created_by"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+var (
+	openShifts       = make(map[string]OpenShift) // open_shift_id -> shift
+	openShiftSyncing = make(map[string]bool)      // open_shift_id -> the claim is being written
+	openShiftsMutex  sync.RWMutex
+)
+
+func saveOpenShiftsData() error {
+	openShiftsMutex.RLock()
+	defer openShiftsMutex.RUnlock()
+	return writeJSONFileAtomic(openShiftsFile, openShifts)
+}
+
+func loadOpenShiftsData() error {
+	openShiftsMutex.Lock()
+	defer openShiftsMutex.Unlock()
+	return readJSONFile(openShiftsFile, &openShifts)
+}
+
+func getOpenShift(id string) (OpenShift, bool) {
+	openShiftsMutex.RLock()
+	defer openShiftsMutex.RUnlock()
+	shift, exists := openShifts[id]
+	return shift, exists
+}
+
+func storeOpenShift(shift OpenShift) {
+	openShiftsMutex.Lock()
+	openShifts[shift.ID] = shift
+	openShiftsMutex.Unlock()
+
+	go saveOpenShiftsData()
+}
+
+// updateOpenShift applies a status change to the stored open shift, only if it still has the
+// expected status. The change is made to the stored shift, so claims added since the caller
+// read it are kept.
+func updateOpenShift(id, expected string, update func(*OpenShift)) (OpenShift, error) {
+	openShiftsMutex.Lock()
+	shift, exists := openShifts[id]
+	if !exists {
+		openShiftsMutex.Unlock()
+		return OpenShift{}, fmt.Errorf("open shift %s not found", id)
+	}
+	if shift.Status != expected {
+		openShiftsMutex.Unlock()
+		return shift, fmt.Errorf("open shift is already %s", shift.Status)
+	}
+	shift.Claims = append([]OpenShiftClaim(nil), shift.Claims...)
+	update(&shift)
+	openShifts[id] = shift
+	openShiftsMutex.Unlock()
+
+	go saveOpenShiftsData()
+	return shift, nil
+}
+
+func (shift OpenShift) value() string {
+	return shift.Start + "-" + shift.End
+}
+
+// reopen gives a filled shift back to the other employees. The claim of the employee it was
+// filled by is dropped, so it cannot be approved again.
+func (shift *OpenShift) reopen() {
+	claims := make([]OpenShiftClaim, 0, len(shift.Claims))
+	for _, claim := range shift.Claims {
+		if claim.EmployeeEmail != shift.FilledBy {
+			claims = append(claims, claim)
+		}
+	}
+	shift.Claims = claims
+	shift.Status = openShiftStatusOpen
+	shift.FilledBy = ""
+	shift.FilledAt = nil
+}
+
+// canTake reports whether the employee holds the position the open shift requires
+func (shift OpenShift) canTake(employee Employee) bool {
+	return shift.Position == "" || strings.EqualFold(strings.TrimSpace(employee.Position), shift.Position)
+}
+
+// writeShiftCell puts a shift into an employee's free cell. Nothing is written when the cell
+// is taken or the shift would introduce compliance problems or a double booking.
+func writeShiftCell(ctx context.Context, service *SpreadsheetService, shop Shop, shopEmail, dateValue, value, actor string) (CellChange, []ScheduleWarning, error) {
+	date, err := time.ParseInLocation(dateLayout, dateValue, time.Local)
+	if err != nil {
+		return CellChange{}, nil, err
+	}
+	year := date.Year()
+	month := polishMonths[date.Month()-1]
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		return CellChange{}, nil, fmt.Errorf("no spreadsheet found for year %d", year)
+	}
+
+	unlock := lockScheduleMonth(shop.ID, month, year)
+	defer unlock()
+
+	if err := checkMonthOpen(shop.ID, month, year); err != nil {
+		return CellChange{}, nil, err
+	}
+	data, err := service.ReadMonthSchedule(ctx, spreadsheetID, month)
+	if err != nil {
+		return CellChange{}, nil, err
+	}
+
+	row, column, found := findScheduleCell(data, shop, shopEmail, date.Day())
+	if !found {
+		return CellChange{}, nil, fmt.Errorf("schedule cell for %s on %s not found", shopEmail, dateValue)
+	}
+
+	name := shop.Employees[shopEmail].Name
+	previous := cellString(data[row], column)
+	if !isFreeCell(previous) {
+		return CellChange{}, []ScheduleWarning{{
+			Type:          "cell_taken",
+			EmployeeEmail: shopEmail,
+			EmployeeName:  name,
+			Date:          dateValue,
+			Shift:         previous,
+			Message:       fmt.Sprintf("%s is already scheduled (%s) on %s", name, previous, dateValue),
+		}}, nil
+	}
+
+	original := cloneGrid(data)
+	before := checkCompliance(shop, month, year, parseMonthSchedule(data, shop, month, year))
+	for len(data[row]) <= column {
+		data[row] = append(data[row], "")
+	}
+	data[row][column] = value
+
+	shifts := parseMonthSchedule(data, shop, month, year)
+	issues := newComplianceWarnings(before, checkCompliance(shop, month, year, shifts))
+
+	var added []ScheduledShift
+	for _, shift := range shifts {
+		if shift.EmployeeEmail == shopEmail && shift.Date.Equal(date) {
+			added = append(added, shift)
+		}
+	}
+	owner, _, _ := shopOwner(shop.ID)
+	for _, conflict := range findShiftConflicts(owner, shop, added) {
+		issues = append(issues, ScheduleWarning{
+			Type:          "double_booking",
+			EmployeeEmail: conflict.EmployeeEmail,
+			EmployeeName:  conflict.EmployeeName,
+			Date:          conflict.Date,
+			Shift:         conflict.Shift,
+			Message:       fmt.Sprintf("%s already works in another shop during %s on %s", conflict.EmployeeName, conflict.Shift, conflict.Date),
+		})
+	}
+	if len(issues) > 0 {
+		return CellChange{}, issues, nil
+	}
+
+	data = applyScheduleTotals(data, shop, month, year)
+	if err := writeColumnSegment(ctx, service, spreadsheetID, month, data, column, row, lastTotalsRow(data, row)); err != nil {
+		return CellChange{}, nil, err
+	}
+	indexMonthSchedule(shop, month, year, data)
+	recordScheduleVersion(shop, original, data, ScheduleVersion{Month: month, Year: year, Action: "open_shift", Actor: actor})
+
+	return CellChange{EmployeeEmail: shopEmail, Date: dateValue, Before: previous, After: value}, nil, nil
+}
+
+// fillOpenShift writes the open shift into the claimant's column and marks it filled
+func fillOpenShift(ctx context.Context, service *SpreadsheetService, shift *OpenShift, employeeEmail, actor string) ([]ScheduleWarning, error) {
+	_, shop, exists := shopOwner(shift.ShopID)
+	if !exists {
+		return nil, fmt.Errorf("shop %s not found", shift.ShopID)
+	}
+	shopEmail, employee, exists := shopEmployee(shop, employeeEmail)
+	if !exists {
+		return nil, fmt.Errorf("employee %s no longer works in %s", employeeEmail, shop.Name)
+	}
+
+	change, issues, err := writeShiftCell(ctx, service, shop, shopEmail, shift.Date, shift.value(), actor)
+	if err != nil || len(issues) > 0 {
+		shift.Issues = issues
+		return issues, err
+	}
+
+	now := time.Now()
+	shift.Status = openShiftStatusFilled
+	shift.FilledBy = normalizeEmail(employeeEmail)
+	shift.FilledAt = &now
+	shift.SheetWritten = true
+	shift.Issues = nil
+
+	recordAudit(AuditEntry{
+		ShopID:  shop.ID,
+		Action:  "open_shift_filled",
+		Actor:   actor,
+		Message: fmt.Sprintf("Open shift %s on %s taken by %s", shift.value(), shift.Date, employee.Name),
+		RefID:   shift.ID,
+		Changes: []CellChange{change},
+	})
+	notify([]string{employeeEmail}, "open_shift_filled",
+		fmt.Sprintf("The open shift %s on %s in %s is yours", shift.value(), shift.Date, shop.Name), shift.ID)
+	return nil, nil
+}
+
+// finishOpenShiftSync stores the outcome of writing a first-come claim and lets the background
+// sync pick the shift up again
+func finishOpenShiftSync(shift OpenShift) {
+	openShiftsMutex.Lock()
+	openShifts[shift.ID] = shift
+	delete(openShiftSyncing, shift.ID)
+	openShiftsMutex.Unlock()
+
+	go saveOpenShiftsData()
+}
+
+// syncPendingOpenShifts writes first-come claims that were accepted while the employer was
+// signed out, with the employer's own Google session once they are back
+func syncPendingOpenShifts(ctx context.Context) {
+	openShiftsMutex.Lock()
+	var pending []OpenShift
+	for id, shift := range openShifts {
+		if shift.Status == openShiftStatusFilled && !shift.SheetWritten && !openShiftSyncing[id] {
+			openShiftSyncing[id] = true
+			pending = append(pending, shift)
+		}
+	}
+	openShiftsMutex.Unlock()
+
+	for _, shift := range pending {
+		owner, _, exists := shopOwner(shift.ShopID)
+		service, ok := cachedSpreadsheetService(owner)
+		if !exists || !ok {
+			finishOpenShiftSync(shift)
+			continue
+		}
+
+		claimant := shift.FilledBy
+		issues, err := fillOpenShift(ctx, service, &shift, claimant, claimant)
+		if err != nil {
+			log.Printf("Error syncing open shift %s: %v", shift.ID, err)
+		} else if len(issues) > 0 {
+			// The schedule changed in the meantime; reopen the shift for someone else
+			shift.reopen()
+			notify([]string{claimant}, "open_shift_reopened",
+				fmt.Sprintf("The open shift %s on %s could not be given to you: %s", shift.value(), shift.Date, issues[0].Message), shift.ID)
+		}
+		finishOpenShiftSync(shift)
+	}
+}
+
+// openShiftsFor lists open shifts visible to the session, soonest first. Employees see the
+// open shifts of their shops they qualify for, plus the ones they claimed.
+func openShiftsFor(session Session) []OpenShift {
+	openShiftsMutex.RLock()
+	defer openShiftsMutex.RUnlock()
+
+	email := normalizeEmail(session.UserInfo.Email)
+	result := make([]OpenShift, 0)
+	for _, shift := range openShifts {
+		if session.Role == "employer" {
+			if _, owned := getEmployerShop(session.UserInfo.Email, shift.ShopID); !owned {
+				continue
+			}
+		} else {
+			_, shop, exists := findShopForEmployee(session.UserInfo.Email, shift.ShopID)
+			if !exists {
+				continue
+			}
+			_, employee, _ := shopEmployee(shop, email)
+			claimed := shift.FilledBy == email
+			for _, claim := range shift.Claims {
+				claimed = claimed || claim.EmployeeEmail == email
+			}
+			if !claimed && (shift.Status != openShiftStatusOpen || !shift.canTake(employee)) {
+				continue
+			}
+		}
+		result = append(result, shift)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
+
+func handleOpenShifts(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shopID := r.URL.Query().Get("shop_id")
+		status := r.URL.Query().Get("status")
+		shifts := openShiftsFor(session)
+		filtered := make([]OpenShift, 0, len(shifts))
+		for _, shift := range shifts {
+			if (shopID == "" || shift.ShopID == shopID) && (status == "" || shift.Status == status) {
+				filtered = append(filtered, shift)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"open_shifts": filtered})
+
+	case http.MethodPost:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can publish open shifts", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			ShopID   string `json:"shop_id"`
+			Date     string `json:"date"`
+			Start    string `json:"start"`
+			End      string `json:"end"`
+			Position string `json:"position"`
+			Note     string `json:"note"`
+			Mode     string `json:"mode"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		date, err := time.ParseInLocation(dateLayout, req.Date, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if _, exists := shop.Spreadsheets[date.Year()]; !exists {
+			http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", date.Year()), http.StatusBadRequest)
+			return
+		}
+		if _, _, ok := parseShiftTimes(req.Start + "-" + req.End); !ok {
+			http.Error(w, "Invalid start or end time, expected HH:MM", http.StatusBadRequest)
+			return
+		}
+
+		if req.Mode == "" {
+			req.Mode = shop.Settings.OpenShiftMode
+		}
+		if req.Mode == "" {
+			req.Mode = openShiftFirstCome
+		}
+		if req.Mode != openShiftFirstCome && req.Mode != openShiftApproval {
+			http.Error(w, "Mode must be first_come or approval", http.StatusBadRequest)
+			return
+		}
+
+		shift := OpenShift{
+			ID:        generateRandomString(16),
+			ShopID:    shop.ID,
+			ShopName:  shop.Name,
+			Date:      req.Date,
+			Start:     req.Start,
+			End:       req.End,
+			Position:  strings.TrimSpace(req.Position),
+			Note:      strings.TrimSpace(req.Note),
+			Mode:      req.Mode,
+			Status:    openShiftStatusOpen,
+			Claims:    []OpenShiftClaim{},
+			CreatedBy: session.UserInfo.Email,
+			CreatedAt: time.Now(),
+		}
+		storeOpenShift(shift)
+
+		var recipients []string
+		for _, employee := range shop.Employees {
+			if shift.canTake(employee) {
+				recipients = append(recipients, employee.Email)
+			}
+		}
+		notify(recipients, "open_shift",
+			fmt.Sprintf("Open shift in %s on %s, %s", shop.Name, shift.Date, shift.value()), shift.ID)
+
+		log.Printf("Published open shift %s in shop %s by %s", shift.ID, shop.ID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shift)
+
+	case http.MethodDelete:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can cancel open shifts", http.StatusForbidden)
+			return
+		}
+
+		shift, exists := getOpenShift(r.URL.Query().Get("id"))
+		if !exists {
+			http.Error(w, "Open shift not found", http.StatusNotFound)
+			return
+		}
+		if _, owned := getEmployerShop(session.UserInfo.Email, shift.ShopID); !owned {
+			http.Error(w, "Open shift not found", http.StatusNotFound)
+			return
+		}
+
+		shift, err := updateOpenShift(shift.ID, openShiftStatusOpen, func(shift *OpenShift) {
+			shift.Status = openShiftStatusCancelled
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Open shift is already %s", shift.Status), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shift)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleOpenShiftClaim lets an employee take an open shift. In first-come mode the first valid
+// claim fills the shift; in approval mode claims are collected for the employer to choose from.
+func handleOpenShiftClaim(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employee" {
+		http.Error(w, "Only employees can claim open shifts", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	email := normalizeEmail(session.UserInfo.Email)
+
+	// Claims are serialised so two employees cannot both win a first-come shift
+	openShiftsMutex.Lock()
+	shift, exists := openShifts[req.ID]
+	if !exists || shift.Status != openShiftStatusOpen {
+		openShiftsMutex.Unlock()
+		http.Error(w, "Open shift is no longer available", http.StatusConflict)
+		return
+	}
+	owner, shop, shopExists := shopOwner(shift.ShopID)
+	_, employee, isEmployee := shopEmployee(shop, email)
+	if !shopExists || !isEmployee {
+		openShiftsMutex.Unlock()
+		http.Error(w, "Open shift not found", http.StatusNotFound)
+		return
+	}
+	if !shift.canTake(employee) {
+		openShiftsMutex.Unlock()
+		http.Error(w, fmt.Sprintf("This shift requires the position %s", shift.Position), http.StatusForbidden)
+		return
+	}
+	for _, claim := range shift.Claims {
+		if claim.EmployeeEmail == email {
+			openShiftsMutex.Unlock()
+			http.Error(w, "You already claimed this shift", http.StatusConflict)
+			return
+		}
+	}
+	date, _ := time.ParseInLocation(dateLayout, shift.Date, time.Local)
+	if _, busy := indexedShiftOn(shop.ID, email, date); busy {
+		openShiftsMutex.Unlock()
+		http.Error(w, "You already have a shift on that day", http.StatusConflict)
+		return
+	}
+
+	shift.Claims = append(shift.Claims, OpenShiftClaim{EmployeeEmail: email, EmployeeName: employee.Name, ClaimedAt: time.Now()})
+	if shift.Mode == openShiftFirstCome {
+		now := time.Now()
+		shift.Status = openShiftStatusFilled
+		shift.FilledBy = email
+		shift.FilledAt = &now
+		openShiftSyncing[shift.ID] = true
+	}
+	openShifts[shift.ID] = shift
+	openShiftsMutex.Unlock()
+
+	if shift.Mode == openShiftApproval {
+		go saveOpenShiftsData()
+		notify([]string{owner}, "open_shift_claimed",
+			fmt.Sprintf("%s wants the open shift %s on %s in %s", employee.Name, shift.value(), shift.Date, shop.Name), shift.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shift)
+		return
+	}
+
+	// First come: write straight away with the employer's Google session, or from the background
+	// sync once they are signed in
+	if service, ok := cachedSpreadsheetService(owner); ok {
+		issues, err := fillOpenShift(r.Context(), service, &shift, email, email)
+		if err != nil {
+			log.Printf("Error writing open shift %s: %v", shift.ID, err)
+		} else if len(issues) > 0 {
+			shift.reopen()
+			finishOpenShiftSync(shift)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":  "You cannot take this shift",
+				"issues": issues,
+			})
+			return
+		}
+	}
+	finishOpenShiftSync(shift)
+
+	notify([]string{owner}, "open_shift_claimed",
+		fmt.Sprintf("%s took the open shift %s on %s in %s", employee.Name, shift.value(), shift.Date, shop.Name), shift.ID)
+
+	log.Printf("Employee %s took open shift %s", email, shift.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
+
+// handleOpenShiftDecision assigns an approval-mode open shift to one of its claimants
+func handleOpenShiftDecision(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can assign open shifts", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ID            string `json:"id"`
+		EmployeeEmail string `json:"employee_email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shift, exists := getOpenShift(req.ID)
+	if !exists {
+		http.Error(w, "Open shift not found", http.StatusNotFound)
+		return
+	}
+	if _, owned := getEmployerShop(session.UserInfo.Email, shift.ShopID); !owned {
+		http.Error(w, "Open shift not found", http.StatusNotFound)
+		return
+	}
+
+	claimed := false
+	for _, claim := range shift.Claims {
+		claimed = claimed || claim.EmployeeEmail == normalizeEmail(req.EmployeeEmail)
+	}
+	if !claimed {
+		http.Error(w, "This employee has not claimed the shift", http.StatusBadRequest)
+		return
+	}
+
+	service, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	// Take the shift out of the open ones first, so a second decision or a cancellation made
+	// while the sheet is written is turned down
+	shift, err = updateOpenShift(shift.ID, openShiftStatusOpen, func(shift *OpenShift) {
+		shift.Status = openShiftStatusFilling
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Open shift is already %s", shift.Status), http.StatusConflict)
+		return
+	}
+
+	issues, err := fillOpenShift(r.Context(), service, &shift, req.EmployeeEmail, session.UserInfo.Email)
+	filled := shift
+	shift, _ = updateOpenShift(shift.ID, openShiftStatusFilling, func(shift *OpenShift) {
+		if err != nil || len(issues) > 0 {
+			shift.Status = openShiftStatusOpen
+			shift.Issues = filled.Issues
+			return
+		}
+		shift.Status = filled.Status
+		shift.FilledBy = filled.FilledBy
+		shift.FilledAt = filled.FilledAt
+		shift.SheetWritten = filled.SheetWritten
+		shift.Issues = nil
+	})
+	if respondMonthLocked(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error filling open shift %s: %v", shift.ID, err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+	if len(issues) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":      "Open shift cannot be given to this employee",
+			"issues":     issues,
+			"open_shift": shift,
+		})
+		return
+	}
+
+	var others []string
+	for _, claim := range shift.Claims {
+		if claim.EmployeeEmail != shift.FilledBy {
+			others = append(others, claim.EmployeeEmail)
+		}
+	}
+	notify(others, "open_shift_taken",
+		fmt.Sprintf("The open shift %s on %s in %s was given to someone else", shift.value(), shift.Date, shift.ShopName), shift.ID)
+
+	log.Printf("Open shift %s assigned to %s by %s", shift.ID, req.EmployeeEmail, session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shift)
+}
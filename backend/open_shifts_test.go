@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestUpdateOpenShiftOnlyOneDecisionWins(t *testing.T) {
+	const id = "open-shift-test"
+	openShiftsMutex.Lock()
+	openShifts[id] = OpenShift{ID: id, Status: openShiftStatusOpen, Claims: []OpenShiftClaim{{EmployeeEmail: "anna@example.com"}}}
+	openShiftsMutex.Unlock()
+	defer func() {
+		openShiftsMutex.Lock()
+		delete(openShifts, id)
+		openShiftsMutex.Unlock()
+	}()
+
+	var wins int
+	var winsMutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := updateOpenShift(id, openShiftStatusOpen, func(shift *OpenShift) {
+				shift.Status = openShiftStatusFilling
+			})
+			if err == nil {
+				winsMutex.Lock()
+				wins++
+				winsMutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if wins != 1 {
+		t.Fatalf("%d decisions took the shift, want 1", wins)
+	}
+
+	// A claim stored meanwhile survives the outcome being recorded
+	openShiftsMutex.Lock()
+	shift := openShifts[id]
+	shift.Claims = append(shift.Claims, OpenShiftClaim{EmployeeEmail: "ewa@example.com"})
+	openShifts[id] = shift
+	openShiftsMutex.Unlock()
+
+	shift, err := updateOpenShift(id, openShiftStatusFilling, func(shift *OpenShift) {
+		shift.Status = openShiftStatusOpen
+	})
+	if err != nil {
+		t.Fatalf("reverting the shift: %v", err)
+	}
+	if len(shift.Claims) != 2 {
+		t.Errorf("got %d claims, want the claim added meanwhile to be kept", len(shift.Claims))
+	}
+	if _, err := updateOpenShift(id, openShiftStatusFilling, func(*OpenShift) {}); err == nil {
+		t.Errorf("a shift that is open again should not be updated as filling")
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpeningHours are the regular hours for a weekday (0 = Sunday ... 6 = Saturday)
+type OpeningHours struct {
+	Weekday int    `json:"weekday"`
+	Open    string `json:"open,omitempty"`  // HH:MM
+	Close   string `json:"close,omitempty"` // HH:MM
+	Closed  bool   `json:"closed,omitempty"`
+}
+
+// OpeningException overrides the regular hours on one date
+type OpeningException struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	Open   string `json:"open,omitempty"`
+	Close  string `json:"close,omitempty"`
+	Closed bool   `json:"closed,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ShopHours describes when a shop is open. MinimumStaff is the number of people needed at any
+// time the shop is open; coverage requirements add to it for their slots.
+type ShopHours struct {
+	Weekly                 []OpeningHours     `json:"weekly"`
+	Exceptions             []OpeningException `json:"exceptions"`
+	ClosedOnPublicHolidays bool               `json:"closed_on_public_holidays"`
+	MinimumStaff           int                `json:"minimum_staff"`
+}
+
+// StaffingInterval is a stretch of a day with a constant difference between needed and scheduled staff
+type StaffingInterval struct {
+	Start    string   `json:"start"`
+	End      string   `json:"end"`
+	Required int      `json:"required"`
+	Staffed  int      `json:"staffed"`
+	Status   string   `json:"status"` // understaffed | overstaffed
+	People   []string `json:"people"`
+}
+
+type DayGaps struct {
+	Date      string             `json:"date"`
+	Open      string             `json:"open,omitempty"`
+	Close     string             `json:"close,omitempty"`
+	Closed    bool               `json:"closed"`
+	Note      string             `json:"note,omitempty"`
+	Intervals []StaffingInterval `json:"intervals"`
+}
+
+func validateOpeningWindow(open, close string, closed bool) error {
+	if closed {
+		return nil
+	}
+	if _, _, ok := parseShiftTimes(open + "-" + close); !ok {
+		return fmt.Errorf("invalid opening hours %s-%s, expected HH:MM times", open, close)
+	}
+	return nil
+}
+
+func (hours ShopHours) validate() error {
+	seen := make(map[int]bool)
+	for _, day := range hours.Weekly {
+		if day.Weekday < 0 || day.Weekday > 6 {
+			return fmt.Errorf("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		if seen[day.Weekday] {
+			return fmt.Errorf("weekday %d is listed twice", day.Weekday)
+		}
+		seen[day.Weekday] = true
+		if err := validateOpeningWindow(day.Open, day.Close, day.Closed); err != nil {
+			return err
+		}
+	}
+
+	dates := make(map[string]bool)
+	for _, exception := range hours.Exceptions {
+		if _, err := time.Parse(dateLayout, exception.Date); err != nil {
+			return fmt.Errorf("invalid exception date %q, expected YYYY-MM-DD", exception.Date)
+		}
+		if dates[exception.Date] {
+			return fmt.Errorf("exception for %s is listed twice", exception.Date)
+		}
+		dates[exception.Date] = true
+		if err := validateOpeningWindow(exception.Open, exception.Close, exception.Closed); err != nil {
+			return err
+		}
+	}
+
+	if hours.MinimumStaff < 0 {
+		return fmt.Errorf("minimum_staff cannot be negative")
+	}
+	return nil
+}
+
+// openingOn resolves the hours for a day: date exceptions first, then public holidays, then
+// the weekday. ok is false when the shop has no opening hours configured for the day.
+func (shop Shop) openingOn(day time.Time) (open, close time.Time, note string, closed, ok bool) {
+	if shop.Hours == nil {
+		return time.Time{}, time.Time{}, "", false, false
+	}
+
+	window := func(openValue, closeValue string) (time.Time, time.Time) {
+		start, end, _ := parseShiftTimes(openValue + "-" + closeValue)
+		return day.Add(time.Duration(start) * time.Minute), day.Add(time.Duration(end) * time.Minute)
+	}
+
+	date := day.Format(dateLayout)
+	for _, exception := range shop.Hours.Exceptions {
+		if exception.Date == date {
+			if exception.Closed {
+				return time.Time{}, time.Time{}, exception.Note, true, true
+			}
+			open, close = window(exception.Open, exception.Close)
+			return open, close, exception.Note, false, true
+		}
+	}
+
+	if shop.Hours.ClosedOnPublicHolidays {
+		if name, holiday := polishPublicHolidays(day.Year())[date]; holiday {
+			return time.Time{}, time.Time{}, name, true, true
+		}
+	}
+
+	for _, regular := range shop.Hours.Weekly {
+		if time.Weekday(regular.Weekday) == day.Weekday() {
+			if regular.Closed {
+				return time.Time{}, time.Time{}, "", true, true
+			}
+			open, close = window(regular.Open, regular.Close)
+			return open, close, "", false, true
+		}
+	}
+	return time.Time{}, time.Time{}, "", false, false
+}
+
+// isClosedOn reports whether the shop is known to be closed for the whole day
+func (shop Shop) isClosedOn(day time.Time) bool {
+	_, _, _, closed, _ := shop.openingOn(day)
+	return closed
+}
+
+// staffingGaps compares scheduled staff with what the shop needs, minute by minute, and
+// returns the intervals where the two differ
+func staffingGaps(shop Shop, day time.Time, shifts []ScheduledShift) DayGaps {
+	result := DayGaps{Date: day.Format(dateLayout), Intervals: make([]StaffingInterval, 0)}
+
+	open, close, note, closed, configured := shop.openingOn(day)
+	result.Closed = closed
+	result.Note = note
+	if configured && !closed {
+		result.Open = open.Format("15:04")
+		result.Close = close.Format("15:04")
+	}
+
+	minimum := 0
+	if shop.Hours != nil {
+		minimum = shop.Hours.MinimumStaff
+	}
+	coverage := shop.coverageOn(day)
+	dayEnd := day.AddDate(0, 0, 1)
+
+	var current *StaffingInterval
+	flush := func(at time.Time) {
+		if current != nil {
+			current.End = at.Format("15:04")
+			if at.Equal(dayEnd) {
+				current.End = "24:00"
+			}
+			result.Intervals = append(result.Intervals, *current)
+			current = nil
+		}
+	}
+
+	for minute := day; minute.Before(dayEnd); minute = minute.Add(time.Minute) {
+		required := 0
+		if configured && !closed && !minute.Before(open) && minute.Before(close) {
+			required = minimum
+		}
+		for _, requirement := range coverage {
+			start, end := requirement.window(day)
+			if !minute.Before(start) && minute.Before(end) {
+				required += requirement.Count
+			}
+		}
+
+		var people []string
+		working := make(map[string]bool)
+		for _, shift := range shifts {
+			if !minute.Before(shift.Start) && minute.Before(shift.End) && !working[shift.EmployeeEmail] {
+				working[shift.EmployeeEmail] = true
+				people = append(people, shop.Employees[shift.EmployeeEmail].Name)
+			}
+		}
+		sort.Strings(people)
+
+		status := ""
+		if len(people) < required {
+			status = "understaffed"
+		} else if len(people) > required {
+			status = "overstaffed"
+		}
+
+		if current != nil && (current.Status != status || current.Required != required || strings.Join(current.People, "|") != strings.Join(people, "|")) {
+			flush(minute)
+		}
+		if status != "" && current == nil {
+			if people == nil {
+				people = []string{}
+			}
+			current = &StaffingInterval{Start: minute.Format("15:04"), Required: required, Staffed: len(people), Status: status, People: people}
+		}
+	}
+	flush(dayEnd)
+
+	return result
+}
+
+func handleOpeningHours(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage opening hours", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		hours := ShopHours{Weekly: []OpeningHours{}, Exceptions: []OpeningException{}}
+		if shop.Hours != nil {
+			hours = *shop.Hours
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hours)
+
+	case http.MethodPut:
+		var hours ShopHours
+		if err := json.NewDecoder(r.Body).Decode(&hours); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := hours.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sort.Slice(hours.Weekly, func(i, j int) bool { return hours.Weekly[i].Weekday < hours.Weekly[j].Weekday })
+		sort.Slice(hours.Exceptions, func(i, j int) bool { return hours.Exceptions[i].Date < hours.Exceptions[j].Date })
+
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][shopID]
+		shop.Hours = &hours
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][shopID] = shop
+		employerShopsMutex.Unlock()
+
+		go saveShopsData()
+
+		log.Printf("Updated opening hours for shop %s by employer %s", shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(hours)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScheduleGaps reads a month and reports under- and overstaffed intervals for each day
+func handleScheduleGaps(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can analyse staffing", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if shop.Hours == nil && len(shop.Coverage) == 0 {
+		http.Error(w, "Set the shop's opening hours or coverage requirements first", http.StatusBadRequest)
+		return
+	}
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+	if err != nil {
+		log.Printf("Error reading schedule data for gap analysis: %v", err)
+		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
+		return
+	}
+	shifts := indexMonthSchedule(shop, month, year, data)
+
+	monthNum := getMonthNumber(month)
+	days := make([]DayGaps, 0, getDaysInMonth(month, year))
+	for day := 1; day <= getDaysInMonth(month, year); day++ {
+		date := time.Date(year, monthNum, day, 0, 0, 0, 0, time.Local)
+		// Overnight shifts from the previous day still count towards this day's early hours
+		var relevant []ScheduledShift
+		for _, shift := range shifts {
+			if shift.Start.Before(date.AddDate(0, 0, 1)) && shift.End.After(date) {
+				relevant = append(relevant, shift)
+			}
+		}
+		days = append(days, staffingGaps(shop, date, relevant))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shop_id": shop.ID,
+		"year":    year,
+		"month":   month,
+		"days":    days,
+	})
+}
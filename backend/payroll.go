@@ -0,0 +1,715 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PremiumRules configures the premiums paid on top of base pay for a shop.
+// Percentages are expressed as whole numbers (20 means 20%).
+type PremiumRules struct {
+	NightStart                  string  `json:"night_start"` // HH:MM
+	NightEnd                    string  `json:"night_end"`   // HH:MM
+	NightPremiumPercent         float64 `json:"night_premium_percent"`
+	MinimumMonthlyWage          float64 `json:"minimum_monthly_wage"` // 0 follows the statutory table
+	DailyNormHours              float64 `json:"daily_norm_hours"`
+	OvertimePercent             float64 `json:"overtime_percent"`
+	OvertimeNightHolidayPercent float64 `json:"overtime_night_holiday_percent"`
+	HolidayPremiumPercent       float64 `json:"holiday_premium_percent"`
+	SundayIsHoliday             bool    `json:"sunday_is_holiday"`
+}
+
+// ScheduledShift is a single worked shift read from a month sheet
+type ScheduledShift struct {
+	EmployeeEmail string    `json:"employee_email"`
+	Date          time.Time `json:"date"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Value         string    `json:"value"`
+	BreakMinutes  int       `json:"break_minutes,omitempty"` // unpaid break of a shift template
+}
+
+type PayrollBreakdown struct {
+	EmployeeEmail    string  `json:"employee_email"`
+	EmployeeName     string  `json:"employee_name"`
+	Hours            float64 `json:"hours"`
+	NightHours       float64 `json:"night_hours"`
+	OvertimeHours50  float64 `json:"overtime_hours_50"`
+	OvertimeHours100 float64 `json:"overtime_hours_100"`
+	HolidayHours     float64 `json:"holiday_hours"`
+	BasePay          float64 `json:"base_pay"`
+	NightPremium     float64 `json:"night_premium"`
+	OvertimePremium  float64 `json:"overtime_premium"`
+	HolidayPremium   float64 `json:"holiday_premium"`
+	AbsenceHours     float64 `json:"absence_hours"`
+	AbsencePay       float64 `json:"absence_pay"`
+	SickHours        float64 `json:"sick_hours"` // part of the absence hours and pay spent on sick leave
+	SickPay          float64 `json:"sick_pay"`
+	Total            float64 `json:"total"`
+	ContractType     string  `json:"contract_type"`
+	Net              NetPay  `json:"net"` // employee ZUS, health contribution, PIT advance and net pay of Total
+
+	contract EmploymentContract
+}
+
+type PayrollResponse struct {
+	ShopID    string             `json:"shop_id"`
+	ShopName  string             `json:"shop_name"`
+	Year      int                `json:"year"`
+	Month     string             `json:"month"`
+	Rules     PremiumRules       `json:"rules"`
+	Hours     string             `json:"hours"` // planned | actual
+	Employees []PayrollBreakdown `json:"employees"`
+}
+
+var shiftTimePattern = regexp.MustCompile(`(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})`)
+
+func defaultPremiumRules() PremiumRules {
+	return PremiumRules{
+		NightStart:                  "21:00",
+		NightEnd:                    "07:00",
+		NightPremiumPercent:         20,
+		MinimumMonthlyWage:          0,
+		DailyNormHours:              8,
+		OvertimePercent:             50,
+		OvertimeNightHolidayPercent: 100,
+		HolidayPremiumPercent:       100,
+		SundayIsHoliday:             true,
+	}
+}
+
+// premiumRules returns the shop's configured rules or the statutory defaults
+func (shop Shop) premiumRules() PremiumRules {
+	if shop.PremiumRules == nil {
+		return defaultPremiumRules()
+	}
+	return *shop.PremiumRules
+}
+
+func (rules PremiumRules) validate() error {
+	if _, ok := parseClockMinutes(rules.NightStart); !ok {
+		return fmt.Errorf("invalid night_start %q, expected HH:MM", rules.NightStart)
+	}
+	if _, ok := parseClockMinutes(rules.NightEnd); !ok {
+		return fmt.Errorf("invalid night_end %q, expected HH:MM", rules.NightEnd)
+	}
+	if rules.DailyNormHours <= 0 || rules.DailyNormHours > 24 {
+		return fmt.Errorf("daily_norm_hours must be between 0 and 24")
+	}
+	if rules.NightPremiumPercent < 0 || rules.OvertimePercent < 0 || rules.OvertimeNightHolidayPercent < 0 || rules.HolidayPremiumPercent < 0 {
+		return fmt.Errorf("premium percentages cannot be negative")
+	}
+	if rules.MinimumMonthlyWage < 0 {
+		return fmt.Errorf("minimum_monthly_wage cannot be negative")
+	}
+	return nil
+}
+
+// parseClockMinutes parses HH:MM into minutes after midnight
+func parseClockMinutes(value string) (int, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours < 0 || hours > 24 {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil || minutes < 0 || minutes > 59 || (hours == 24 && minutes != 0) {
+		return 0, false
+	}
+	return hours*60 + minutes, true
+}
+
+// parseShiftTimes reads a "HH:MM-HH:MM" cell. Shifts ending before they start run past midnight.
+func parseShiftTimes(value string) (startMinutes, endMinutes int, ok bool) {
+	match := shiftTimePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, false
+	}
+
+	start, startOK := parseClockMinutes(match[1] + ":" + match[2])
+	end, endOK := parseClockMinutes(match[3] + ":" + match[4])
+	if !startOK || !endOK {
+		return 0, 0, false
+	}
+	if end <= start {
+		end += 24 * 60
+	}
+	return start, end, true
+}
+
+func cellString(row []interface{}, index int) string {
+	if index < 0 || index >= len(row) || row[index] == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprintf("%v", row[index]))
+}
+
+// scheduleColumns maps month sheet column indexes to employee emails using the header names
+func scheduleColumns(header []interface{}, employees map[string]Employee) map[int]string {
+	columns := make(map[int]string)
+	for index := 1; index < len(header); index++ {
+		name := strings.ToUpper(cellString(header, index))
+		if name == "" || name == "TAGI" {
+			continue
+		}
+		for email, employee := range employees {
+			if strings.ToUpper(strings.TrimSpace(employee.Name)) == name {
+				columns[index] = email
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// parseDayCell extracts the day of month from a "Poniedziałek 1" style cell
+func parseDayCell(value string) (int, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	day, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || day < 1 || day > 31 {
+		return 0, false
+	}
+	return day, true
+}
+
+// forEachScheduleCell calls fn for every non-empty employee cell of the day rows in a month grid
+func forEachScheduleCell(data [][]interface{}, employees map[string]Employee, month string, year int, fn func(email string, date time.Time, value string)) {
+	if len(data) == 0 {
+		return
+	}
+
+	monthNum := getMonthNumber(month)
+	columns := scheduleColumns(data[0], employees)
+
+	for _, row := range data[1:] {
+		first := cellString(row, 0)
+		if first == "SUMA GODZIN" || first == "WYPŁATA" {
+			break
+		}
+		day, ok := parseDayCell(first)
+		if !ok {
+			continue
+		}
+		date := time.Date(year, monthNum, day, 0, 0, 0, 0, time.Local)
+
+		for column, email := range columns {
+			if value := cellString(row, column); value != "" {
+				fn(email, date, value)
+			}
+		}
+	}
+}
+
+// parseMonthSchedule extracts worked shifts from a month sheet grid. Cells may hold explicit
+// times or shift template codes, which resolve to the template valid on the cell's day.
+func parseMonthSchedule(data [][]interface{}, shop Shop, month string, year int) []ScheduledShift {
+	var shifts []ScheduledShift
+	forEachScheduleCell(data, shop.Employees, month, year, func(email string, date time.Time, value string) {
+		startMinutes, endMinutes, breakMinutes, ok := shop.resolveShift(value, date)
+		if !ok {
+			return
+		}
+		shifts = append(shifts, ScheduledShift{
+			EmployeeEmail: email,
+			Date:          date,
+			Start:         date.Add(time.Duration(startMinutes) * time.Minute),
+			End:           date.Add(time.Duration(endMinutes) * time.Minute),
+			Value:         value,
+			BreakMinutes:  breakMinutes,
+		})
+	})
+
+	sort.Slice(shifts, func(i, j int) bool {
+		if !shifts[i].Start.Equal(shifts[j].Start) {
+			return shifts[i].Start.Before(shifts[j].Start)
+		}
+		return shifts[i].EmployeeEmail < shifts[j].EmployeeEmail
+	})
+	return shifts
+}
+
+func (s ScheduledShift) Hours() float64 {
+	return s.End.Sub(s.Start).Hours() - float64(s.BreakMinutes)/60
+}
+
+// breakWindow returns the shift's unpaid break, which is placed in the middle of the shift
+func (s ScheduledShift) breakWindow() (time.Time, time.Time, bool) {
+	if s.BreakMinutes <= 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	breakLength := time.Duration(s.BreakMinutes) * time.Minute
+	breakStart := s.Start.Add((s.End.Sub(s.Start) - breakLength) / 2)
+	return breakStart, breakStart.Add(breakLength), true
+}
+
+// easterSunday uses the anonymous Gregorian algorithm
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+}
+
+var (
+	publicHolidayCache      = make(map[int]map[string]string)
+	publicHolidayCacheMutex sync.Mutex
+)
+
+// polishPublicHolidays returns the statutory days off work for a year keyed by YYYY-MM-DD.
+// Each year is built once, the returned map is shared and must not be modified.
+func polishPublicHolidays(year int) map[string]string {
+	publicHolidayCacheMutex.Lock()
+	defer publicHolidayCacheMutex.Unlock()
+
+	holidays, cached := publicHolidayCache[year]
+	if !cached {
+		holidays = buildPolishPublicHolidays(year)
+		publicHolidayCache[year] = holidays
+	}
+	return holidays
+}
+
+func buildPolishPublicHolidays(year int) map[string]string {
+	easter := easterSunday(year)
+	holidays := map[string]string{
+		fmt.Sprintf("%d-01-01", year): "Nowy Rok",
+		fmt.Sprintf("%d-01-06", year): "Trzech Króli",
+		fmt.Sprintf("%d-05-01", year): "Święto Pracy",
+		fmt.Sprintf("%d-05-03", year): "Święto Konstytucji 3 Maja",
+		fmt.Sprintf("%d-08-15", year): "Wniebowzięcie NMP",
+		fmt.Sprintf("%d-11-01", year): "Wszystkich Świętych",
+		fmt.Sprintf("%d-11-11", year): "Święto Niepodległości",
+		fmt.Sprintf("%d-12-25", year): "Boże Narodzenie",
+		fmt.Sprintf("%d-12-26", year): "Drugi dzień Bożego Narodzenia",
+	}
+	if year >= 2025 {
+		holidays[fmt.Sprintf("%d-12-24", year)] = "Wigilia"
+	}
+	holidays[easter.Format("2006-01-02")] = "Wielkanoc"
+	holidays[easter.AddDate(0, 0, 1).Format("2006-01-02")] = "Poniedziałek Wielkanocny"
+	holidays[easter.AddDate(0, 0, 49).Format("2006-01-02")] = "Zielone Świątki"
+	holidays[easter.AddDate(0, 0, 60).Format("2006-01-02")] = "Boże Ciało"
+	return holidays
+}
+
+func isPublicHoliday(date time.Time) bool {
+	_, exists := polishPublicHolidays(date.Year())[date.Format("2006-01-02")]
+	return exists
+}
+
+// nominalWorkingHours is the full-time working time norm for a month (8h per working day)
+func nominalWorkingHours(month time.Month, year int) float64 {
+	holidays := polishPublicHolidays(year)
+	days := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local).Day()
+	workingDays := 0
+	for day := 1; day <= days; day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		if _, holiday := holidays[date.Format("2006-01-02")]; holiday {
+			continue
+		}
+		workingDays++
+	}
+	return float64(workingDays * 8)
+}
+
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func (rules PremiumRules) isNightMinute(t time.Time) bool {
+	nightStart, _ := parseClockMinutes(rules.NightStart)
+	nightEnd, _ := parseClockMinutes(rules.NightEnd)
+	minute := t.Hour()*60 + t.Minute()
+	if nightStart <= nightEnd {
+		return minute >= nightStart && minute < nightEnd
+	}
+	return minute >= nightStart || minute < nightEnd
+}
+
+func (rules PremiumRules) isHolidayMinute(t time.Time) bool {
+	if rules.SundayIsHoliday && t.Weekday() == time.Sunday {
+		return true
+	}
+	return isPublicHoliday(t)
+}
+
+// shiftSegment is a worked stretch of a shift during which the night and holiday state does
+// not change
+type shiftSegment struct {
+	Start, End     time.Time
+	Night, Holiday bool
+}
+
+// workedSegments cuts a shift at midnights, the night window edges and its break, so every
+// segment can be priced as a whole instead of minute by minute
+func (rules PremiumRules) workedSegments(shift ScheduledShift) []shiftSegment {
+	cuts := []time.Time{shift.Start, shift.End}
+	breakStart, breakEnd, hasBreak := shift.breakWindow()
+	if hasBreak {
+		cuts = append(cuts, breakStart, breakEnd)
+	}
+
+	nightStart, _ := parseClockMinutes(rules.NightStart)
+	nightEnd, _ := parseClockMinutes(rules.NightEnd)
+	day := time.Date(shift.Start.Year(), shift.Start.Month(), shift.Start.Day(), 0, 0, 0, 0, shift.Start.Location())
+	for ; day.Before(shift.End); day = day.AddDate(0, 0, 1) {
+		cuts = append(cuts, day)
+		for _, minute := range []int{nightStart, nightEnd} {
+			cuts = append(cuts, time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location()))
+		}
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+
+	var segments []shiftSegment
+	for i := 0; i+1 < len(cuts); i++ {
+		start, end := cuts[i], cuts[i+1]
+		if start.Before(shift.Start) || end.After(shift.End) || !start.Before(end) {
+			continue
+		}
+		if hasBreak && !start.Before(breakStart) && !end.After(breakEnd) {
+			continue
+		}
+		segments = append(segments, shiftSegment{
+			Start:   start,
+			End:     end,
+			Night:   rules.isNightMinute(start),
+			Holiday: rules.isHolidayMinute(start),
+		})
+	}
+	return segments
+}
+
+// calculateMonthlyPayroll applies the shop's premium rules to every shift of the month.
+// Overtime is counted per work day (the day the shift starts) above the daily norm.
+// Paid absences are added according to the shop's absence code registry.
+func calculateMonthlyPayroll(shop Shop, shifts []ScheduledShift, absences []ScheduledAbsence, month string, year int) []PayrollBreakdown {
+	rules := shop.premiumRules()
+	monthNum := getMonthNumber(month)
+
+	minimumHourlyRate := 0.0
+	if norm := nominalWorkingHours(monthNum, year); norm > 0 {
+		minimumHourlyRate = rules.minimumMonthlyWage(time.Date(year, monthNum, 1, 0, 0, 0, 0, time.Local)) / norm
+	}
+
+	type minuteTotals struct {
+		worked, night, overtime50, overtime100, holiday float64
+		basePay, overtimePremium, holidayPremium        float64
+		absenceHours, absencePay                        float64
+		sickHours, sickPay                              float64
+	}
+	totals := make(map[string]*minuteTotals)
+	dailyMinutes := make(map[string]float64) // email|date -> minutes worked so far
+	normMinutes := rules.DailyNormHours * 60
+
+	for _, shift := range shifts {
+		employee, exists := shop.Employees[shift.EmployeeEmail]
+		if !exists {
+			continue
+		}
+		t := totals[shift.EmployeeEmail]
+		if t == nil {
+			t = &minuteTotals{}
+			totals[shift.EmployeeEmail] = t
+		}
+
+		rate := employeeRateOn(shop.ID, employee, shift.Date)
+		dayKey := shift.EmployeeEmail + "|" + shift.Date.Format("2006-01-02")
+
+		for _, segment := range rules.workedSegments(shift) {
+			minutes := segment.End.Sub(segment.Start).Minutes()
+			// The part of the segment past the day's norm is overtime
+			regular := math.Min(minutes, math.Max(0, normMinutes-dailyMinutes[dayKey]))
+			overtime := minutes - regular
+			dailyMinutes[dayKey] += minutes
+
+			t.worked += minutes
+			t.basePay += rate / 60 * minutes
+			if segment.Night {
+				t.night += minutes
+			}
+
+			if overtime > 0 {
+				if segment.Night || segment.Holiday {
+					t.overtime100 += overtime
+					t.overtimePremium += rate / 60 * overtime * rules.OvertimeNightHolidayPercent / 100
+				} else {
+					t.overtime50 += overtime
+					t.overtimePremium += rate / 60 * overtime * rules.OvertimePercent / 100
+				}
+			}
+			if segment.Holiday && regular > 0 {
+				t.holiday += regular
+				t.holidayPremium += rate / 60 * regular * rules.HolidayPremiumPercent / 100
+			}
+		}
+	}
+
+	for _, absence := range absences {
+		employee, exists := shop.Employees[absence.EmployeeEmail]
+		if !exists {
+			continue
+		}
+		t := totals[absence.EmployeeEmail]
+		if t == nil {
+			t = &minuteTotals{}
+			totals[absence.EmployeeEmail] = t
+		}
+		hours, pay := absencePay(shop, employee, absence)
+		t.absenceHours += hours
+		t.absencePay += pay
+		if isSickLeave(absence.Code) {
+			t.sickHours += hours
+			t.sickPay += pay
+		}
+	}
+
+	rates := payrollRatesFor(year)
+	result := make([]PayrollBreakdown, 0, len(shop.Employees))
+	for email, employee := range shop.Employees {
+		breakdown := PayrollBreakdown{
+			EmployeeEmail: email,
+			EmployeeName:  employee.Name,
+			ContractType:  employee.Contract.contractType(),
+			contract:      employee.Contract,
+		}
+		if t := totals[email]; t != nil {
+			breakdown.Hours = roundMoney(t.worked / 60)
+			breakdown.NightHours = roundMoney(t.night / 60)
+			breakdown.OvertimeHours50 = roundMoney(t.overtime50 / 60)
+			breakdown.OvertimeHours100 = roundMoney(t.overtime100 / 60)
+			breakdown.HolidayHours = roundMoney(t.holiday / 60)
+			breakdown.BasePay = roundMoney(t.basePay)
+			breakdown.NightPremium = roundMoney(t.night / 60 * minimumHourlyRate * rules.NightPremiumPercent / 100)
+			breakdown.OvertimePremium = roundMoney(t.overtimePremium)
+			breakdown.HolidayPremium = roundMoney(t.holidayPremium)
+			breakdown.AbsenceHours = roundMoney(t.absenceHours)
+			breakdown.AbsencePay = roundMoney(t.absencePay)
+			breakdown.SickHours = roundMoney(t.sickHours)
+			breakdown.SickPay = roundMoney(t.sickPay)
+		}
+		breakdown.Total = roundMoney(breakdown.BasePay + breakdown.NightPremium + breakdown.OvertimePremium + breakdown.HolidayPremium + breakdown.AbsencePay)
+		breakdown.Net = calculateNetPay(employee.Contract, breakdown.Total, breakdown.SickPay, rates)
+		result = append(result, breakdown)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].EmployeeName < result[j].EmployeeName
+	})
+	return result
+}
+
+// applyPayerNetPay recomputes the employee's side of one month's breakdowns paid by the same
+// employer. A person working in several of the employer's shops under the same contract is
+// taxed once on the combined gross, so the tax-reducing amount and the tax-deductible costs
+// are applied once; each shop's breakdown keeps its share of the result.
+func applyPayerNetPay(breakdowns []*PayrollBreakdown, year int) {
+	type payee struct {
+		email    string
+		contract EmploymentContract
+	}
+	groups := make(map[payee][]*PayrollBreakdown)
+	var order []payee
+	for _, breakdown := range breakdowns {
+		key := payee{normalizeEmail(breakdown.EmployeeEmail), breakdown.contract}
+		if _, exists := groups[key]; !exists {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], breakdown)
+	}
+
+	rates := payrollRatesFor(year)
+	for _, key := range order {
+		group := groups[key]
+		if len(group) == 1 {
+			continue
+		}
+		var gross, sickPay float64
+		grosses := make([]float64, len(group))
+		for i, breakdown := range group {
+			gross += breakdown.Total
+			sickPay += breakdown.SickPay
+			grosses[i] = breakdown.Total
+		}
+		parts := splitNetPay(calculateNetPay(key.contract, gross, sickPay, rates), grosses)
+		for i, breakdown := range group {
+			breakdown.Net = parts[i]
+		}
+	}
+}
+
+// ReadMonthSchedule reads the whole month sheet, however many employee columns it has
+func (s *SpreadsheetService) ReadMonthSchedule(ctx context.Context, spreadsheetID, month string) ([][]interface{}, error) {
+	return s.ReadSpreadsheetData(ctx, spreadsheetID, month)
+}
+
+func handlePayroll(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view payroll", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+	if err != nil {
+		log.Printf("Error reading schedule data for payroll: %v", err)
+		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
+		return
+	}
+
+	shifts := indexMonthSchedule(shop, month, year, data)
+	hours := "planned"
+	if r.URL.Query().Get("hours") == "actual" {
+		// Clocked times replace the planned ones where both punches exist
+		hours = "actual"
+		shifts = actualShifts(shifts, monthTimeEntries(shop.ID, month, year, ""))
+	}
+	response := PayrollResponse{
+		ShopID:    shop.ID,
+		ShopName:  shop.Name,
+		Year:      year,
+		Month:     month,
+		Rules:     shop.premiumRules(),
+		Hours:     hours,
+		Employees: calculateMonthlyPayroll(shop, shifts, parseMonthAbsences(data, shop, month, year), month, year),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func handlePremiumRules(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage premium rules", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shop.premiumRules())
+
+	case http.MethodPut:
+		// Decode over the configured rules, a body with only night_premium_percent keeps the rest
+		rules := shop.premiumRules()
+		if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := rules.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][shopID]
+		shop.PremiumRules = &rules
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][shopID] = shop
+		employerShopsMutex.Unlock()
+
+		go saveShopsData()
+
+		log.Printf("Updated premium rules for shop %s by employer %s", shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PayrollExportRow is one employee's month in one shop. Night and holiday hours are part of
+// the regular and overtime hours and only carry premiums; leave and sick hours are paid
+// absences on top of them.
+type PayrollExportRow struct {
+	ShopID              string  `json:"shop_id"`
+	ShopName            string  `json:"shop_name"`
+	EmployeeEmail       string  `json:"employee_email"`
+	EmployeeName        string  `json:"employee_name"`
+	ContractType        string  `json:"contract_type"`
+	RegularHours        float64 `json:"regular_hours"`
+	NightHours          float64 `json:"night_hours"`
+	OvertimeHours50     float64 `json:"overtime_hours_50"`
+	OvertimeHours100    float64 `json:"overtime_hours_100"`
+	HolidayHours        float64 `json:"holiday_hours"`
+	LeaveHours          float64 `json:"leave_hours"`
+	SickHours           float64 `json:"sick_hours"`
+	BasePay             float64 `json:"base_pay"`
+	NightPremium        float64 `json:"night_premium"`
+	OvertimePremium     float64 `json:"overtime_premium"`
+	HolidayPremium      float64 `json:"holiday_premium"`
+	LeavePay            float64 `json:"leave_pay"`
+	SickPay             float64 `json:"sick_pay"`
+	Gross               float64 `json:"gross"`
+	SocialContributions float64 `json:"social_contributions"`
+	HealthContribution  float64 `json:"health_contribution"`
+	PITAdvance          float64 `json:"pit_advance"`
+	Net                 float64 `json:"net"`
+}
+
+// PayrollExport is an employer's payroll of one month across all their shops
+type PayrollExport struct {
+	Employer    string             `json:"employer"`
+	Year        int                `json:"year"`
+	Month       string             `json:"month"`
+	Hours       string             `json:"hours"` // planned | actual
+	GeneratedAt time.Time          `json:"generated_at"`
+	Rows        []PayrollExportRow `json:"rows"`
+	Skipped     []string           `json:"skipped,omitempty"` // shops whose month could not be read
+}
+
+// PayrollFormatter writes an export in one file format. Register new formats, such as the
+// import format of an accounting program, in payrollFormatters.
+type PayrollFormatter interface {
+	ContentType() string
+	FileExtension() string
+	Format(w io.Writer, export PayrollExport) error
+}
+
+var payrollFormatters = map[string]PayrollFormatter{
+	"csv":  csvPayrollFormatter{},
+	"json": jsonPayrollFormatter{},
+}
+
+type jsonPayrollFormatter struct{}
+
+func (jsonPayrollFormatter) ContentType() string   { return "application/json" }
+func (jsonPayrollFormatter) FileExtension() string { return "json" }
+
+func (jsonPayrollFormatter) Format(w io.Writer, export PayrollExport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+// csvPayrollFormatter writes semicolon-separated values with decimal commas, the way a Polish
+// spreadsheet program opens them without an import wizard. A CSV file has no place to list the
+// shops that could not be read, so an incomplete export fails instead of looking complete.
+type csvPayrollFormatter struct{}
+
+func (csvPayrollFormatter) ContentType() string   { return "text/csv; charset=utf-8" }
+func (csvPayrollFormatter) FileExtension() string { return "csv" }
+
+func (csvPayrollFormatter) Format(w io.Writer, export PayrollExport) error {
+	if len(export.Skipped) > 0 {
+		return fmt.Errorf("payroll is incomplete, could not read %s", strings.Join(export.Skipped, "; "))
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	header := []string{
+		"shop_id", "shop_name", "employee_email", "employee_name", "contract_type",
+		"regular_hours", "night_hours", "overtime_hours_50", "overtime_hours_100", "holiday_hours", "leave_hours", "sick_hours",
+		"base_pay", "night_premium", "overtime_premium", "holiday_premium", "leave_pay", "sick_pay",
+		"gross", "social_contributions", "health_contribution", "pit_advance", "net",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	amount := func(value float64) string {
+		return strings.Replace(fmt.Sprintf("%.2f", value), ".", ",", 1)
+	}
+	for _, row := range export.Rows {
+		record := []string{csvText(row.ShopID), csvText(row.ShopName), csvText(row.EmployeeEmail), csvText(row.EmployeeName), row.ContractType}
+		for _, value := range []float64{
+			row.RegularHours, row.NightHours, row.OvertimeHours50, row.OvertimeHours100, row.HolidayHours, row.LeaveHours, row.SickHours,
+			row.BasePay, row.NightPremium, row.OvertimePremium, row.HolidayPremium, row.LeavePay, row.SickPay,
+			row.Gross, row.SocialContributions, row.HealthContribution, row.PITAdvance, row.Net,
+		} {
+			record = append(record, amount(value))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText keeps a spreadsheet program from running a name as a formula
+func csvText(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@") {
+		return "'" + value
+	}
+	return value
+}
+
+// payrollExportRow flattens a shop's payroll breakdown of one employee
+func payrollExportRow(shop Shop, breakdown PayrollBreakdown) PayrollExportRow {
+	return PayrollExportRow{
+		ShopID:              shop.ID,
+		ShopName:            shop.Name,
+		EmployeeEmail:       breakdown.EmployeeEmail,
+		EmployeeName:        breakdown.EmployeeName,
+		ContractType:        breakdown.ContractType,
+		RegularHours:        roundMoney(breakdown.Hours - breakdown.OvertimeHours50 - breakdown.OvertimeHours100),
+		NightHours:          breakdown.NightHours,
+		OvertimeHours50:     breakdown.OvertimeHours50,
+		OvertimeHours100:    breakdown.OvertimeHours100,
+		HolidayHours:        breakdown.HolidayHours,
+		LeaveHours:          roundMoney(breakdown.AbsenceHours - breakdown.SickHours),
+		SickHours:           breakdown.SickHours,
+		BasePay:             breakdown.BasePay,
+		NightPremium:        breakdown.NightPremium,
+		OvertimePremium:     breakdown.OvertimePremium,
+		HolidayPremium:      breakdown.HolidayPremium,
+		LeavePay:            roundMoney(breakdown.AbsencePay - breakdown.SickPay),
+		SickPay:             breakdown.SickPay,
+		Gross:               breakdown.Total,
+		SocialContributions: breakdown.Net.SocialContributions,
+		HealthContribution:  breakdown.Net.HealthContribution,
+		PITAdvance:          breakdown.Net.PITAdvance,
+		Net:                 breakdown.Net.Net,
+	}
+}
+
+// handlePayrollExport downloads the payroll of a month for every shop of the employer, in the
+// format chosen with ?format= (csv by default)
+func handlePayrollExport(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can export payroll", http.StatusForbidden)
+		return
+	}
+
+	month := r.URL.Query().Get("month")
+	if month == "" {
+		http.Error(w, "Month parameter is required", http.StatusBadRequest)
+		return
+	}
+	monthNum := getMonthNumber(month)
+	if monthNum == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	formatter, exists := payrollFormatters[format]
+	if !exists {
+		names := make([]string, 0, len(payrollFormatters))
+		for name := range payrollFormatters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		http.Error(w, fmt.Sprintf("Unknown format %q, use one of: %s", format, strings.Join(names, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	var shops []Shop
+	employerShopsMutex.RLock()
+	for _, shop := range employerShops[session.UserInfo.Email] {
+		shops = append(shops, shop)
+	}
+	employerShopsMutex.RUnlock()
+	sort.Slice(shops, func(i, j int) bool {
+		return shops[i].Name < shops[j].Name
+	})
+
+	export := PayrollExport{
+		Employer:    session.UserInfo.Email,
+		Year:        year,
+		Month:       month,
+		Hours:       "planned",
+		GeneratedAt: time.Now(),
+		Rows:        []PayrollExportRow{},
+	}
+	if r.URL.Query().Get("hours") == "actual" {
+		export.Hours = "actual"
+	}
+
+	type shopBreakdown struct {
+		shop      Shop
+		breakdown PayrollBreakdown
+	}
+	var payroll []shopBreakdown
+	for _, shop := range shops {
+		spreadsheetID, exists := shop.Spreadsheets[year]
+		if !exists {
+			// Nothing was scheduled in the shop that year, so there is nothing to pay
+			continue
+		}
+		data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+		if err != nil {
+			log.Printf("Error reading %s of shop %s for payroll export: %v", month, shop.ID, err)
+			export.Skipped = append(export.Skipped, fmt.Sprintf("%s: failed to read %s", shop.Name, month))
+			continue
+		}
+
+		shifts := indexMonthSchedule(shop, month, year, data)
+		if export.Hours == "actual" {
+			shifts = actualShifts(shifts, monthTimeEntries(shop.ID, month, year, ""))
+		}
+		for _, breakdown := range calculateMonthlyPayroll(shop, shifts, parseMonthAbsences(data, shop, month, year), month, year) {
+			// Employees without any hours or pay this month are left out
+			if breakdown.Hours == 0 && breakdown.AbsenceHours == 0 && breakdown.Total == 0 {
+				continue
+			}
+			payroll = append(payroll, shopBreakdown{shop, breakdown})
+		}
+	}
+
+	// A person working in several shops is taxed once on their pay from all of them
+	breakdowns := make([]*PayrollBreakdown, len(payroll))
+	for i := range payroll {
+		breakdowns[i] = &payroll[i].breakdown
+	}
+	applyPayerNetPay(breakdowns, year)
+	for _, item := range payroll {
+		export.Rows = append(export.Rows, payrollExportRow(item.shop, item.breakdown))
+	}
+
+	var body bytes.Buffer
+	if err := formatter.Format(&body, export); err != nil {
+		log.Printf("Error writing payroll export: %v", err)
+		http.Error(w, "Payroll export failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	log.Printf("Exported payroll of %s %d as %s for employer %s: %d rows", month, year, format, session.UserInfo.Email, len(export.Rows))
+	w.Header().Set("Content-Type", formatter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"wyplaty-%s.%s\"", monthKey(year, monthNum), formatter.FileExtension()))
+	w.Write(body.Bytes())
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCSVPayrollFormatterEscapesFormulas(t *testing.T) {
+	export := PayrollExport{Rows: []PayrollExportRow{
+		{ShopID: "-shop", ShopName: "=HYPERLINK(\"x\")", EmployeeEmail: "@anna@example.com", EmployeeName: "+48 Anna", ContractType: contractEmployment, Gross: 100},
+		{ShopID: "shop", ShopName: "Zalasewo", EmployeeEmail: "ewa@example.com", EmployeeName: "Ewa", ContractType: contractEmployment, Gross: -5},
+	}}
+
+	var out bytes.Buffer
+	if err := (csvPayrollFormatter{}).Format(&out, export); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want a header and 2 rows", len(lines))
+	}
+	if want := `'-shop;"'=HYPERLINK(""x"")";'@anna@example.com;'+48 Anna;umowa_o_prace;`; !strings.HasPrefix(lines[1], want) {
+		t.Errorf("row = %s, want it to start with %s", lines[1], want)
+	}
+	if want := "shop;Zalasewo;ewa@example.com;Ewa;"; !strings.HasPrefix(lines[2], want) {
+		t.Errorf("row = %s, want it to start with %s", lines[2], want)
+	}
+	if !strings.Contains(lines[2], ";-5,00;") {
+		t.Errorf("row = %s, amounts should not be escaped", lines[2])
+	}
+}
+
+func TestCSVPayrollFormatterFailsOnSkippedShops(t *testing.T) {
+	export := PayrollExport{Skipped: []string{"Zalasewo: failed to read Marzec"}}
+
+	var out bytes.Buffer
+	err := (csvPayrollFormatter{}).Format(&out, export)
+	if err == nil || !strings.Contains(err.Error(), "Zalasewo") {
+		t.Errorf("Format error = %v, want the skipped shop to be reported", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("an incomplete export should not write a file, got %q", out.String())
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testShift(email, day, start, end string, breakMinutes int) ScheduledShift {
+	date, _ := time.ParseInLocation(dateLayout, day, time.Local)
+	startMinutes, _ := parseClockMinutes(start)
+	endMinutes, _ := parseClockMinutes(end)
+	if endMinutes <= startMinutes {
+		endMinutes += 24 * 60
+	}
+	return ScheduledShift{
+		EmployeeEmail: email,
+		Date:          date,
+		Start:         date.Add(time.Duration(startMinutes) * time.Minute),
+		End:           date.Add(time.Duration(endMinutes) * time.Minute),
+		Value:         start + "-" + end,
+		BreakMinutes:  breakMinutes,
+	}
+}
+
+func testPayrollShop() Shop {
+	rules := defaultPremiumRules()
+	rules.MinimumMonthlyWage = 4666
+	return Shop{
+		ID:           "payroll-test-shop",
+		Name:         "Test",
+		Employees:    map[string]Employee{"anna@example.com": {Email: "anna@example.com", Name: "Anna", HourlyRate: 40}},
+		PremiumRules: &rules,
+	}
+}
+
+func TestCalculateMonthlyPayrollPremiums(t *testing.T) {
+	const email = "anna@example.com"
+	// March 2025 has 21 working days, so the minimum hourly rate for the night premium is 4666 / 168
+	nightRate := 4666.0 / 168 * 0.2
+
+	tests := []struct {
+		name   string
+		shifts []ScheduledShift
+		want   PayrollBreakdown
+	}{
+		{
+			name:   "weekday within the norm",
+			shifts: []ScheduledShift{testShift(email, "2025-03-03", "08:00", "16:00", 0)},
+			want:   PayrollBreakdown{Hours: 8, BasePay: 320},
+		},
+		{
+			name:   "weekday overtime",
+			shifts: []ScheduledShift{testShift(email, "2025-03-03", "08:00", "18:00", 0)},
+			want:   PayrollBreakdown{Hours: 10, OvertimeHours50: 2, BasePay: 400, OvertimePremium: 40},
+		},
+		{
+			name: "two shifts of one day share the norm",
+			shifts: []ScheduledShift{
+				testShift(email, "2025-03-03", "08:00", "12:00", 0),
+				testShift(email, "2025-03-03", "14:00", "20:00", 0),
+			},
+			want: PayrollBreakdown{Hours: 10, OvertimeHours50: 2, BasePay: 400, OvertimePremium: 40},
+		},
+		{
+			name:   "night shift past midnight",
+			shifts: []ScheduledShift{testShift(email, "2025-03-03", "22:00", "06:00", 0)},
+			want:   PayrollBreakdown{Hours: 8, NightHours: 8, BasePay: 320, NightPremium: roundMoney(8 * nightRate)},
+		},
+		{
+			name:   "overtime at night",
+			shifts: []ScheduledShift{testShift(email, "2025-03-04", "14:00", "00:00", 0)},
+			want:   PayrollBreakdown{Hours: 10, NightHours: 3, OvertimeHours100: 2, BasePay: 400, NightPremium: roundMoney(3 * nightRate), OvertimePremium: 80},
+		},
+		{
+			name:   "sunday",
+			shifts: []ScheduledShift{testShift(email, "2025-03-02", "10:00", "18:00", 0)},
+			want:   PayrollBreakdown{Hours: 8, HolidayHours: 8, BasePay: 320, HolidayPremium: 320},
+		},
+		{
+			name:   "sunday overtime",
+			shifts: []ScheduledShift{testShift(email, "2025-03-02", "10:00", "20:00", 0)},
+			want:   PayrollBreakdown{Hours: 10, HolidayHours: 8, OvertimeHours100: 2, BasePay: 400, HolidayPremium: 320, OvertimePremium: 80},
+		},
+		{
+			name:   "saturday night into sunday",
+			shifts: []ScheduledShift{testShift(email, "2025-03-08", "20:00", "04:00", 0)},
+			want:   PayrollBreakdown{Hours: 8, NightHours: 7, HolidayHours: 4, BasePay: 320, NightPremium: roundMoney(7 * nightRate), HolidayPremium: 160},
+		},
+		{
+			name:   "public holiday",
+			shifts: []ScheduledShift{testShift(email, "2025-04-21", "08:00", "12:00", 0)},
+			want:   PayrollBreakdown{Hours: 4, HolidayHours: 4, BasePay: 160, HolidayPremium: 160},
+		},
+		{
+			name:   "unpaid break does not count",
+			shifts: []ScheduledShift{testShift(email, "2025-03-03", "08:00", "16:30", 30)},
+			want:   PayrollBreakdown{Hours: 8, BasePay: 320},
+		},
+	}
+
+	shop := testPayrollShop()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			month := polishMonths[test.shifts[0].Date.Month()-1]
+			got := calculateMonthlyPayroll(shop, test.shifts, nil, month, 2025)[0]
+
+			checks := []struct {
+				field     string
+				got, want float64
+			}{
+				{"hours", got.Hours, test.want.Hours},
+				{"night hours", got.NightHours, test.want.NightHours},
+				{"overtime 50 hours", got.OvertimeHours50, test.want.OvertimeHours50},
+				{"overtime 100 hours", got.OvertimeHours100, test.want.OvertimeHours100},
+				{"holiday hours", got.HolidayHours, test.want.HolidayHours},
+				{"base pay", got.BasePay, test.want.BasePay},
+				{"night premium", got.NightPremium, test.want.NightPremium},
+				{"overtime premium", got.OvertimePremium, test.want.OvertimePremium},
+				{"holiday premium", got.HolidayPremium, test.want.HolidayPremium},
+			}
+			for _, check := range checks {
+				if math.Abs(check.got-check.want) > 0.005 {
+					t.Errorf("%s = %.2f, want %.2f", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPolishPublicHolidays(t *testing.T) {
+	for _, day := range []string{"2025-04-20", "2025-04-21", "2025-06-08", "2025-06-19", "2025-12-24", "2026-04-06"} {
+		date, _ := time.ParseInLocation(dateLayout, day, time.Local)
+		if !isPublicHoliday(date) {
+			t.Errorf("%s should be a public holiday", day)
+		}
+	}
+	for _, day := range []string{"2024-12-24", "2025-04-22", "2025-03-03"} {
+		date, _ := time.ParseInLocation(dateLayout, day, time.Local)
+		if isPublicHoliday(date) {
+			t.Errorf("%s should not be a public holiday", day)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const peopleDataFile = "people_data.json"
+
+// Person is the global record of an employee, shared by all their per-shop employments
+type Person struct {
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Employment describes one shop's employment record for a person
+type Employment struct {
+	ShopID     string             `json:"shop_id"`
+	ShopName   string             `json:"shop_name"`
+	HourlyRate float64            `json:"hourly_rate"`
+	Position   string             `json:"position,omitempty"`
+	StartDate  string             `json:"start_date,omitempty"`
+	EndDate    string             `json:"end_date,omitempty"`
+	Contract   EmploymentContract `json:"contract"`
+}
+
+type PersonWithEmployments struct {
+	Person
+	Employments []Employment `json:"employments"`
+}
+
+type PersonShopSummary struct {
+	Employment
+	Payroll PayrollBreakdown `json:"payroll"`
+}
+
+type PersonSummary struct {
+	Person        Person              `json:"person"`
+	Year          int                 `json:"year"`
+	Months        []string            `json:"months"`
+	Shops         []PersonShopSummary `json:"shops"`
+	TotalHours    float64             `json:"total_hours"`
+	TotalEarnings float64             `json:"total_earnings"`
+	Skipped       []string            `json:"skipped,omitempty"` // shop months that could not be read, so the totals are incomplete
+}
+
+var (
+	people      = make(map[string]Person) // normalized email -> person
+	peopleMutex sync.RWMutex
+)
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func savePeopleData() error {
+	peopleMutex.RLock()
+	defer peopleMutex.RUnlock()
+	return writeJSONFileAtomic(peopleDataFile, people)
+}
+
+func loadPeopleData() error {
+	peopleMutex.Lock()
+	defer peopleMutex.Unlock()
+	return readJSONFile(peopleDataFile, &people)
+}
+
+// upsertPerson creates the global record for an email or refreshes its name
+func upsertPerson(email, name string) Person {
+	peopleMutex.Lock()
+	defer peopleMutex.Unlock()
+
+	key := normalizeEmail(email)
+	now := time.Now()
+	person, exists := people[key]
+	if !exists {
+		person = Person{Email: key, CreatedAt: now}
+	}
+	if name = strings.TrimSpace(name); name != "" && name != person.Name {
+		person.Name = name
+		person.UpdatedAt = now
+	}
+	if !exists {
+		person.UpdatedAt = now
+	}
+	people[key] = person
+	return person
+}
+
+func getPerson(email string) (Person, bool) {
+	peopleMutex.RLock()
+	defer peopleMutex.RUnlock()
+	person, exists := people[normalizeEmail(email)]
+	return person, exists
+}
+
+// syncPeopleFromShops makes sure every employee of every shop has a global record
+func syncPeopleFromShops() {
+	employerShopsMutex.RLock()
+	var employees []Employee
+	for _, shops := range employerShops {
+		for _, shop := range shops {
+			for _, employee := range shop.Employees {
+				employees = append(employees, employee)
+			}
+		}
+	}
+	employerShopsMutex.RUnlock()
+
+	added := 0
+	for _, employee := range employees {
+		if _, exists := getPerson(employee.Email); !exists {
+			upsertPerson(employee.Email, employee.Name)
+			added++
+		}
+	}
+
+	if added > 0 {
+		log.Printf("Created %d person records from existing shop employees", added)
+		if err := savePeopleData(); err != nil {
+			log.Printf("Error saving people data: %v", err)
+		}
+	}
+}
+
+func employmentFor(shop Shop, employee Employee) Employment {
+	return Employment{
+		ShopID:     shop.ID,
+		ShopName:   shop.Name,
+		HourlyRate: employeeRateOn(shop.ID, employee, time.Now()),
+		Position:   employee.Position,
+		StartDate:  employee.StartDate,
+		EndDate:    employee.EndDate,
+		Contract:   employee.Contract,
+	}
+}
+
+// employerEmploymentsFor returns the employer's shops where the person is employed
+func employerEmploymentsFor(employerEmail, personEmail string) []Shop {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	key := normalizeEmail(personEmail)
+	var shops []Shop
+	for _, shop := range employerShops[employerEmail] {
+		for email := range shop.Employees {
+			if normalizeEmail(email) == key {
+				shops = append(shops, shop)
+				break
+			}
+		}
+	}
+	sort.Slice(shops, func(i, j int) bool { return shops[i].Name < shops[j].Name })
+	return shops
+}
+
+// OwnedShop pairs a shop with the employer that owns it
+type OwnedShop struct {
+	Owner string
+	Shop  Shop
+}
+
+// personShops returns every shop the person works in, across all employers
+func personShops(personEmail string) []OwnedShop {
+	employerShopsMutex.RLock()
+	defer employerShopsMutex.RUnlock()
+
+	var result []OwnedShop
+	for employer, shops := range employerShops {
+		for _, shop := range shops {
+			if _, _, exists := shopEmployee(shop, personEmail); exists {
+				result = append(result, OwnedShop{Owner: employer, Shop: shop})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Shop.Name < result[j].Shop.Name })
+	return result
+}
+
+// shopEmployee looks an employee up by email regardless of letter case
+func shopEmployee(shop Shop, email string) (string, Employee, bool) {
+	if employee, exists := shop.Employees[email]; exists {
+		return email, employee, true
+	}
+	key := normalizeEmail(email)
+	for shopEmail, employee := range shop.Employees {
+		if normalizeEmail(shopEmail) == key {
+			return shopEmail, employee, true
+		}
+	}
+	return "", Employee{}, false
+}
+
+func (b *PayrollBreakdown) add(other PayrollBreakdown) {
+	b.Hours = roundMoney(b.Hours + other.Hours)
+	b.NightHours = roundMoney(b.NightHours + other.NightHours)
+	b.OvertimeHours50 = roundMoney(b.OvertimeHours50 + other.OvertimeHours50)
+	b.OvertimeHours100 = roundMoney(b.OvertimeHours100 + other.OvertimeHours100)
+	b.HolidayHours = roundMoney(b.HolidayHours + other.HolidayHours)
+	b.BasePay = roundMoney(b.BasePay + other.BasePay)
+	b.NightPremium = roundMoney(b.NightPremium + other.NightPremium)
+	b.OvertimePremium = roundMoney(b.OvertimePremium + other.OvertimePremium)
+	b.HolidayPremium = roundMoney(b.HolidayPremium + other.HolidayPremium)
+	b.AbsenceHours = roundMoney(b.AbsenceHours + other.AbsenceHours)
+	b.AbsencePay = roundMoney(b.AbsencePay + other.AbsencePay)
+	b.SickHours = roundMoney(b.SickHours + other.SickHours)
+	b.SickPay = roundMoney(b.SickPay + other.SickPay)
+	b.Total = roundMoney(b.Total + other.Total)
+	b.Net.add(other.Net)
+}
+
+func handlePeople(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view people", http.StatusForbidden)
+		return
+	}
+
+	employerShopsMutex.RLock()
+	byPerson := make(map[string][]Employment)
+	for _, shop := range employerShops[session.UserInfo.Email] {
+		for _, employee := range shop.Employees {
+			key := normalizeEmail(employee.Email)
+			byPerson[key] = append(byPerson[key], employmentFor(shop, employee))
+		}
+	}
+	employerShopsMutex.RUnlock()
+
+	result := make([]PersonWithEmployments, 0, len(byPerson))
+	for email, employments := range byPerson {
+		person, exists := getPerson(email)
+		if !exists {
+			person = Person{Email: email}
+		}
+		sort.Slice(employments, func(i, j int) bool { return employments[i].ShopName < employments[j].ShopName })
+		result = append(result, PersonWithEmployments{Person: person, Employments: employments})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"people": result})
+}
+
+// handlePersonSummary combines one person's hours and earnings across all of the employer's shops
+func handlePersonSummary(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view person summaries", http.StatusForbidden)
+		return
+	}
+
+	email := r.URL.Query().Get("email")
+	if email == "" {
+		http.Error(w, "Email parameter is required", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	months := polishMonths
+	if month := r.URL.Query().Get("month"); month != "" {
+		if getMonthNumber(month) == 0 {
+			http.Error(w, "Unknown month", http.StatusBadRequest)
+			return
+		}
+		months = []string{month}
+	}
+
+	shops := employerEmploymentsFor(session.UserInfo.Email, email)
+	if len(shops) == 0 {
+		http.Error(w, "Person not found in your shops", http.StatusNotFound)
+		return
+	}
+
+	person, exists := getPerson(email)
+	if !exists {
+		person = Person{Email: normalizeEmail(email)}
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	summary := PersonSummary{
+		Person: person,
+		Year:   year,
+		Months: months,
+		Shops:  make([]PersonShopSummary, 0, len(shops)),
+	}
+
+	shopEmails := make([]string, len(shops))
+	for i, shop := range shops {
+		shopEmail, employee, _ := shopEmployee(shop, email)
+		shopEmails[i] = shopEmail
+		summary.Shops = append(summary.Shops, PersonShopSummary{
+			Employment: employmentFor(shop, employee),
+			Payroll:    PayrollBreakdown{EmployeeEmail: shopEmail, EmployeeName: employee.Name},
+		})
+	}
+
+	// Each month is taxed once on the pay of all shops together, then added to the shops
+	for _, month := range months {
+		monthPayroll := make([]PayrollBreakdown, 0, len(shops))
+		monthShops := make([]int, 0, len(shops))
+		for i, shop := range shops {
+			spreadsheetID, exists := shop.Spreadsheets[year]
+			if !exists {
+				continue
+			}
+			data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+			if err != nil {
+				log.Printf("Error reading %s for shop %s: %v", month, shop.ID, err)
+				summary.Skipped = append(summary.Skipped, fmt.Sprintf("%s: failed to read %s", shop.Name, month))
+				continue
+			}
+			shifts := parseMonthSchedule(data, shop, month, year)
+			absences := parseMonthAbsences(data, shop, month, year)
+			for _, breakdown := range calculateMonthlyPayroll(shop, shifts, absences, month, year) {
+				if breakdown.EmployeeEmail == shopEmails[i] {
+					monthPayroll = append(monthPayroll, breakdown)
+					monthShops = append(monthShops, i)
+				}
+			}
+		}
+
+		breakdowns := make([]*PayrollBreakdown, len(monthPayroll))
+		for i := range monthPayroll {
+			breakdowns[i] = &monthPayroll[i]
+		}
+		applyPayerNetPay(breakdowns, year)
+		for i, breakdown := range monthPayroll {
+			summary.Shops[monthShops[i]].Payroll.add(breakdown)
+		}
+	}
+
+	for _, shopSummary := range summary.Shops {
+		summary.TotalHours = roundMoney(summary.TotalHours + shopSummary.Payroll.Hours)
+		summary.TotalEarnings = roundMoney(summary.TotalEarnings + shopSummary.Payroll.Total)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateHistoryFile = "rate_history_data.json"
+	dateLayout      = "2006-01-02"
+)
+
+// RateEntry is one hourly rate valid from EffectiveFrom until the next entry.
+// An empty EffectiveFrom marks the initial rate, valid for all earlier dates.
+type RateEntry struct {
+	Rate          float64   `json:"rate"`
+	EffectiveFrom string    `json:"effective_from"`
+	Note          string    `json:"note,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type RateEntryRequest struct {
+	ShopID        string  `json:"shop_id"`
+	EmployeeEmail string  `json:"employee_email"`
+	Rate          float64 `json:"rate"`
+	EffectiveFrom string  `json:"effective_from"`
+	Note          string  `json:"note"`
+}
+
+var (
+	rateHistory      = make(map[string]map[string][]RateEntry) // shop_id -> employee_email -> entries
+	rateHistoryMutex sync.RWMutex
+)
+
+func saveRateHistoryData() error {
+	rateHistoryMutex.RLock()
+	defer rateHistoryMutex.RUnlock()
+	return writeJSONFileAtomic(rateHistoryFile, rateHistory)
+}
+
+func loadRateHistoryData() error {
+	rateHistoryMutex.Lock()
+	defer rateHistoryMutex.Unlock()
+	return readJSONFile(rateHistoryFile, &rateHistory)
+}
+
+// getRateHistory returns a copy of the entries sorted by effective date
+func getRateHistory(shopID, employeeEmail string) []RateEntry {
+	rateHistoryMutex.RLock()
+	defer rateHistoryMutex.RUnlock()
+
+	entries := append([]RateEntry(nil), rateHistory[shopID][employeeEmail]...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EffectiveFrom < entries[j].EffectiveFrom
+	})
+	return entries
+}
+
+func rateFromHistory(entries []RateEntry, fallback float64, date time.Time) float64 {
+	rate := fallback
+	day := date.Format(dateLayout)
+	for _, entry := range entries {
+		if entry.EffectiveFrom > day {
+			break
+		}
+		rate = entry.Rate
+	}
+	return rate
+}
+
+// employeeRateOn returns the hourly rate valid for the employee at the shop on the given date
+func employeeRateOn(shopID string, employee Employee, date time.Time) float64 {
+	return rateFromHistory(getRateHistory(shopID, employee.Email), employee.HourlyRate, date)
+}
+
+// addRateEntry records a new rate. The first entry for an employee also stores their
+// previous rate as the initial one, so earlier months keep being priced with it.
+func addRateEntry(shopID, employeeEmail string, previousRate float64, entry RateEntry) []RateEntry {
+	rateHistoryMutex.Lock()
+	defer rateHistoryMutex.Unlock()
+
+	if rateHistory[shopID] == nil {
+		rateHistory[shopID] = make(map[string][]RateEntry)
+	}
+
+	entries := rateHistory[shopID][employeeEmail]
+	if len(entries) == 0 && previousRate > 0 && entry.EffectiveFrom != "" {
+		entries = append(entries, RateEntry{
+			Rate:      previousRate,
+			Note:      "Stawka początkowa",
+			CreatedBy: entry.CreatedBy,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	replaced := false
+	for i := range entries {
+		if entries[i].EffectiveFrom == entry.EffectiveFrom {
+			entries[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].EffectiveFrom < entries[j].EffectiveFrom
+	})
+	rateHistory[shopID][employeeEmail] = entries
+
+	return append([]RateEntry(nil), entries...)
+}
+
+// recordRateChange keeps the history in step with rates set through the employee form
+func recordRateChange(shopID, employeeEmail string, previousRate, newRate float64, changedBy string) {
+	entries := getRateHistory(shopID, employeeEmail)
+	now := time.Now()
+
+	switch {
+	case len(entries) == 0 && (previousRate <= 0 || previousRate == newRate):
+		addRateEntry(shopID, employeeEmail, 0, RateEntry{
+			Rate:      newRate,
+			Note:      "Stawka początkowa",
+			CreatedBy: changedBy,
+			CreatedAt: now,
+		})
+	case rateFromHistory(entries, previousRate, now) != newRate:
+		addRateEntry(shopID, employeeEmail, previousRate, RateEntry{
+			Rate:          newRate,
+			EffectiveFrom: now.Format(dateLayout),
+			Note:          "Zmiana stawki",
+			CreatedBy:     changedBy,
+			CreatedAt:     now,
+		})
+	default:
+		return
+	}
+
+	go saveRateHistoryData()
+}
+
+// applyScheduleTotals recalculates the SUMA GODZIN and WYPŁATA rows using the rate valid on each shift's date.
+// Paid absence codes count towards both rows.
+func applyScheduleTotals(data [][]interface{}, shop Shop, month string, year int) [][]interface{} {
+	if len(data) == 0 {
+		return data
+	}
+
+	hoursRow, wagesRow := -1, -1
+	for i, row := range data {
+		switch cellString(row, 0) {
+		case "SUMA GODZIN":
+			hoursRow = i
+		case "WYPŁATA":
+			wagesRow = i
+		}
+	}
+	if hoursRow == -1 || wagesRow == -1 {
+		return data
+	}
+
+	hours := make(map[string]float64)
+	wages := make(map[string]float64)
+	for _, shift := range parseMonthSchedule(data, shop, month, year) {
+		employee := shop.Employees[shift.EmployeeEmail]
+		hours[shift.EmployeeEmail] += shift.Hours()
+		wages[shift.EmployeeEmail] += shift.Hours() * employeeRateOn(shop.ID, employee, shift.Date)
+	}
+	for _, absence := range parseMonthAbsences(data, shop, month, year) {
+		paidHours, pay := absencePay(shop, shop.Employees[absence.EmployeeEmail], absence)
+		hours[absence.EmployeeEmail] += paidHours
+		wages[absence.EmployeeEmail] += pay
+	}
+
+	formatAmount := func(value float64) string {
+		return strings.Replace(fmt.Sprintf("%.2f", value), ".", ",", 1)
+	}
+
+	for column, email := range scheduleColumns(data[0], shop.Employees) {
+		for _, rowIndex := range []int{hoursRow, wagesRow} {
+			for len(data[rowIndex]) <= column {
+				data[rowIndex] = append(data[rowIndex], "")
+			}
+		}
+		data[hoursRow][column] = formatAmount(hours[email])
+		data[wagesRow][column] = formatAmount(roundMoney(wages[email]))
+	}
+	return data
+}
+
+// managementRateRows lists every rate period that overlaps the given year
+func managementRateRows(shop Shop, year int) [][]interface{} {
+	yearStart := fmt.Sprintf("%d-01-01", year)
+	yearEnd := fmt.Sprintf("%d-12-31", year)
+
+	emails := make([]string, 0, len(shop.Employees))
+	for email := range shop.Employees {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+
+	var rows [][]interface{}
+	for _, email := range emails {
+		employee := shop.Employees[email]
+		entries := getRateHistory(shop.ID, email)
+		if len(entries) == 0 {
+			rows = append(rows, []interface{}{employee.Email, employee.Name, employee.HourlyRate, "", ""})
+			continue
+		}
+
+		for i, entry := range entries {
+			if entry.EffectiveFrom > yearEnd {
+				break
+			}
+			// Skip periods that ended before this year started
+			if i+1 < len(entries) && entries[i+1].EffectiveFrom <= yearStart {
+				continue
+			}
+			rows = append(rows, []interface{}{employee.Email, employee.Name, entry.Rate, entry.EffectiveFrom, entry.Note})
+		}
+	}
+	return rows
+}
+
+func handleRateHistory(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage hourly rates", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shopID := r.URL.Query().Get("shop_id")
+		employeeEmail := r.URL.Query().Get("employee_email")
+		if shopID == "" || employeeEmail == "" {
+			http.Error(w, "Shop ID and employee email are required", http.StatusBadRequest)
+			return
+		}
+
+		shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		employee, exists := shop.Employees[employeeEmail]
+		if !exists {
+			http.Error(w, "Employee not found", http.StatusNotFound)
+			return
+		}
+
+		entries := getRateHistory(shopID, employeeEmail)
+		if entries == nil {
+			entries = []RateEntry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"employee_email": employeeEmail,
+			"current_rate":   employeeRateOn(shopID, employee, time.Now()),
+			"rates":          entries,
+		})
+
+	case http.MethodPost:
+		var req RateEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.ShopID == "" || req.EmployeeEmail == "" || req.EffectiveFrom == "" {
+			http.Error(w, "Shop ID, employee email and effective date are required", http.StatusBadRequest)
+			return
+		}
+		if req.Rate <= 0 {
+			http.Error(w, "Rate must be greater than zero", http.StatusBadRequest)
+			return
+		}
+		if _, err := time.Parse(dateLayout, req.EffectiveFrom); err != nil {
+			http.Error(w, "Invalid effective date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		employee, exists := shop.Employees[req.EmployeeEmail]
+		if !exists {
+			http.Error(w, "Employee not found", http.StatusNotFound)
+			return
+		}
+		effectiveFrom, _ := time.ParseInLocation(dateLayout, req.EffectiveFrom, time.Local)
+		warning, err := checkMinimumRate(employee.Contract, req.Rate, effectiveFrom)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entries := addRateEntry(req.ShopID, req.EmployeeEmail, employee.HourlyRate, RateEntry{
+			Rate:          req.Rate,
+			EffectiveFrom: req.EffectiveFrom,
+			Note:          strings.TrimSpace(req.Note),
+			CreatedBy:     session.UserInfo.Email,
+			CreatedAt:     time.Now(),
+		})
+
+		// Keep the employee's current rate in line with the history
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][req.ShopID]
+		employee = shop.Employees[req.EmployeeEmail]
+		employee.HourlyRate = rateFromHistory(entries, employee.HourlyRate, time.Now())
+		shop.Employees[req.EmployeeEmail] = employee
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][req.ShopID] = shop
+		employerShopsMutex.Unlock()
+
+		go func() {
+			saveRateHistoryData()
+			saveShopsData()
+		}()
+
+		// Refresh the rate listing in every yearly spreadsheet
+		if len(shop.Spreadsheets) > 0 {
+			spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+			if err == nil {
+				for year, spreadsheetID := range shop.Spreadsheets {
+					if err := spreadsheetService.initializeManagementSheet(r.Context(), spreadsheetID, session.UserInfo.Email, req.ShopID, year); err != nil {
+						log.Printf("Error updating management sheet for year %d: %v", year, err)
+					}
+				}
+			}
+		}
+
+		log.Printf("Added rate %.2f from %s for employee %s in shop %s", req.Rate, req.EffectiveFrom, req.EmployeeEmail, req.ShopID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Rate added successfully",
+			"rates":   entries,
+			"warning": warning,
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
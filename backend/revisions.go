@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+var (
+	scheduleMonthLocks      = make(map[string]*sync.Mutex) // shop_id|YYYY-MM -> lock
+	scheduleMonthLocksMutex sync.Mutex
+)
+
+// lockScheduleMonth serializes updates of one shop month, so a revision check and the write
+// that follows it cannot interleave with another update. It returns the unlock function.
+func lockScheduleMonth(shopID, month string, year int) func() {
+	key := shopID + "|" + monthKey(year, getMonthNumber(month))
+
+	scheduleMonthLocksMutex.Lock()
+	lock, exists := scheduleMonthLocks[key]
+	if !exists {
+		lock = &sync.Mutex{}
+		scheduleMonthLocks[key] = lock
+	}
+	scheduleMonthLocksMutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// scheduleRevision is a token identifying the contents of a month grid. Trailing empty cells
+// and rows are ignored, because the Sheets API leaves them out of what it returns.
+func scheduleRevision(data [][]interface{}) string {
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		cells := make([]string, 0, len(row))
+		for index := range row {
+			cells = append(cells, cellString(row, index))
+		}
+		for len(cells) > 0 && cells[len(cells)-1] == "" {
+			cells = cells[:len(cells)-1]
+		}
+		rows = append(rows, cells)
+	}
+	for len(rows) > 0 && len(rows[len(rows)-1]) == 0 {
+		rows = rows[:len(rows)-1]
+	}
+
+	encoded, _ := json.Marshal(rows)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:8])
+}
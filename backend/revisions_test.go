@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestScheduleRevisionIgnoresTrailingEmptyCells(t *testing.T) {
+	base := [][]interface{}{{"", "Anna"}, {"Sobota 1", "08:00-16:00"}}
+	tests := []struct {
+		name  string
+		grid  [][]interface{}
+		equal bool
+	}{
+		{"trailing empty cells", [][]interface{}{{"", "Anna", ""}, {"Sobota 1", "08:00-16:00", "", ""}}, true},
+		{"trailing empty rows", [][]interface{}{{"", "Anna"}, {"Sobota 1", "08:00-16:00"}, {}, {"", ""}}, true},
+		{"nil cells", [][]interface{}{{"", "Anna", nil}, {"Sobota 1", "08:00-16:00"}}, true},
+		{"a changed cell", [][]interface{}{{"", "Anna"}, {"Sobota 1", "08:00-15:00"}}, false},
+		{"an empty row in between", [][]interface{}{{"", "Anna"}, {}, {"Sobota 1", "08:00-16:00"}}, false},
+		{"a leading empty cell", [][]interface{}{{"", "Anna"}, {"", "Sobota 1", "08:00-16:00"}}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if equal := scheduleRevision(test.grid) == scheduleRevision(base); equal != test.equal {
+				t.Errorf("same revision = %v, want %v", equal, test.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxRotationWeeks   = 8
+	maxRotationDaySpan = 366
+)
+
+// RotationPattern is a recurring schedule of Weeks weeks. Cells holds one value per day of
+// the cycle for each employee, starting on a Monday; empty values leave the cell untouched.
+type RotationPattern struct {
+	ID        string              `json:"id"`
+	Name      string              `json:"name"`
+	Weeks     int                 `json:"weeks"`
+	Cells     map[string][]string `json:"cells"` // employee email -> value per cycle day
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// normalize validates the pattern against the shop and rewrites employee emails to the keys
+// the shop uses
+func (pattern *RotationPattern) normalize(shop Shop) error {
+	pattern.Name = strings.TrimSpace(pattern.Name)
+	if pattern.Name == "" {
+		return fmt.Errorf("rotation name is required")
+	}
+	if pattern.Weeks < 1 || pattern.Weeks > maxRotationWeeks {
+		return fmt.Errorf("weeks must be between 1 and %d", maxRotationWeeks)
+	}
+	if len(pattern.Cells) == 0 {
+		return fmt.Errorf("rotation needs at least one employee")
+	}
+
+	cells := make(map[string][]string, len(pattern.Cells))
+	for email, values := range pattern.Cells {
+		shopEmail, employee, exists := shopEmployee(shop, email)
+		if !exists {
+			return fmt.Errorf("%s does not work in this shop", email)
+		}
+		if len(values) != pattern.Weeks*7 {
+			return fmt.Errorf("%s needs %d values, one per day of the cycle", employee.Name, pattern.Weeks*7)
+		}
+		for index, value := range values {
+			value = strings.TrimSpace(value)
+			values[index] = value
+			if value == "" || shop.hasShiftTemplate(value) {
+				continue
+			}
+			if _, _, isShift := parseShiftTimes(value); isShift {
+				continue
+			}
+			if _, known := shop.absenceCode(value); known {
+				continue
+			}
+			return fmt.Errorf("%s day %d: %q is neither a shift, a shift template nor a known absence code", employee.Name, index+1, value)
+		}
+		cells[shopEmail] = values
+	}
+	pattern.Cells = cells
+	return nil
+}
+
+// valueOn returns the pattern's value for an employee on a day, counting cycles from anchor
+func (pattern RotationPattern) valueOn(email string, anchor, day time.Time) string {
+	values := pattern.Cells[email]
+	if len(values) == 0 {
+		return ""
+	}
+	days := int(math.Round(day.Sub(anchor).Hours()/24)) % len(values)
+	if days < 0 {
+		days += len(values)
+	}
+	return values[days]
+}
+
+func (shop Shop) rotation(id string) (RotationPattern, bool) {
+	for _, pattern := range shop.Rotations {
+		if pattern.ID == id {
+			return pattern, true
+		}
+	}
+	return RotationPattern{}, false
+}
+
+// putShopRotation adds a pattern, or replaces the one with its ID, on the shop as currently
+// stored, so two employers saving rotations at once do not drop each other's changes
+func putShopRotation(employerEmail, shopID string, pattern RotationPattern) (RotationPattern, bool) {
+	employerShopsMutex.Lock()
+	shop := employerShops[employerEmail][shopID]
+	now := time.Now()
+	rotations := make([]RotationPattern, 0, len(shop.Rotations)+1)
+	replaced := false
+	for _, existing := range shop.Rotations {
+		if pattern.ID != "" && existing.ID == pattern.ID {
+			pattern.CreatedAt = existing.CreatedAt
+			pattern.UpdatedAt = now
+			rotations = append(rotations, pattern)
+			replaced = true
+			continue
+		}
+		rotations = append(rotations, existing)
+	}
+	if !replaced {
+		if pattern.ID != "" {
+			employerShopsMutex.Unlock()
+			return pattern, false
+		}
+		pattern.ID = generateRandomString(12)
+		pattern.CreatedAt = now
+		pattern.UpdatedAt = now
+		rotations = append(rotations, pattern)
+	}
+	shop.Rotations = rotations
+	shop.UpdatedAt = now
+	employerShops[employerEmail][shopID] = shop
+	employerShopsMutex.Unlock()
+
+	go saveShopsData()
+	return pattern, true
+}
+
+// deleteShopRotation removes a pattern from the shop as currently stored
+func deleteShopRotation(employerEmail, shopID, id string) bool {
+	employerShopsMutex.Lock()
+	shop := employerShops[employerEmail][shopID]
+	rotations := make([]RotationPattern, 0, len(shop.Rotations))
+	for _, existing := range shop.Rotations {
+		if existing.ID != id {
+			rotations = append(rotations, existing)
+		}
+	}
+	if len(rotations) == len(shop.Rotations) {
+		employerShopsMutex.Unlock()
+		return false
+	}
+	shop.Rotations = rotations
+	shop.UpdatedAt = time.Now()
+	employerShops[employerEmail][shopID] = shop
+	employerShopsMutex.Unlock()
+
+	go saveShopsData()
+	return true
+}
+
+func handleRotations(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage rotations", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rotations := shop.Rotations
+		if rotations == nil {
+			rotations = []RotationPattern{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"rotations": rotations})
+
+	case http.MethodPut:
+		var pattern RotationPattern
+		if err := json.NewDecoder(r.Body).Decode(&pattern); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := pattern.normalize(shop); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		pattern, stored := putShopRotation(session.UserInfo.Email, shopID, pattern)
+		if !stored {
+			http.Error(w, "Rotation not found", http.StatusNotFound)
+			return
+		}
+
+		log.Printf("Saved rotation %s for shop %s by employer %s", pattern.ID, shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pattern)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if !deleteShopRotation(session.UserInfo.Email, shopID, id) {
+			http.Error(w, "Rotation not found", http.StatusNotFound)
+			return
+		}
+
+		log.Printf("Deleted rotation %s for shop %s by employer %s", id, shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Rotation deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleApplyRotation writes a rotation pattern into the drafts for a date range. The first
+// cycle starts on the Monday of the start date's week unless another Monday is given.
+func handleApplyRotation(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can apply rotations", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID     string `json:"shop_id"`
+		RotationID string `json:"rotation_id"`
+		StartDate  string `json:"start_date"`
+		EndDate    string `json:"end_date"`
+		CycleStart string `json:"cycle_start,omitempty"` // Monday on which week 1 of the cycle begins
+		Overwrite  bool   `json:"overwrite"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	start, startErr := time.ParseInLocation(dateLayout, req.StartDate, time.Local)
+	end, endErr := time.ParseInLocation(dateLayout, req.EndDate, time.Local)
+	if startErr != nil || endErr != nil {
+		http.Error(w, "Start and end dates must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if end.Before(start) {
+		http.Error(w, "End date must not be before start date", http.StatusBadRequest)
+		return
+	}
+	if end.Sub(start).Hours()/24 >= maxRotationDaySpan {
+		http.Error(w, fmt.Sprintf("A rotation can be applied to at most %d days at once", maxRotationDaySpan), http.StatusBadRequest)
+		return
+	}
+
+	anchor := weekStart(start)
+	if req.CycleStart != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.CycleStart, time.Local)
+		if err != nil || parsed.Weekday() != time.Monday {
+			http.Error(w, "cycle_start must be a Monday (YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		anchor = parsed
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	pattern, exists := shop.rotation(req.RotationID)
+	if !exists {
+		http.Error(w, "Rotation not found", http.StatusNotFound)
+		return
+	}
+
+	var cells []plannedCell
+	var skipped []string
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		for email := range pattern.Cells {
+			employee, exists := shop.Employees[email]
+			if !exists {
+				continue
+			}
+			value := pattern.valueOn(email, anchor, day)
+			if value == "" {
+				continue
+			}
+			if !employee.employedOn(day) {
+				skipped = append(skipped, fmt.Sprintf("%s %s: not employed on that day", day.Format(dateLayout), employee.Name))
+				continue
+			}
+			cells = append(cells, plannedCell{EmployeeEmail: email, Date: day, Value: value})
+		}
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		log.Printf("Error getting spreadsheet service: %v", err)
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := draftPlannedCells(r.Context(), spreadsheetService, shop, cells, req.Overwrite, session.UserInfo.Email)
+	result.Skipped = append(skipped, result.Skipped...)
+	if respondMonthLocked(w, err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error applying rotation %s in shop %s: %v", pattern.ID, shop.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to apply rotation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	message := fmt.Sprintf("Applied rotation %s to the drafts from %s to %s", pattern.Name, req.StartDate, req.EndDate)
+	if len(result.Changes) > 0 {
+		recordAudit(AuditEntry{
+			ShopID:  shop.ID,
+			Action:  "rotation_applied",
+			Actor:   session.UserInfo.Email,
+			Message: message,
+			RefID:   pattern.ID,
+			Changes: result.Changes,
+		})
+	}
+
+	log.Printf("%s in shop %s: %d cells changed, %d skipped", message, shop.ID, len(result.Changes), len(result.Skipped))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduleWriteResponse(session.UserInfo.Email, shop, message, result))
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UnfilledSlot is coverage the generator could not staff without breaking a hard constraint
+type UnfilledSlot struct {
+	Date     string `json:"date"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Position string `json:"position,omitempty"`
+	Missing  int    `json:"missing"`
+}
+
+// employedOn reports whether the date falls within the employment period
+func (employee Employee) employedOn(date time.Time) bool {
+	day := date.Format(dateLayout)
+	if employee.StartDate != "" && day < employee.StartDate {
+		return false
+	}
+	return employee.EndDate == "" || day <= employee.EndDate
+}
+
+// generateSchedule fills the month grid from the shop's coverage requirements. Compliance
+// limits, availability marked as unavailable, absences and shifts in other shops are hard
+// constraints; among the remaining candidates it prefers people below their contracted hours,
+// then people who marked the slot as preferred, then whoever has the fewest hours so far.
+func generateSchedule(shop Shop, month string, year int, data [][]interface{}) ([][]interface{}, []UnfilledSlot) {
+	monthNum := getMonthNumber(month)
+	columns := scheduleColumns(data[0], shop.Employees)
+	columnOf := make(map[string]int, len(columns))
+	for column, email := range columns {
+		columnOf[email] = column
+	}
+
+	rowOf := make(map[int]int)
+	for rowIndex := 1; rowIndex < len(data); rowIndex++ {
+		first := cellString(data[rowIndex], 0)
+		if first == "SUMA GODZIN" || first == "WYPŁATA" {
+			break
+		}
+		if day, ok := parseDayCell(first); ok {
+			rowOf[day] = rowIndex
+		}
+	}
+
+	assigned := make(map[string][]ScheduledShift)
+	hours := make(map[string]float64)
+	for _, shift := range parseMonthSchedule(data, shop, month, year) {
+		assigned[shift.EmployeeEmail] = append(assigned[shift.EmployeeEmail], shift)
+		hours[shift.EmployeeEmail] += shift.Hours()
+	}
+
+	skipMonth := monthKey(year, monthNum)
+	elsewhere := make(map[string][]ScheduledShift)
+	for email := range columnOf {
+		elsewhere[email] = personShifts(email, shop.ID, skipMonth)
+	}
+
+	// Compliance warnings of each employee's month so far, dropped when they get another shift
+	compliance := make(map[string][]ScheduleWarning)
+
+	norm := nominalWorkingHours(monthNum, year)
+	unfilled := make([]UnfilledSlot, 0)
+
+	for day := 1; day <= getDaysInMonth(month, year); day++ {
+		rowIndex, exists := rowOf[day]
+		if !exists {
+			continue
+		}
+		date := time.Date(year, monthNum, day, 0, 0, 0, 0, time.Local)
+
+		for _, requirement := range shop.coverageOn(date) {
+			slotStart, slotEnd := requirement.window(date)
+
+			staffed := 0
+			for email, shifts := range assigned {
+				for _, shift := range shifts {
+					if !shift.Start.After(slotStart) && !shift.End.Before(slotEnd) && requirement.matches(shop.Employees[email]) {
+						staffed++
+					}
+				}
+			}
+
+			for staffed < requirement.Count {
+				type candidate struct {
+					email     string
+					overTime  bool
+					preferred bool
+					load      float64
+				}
+				var candidates []candidate
+
+				for email, column := range columnOf {
+					employee := shop.Employees[email]
+					if !employee.employedOn(date) || !requirement.matches(employee) {
+						continue
+					}
+					if cellString(data[rowIndex], column) != "" {
+						continue
+					}
+
+					shift := ScheduledShift{
+						EmployeeEmail: email,
+						Date:          date,
+						Start:         slotStart,
+						End:           slotEnd,
+						Value:         requirement.Start + "-" + requirement.End,
+					}
+
+					available, preferred := true, false
+					for _, entry := range availabilityOn(email, date) {
+						start, end := entry.window(date)
+						switch {
+						case entry.Status == availabilityUnavailable && shift.Start.Before(end) && start.Before(shift.End):
+							available = false
+						case entry.Status == availabilityPreferred && !start.After(shift.Start) && !end.Before(shift.End):
+							preferred = true
+						}
+					}
+					if !available {
+						continue
+					}
+
+					overlaps := false
+					for _, other := range elsewhere[email] {
+						overlaps = overlaps || (shift.Start.Before(other.End) && other.Start.Before(shift.End))
+					}
+					if overlaps {
+						continue
+					}
+
+					before, checked := compliance[email]
+					if !checked {
+						before = checkCompliance(shop, month, year, assigned[email])
+						compliance[email] = before
+					}
+					after := checkCompliance(shop, month, year, append(append([]ScheduledShift{}, assigned[email]...), shift))
+					if len(newComplianceWarnings(before, after)) > 0 {
+						continue
+					}
+
+					target := employee.ContractedHours
+					if target <= 0 {
+						target = norm
+					}
+					candidates = append(candidates, candidate{
+						email:     email,
+						overTime:  hours[email]+shift.Hours() > target,
+						preferred: preferred,
+						load:      hours[email] / target,
+					})
+				}
+
+				if len(candidates) == 0 {
+					unfilled = append(unfilled, UnfilledSlot{
+						Date:     date.Format(dateLayout),
+						Start:    requirement.Start,
+						End:      requirement.End,
+						Position: requirement.Position,
+						Missing:  requirement.Count - staffed,
+					})
+					break
+				}
+
+				sort.Slice(candidates, func(i, j int) bool {
+					a, b := candidates[i], candidates[j]
+					if a.overTime != b.overTime {
+						return !a.overTime
+					}
+					if a.preferred != b.preferred {
+						return a.preferred
+					}
+					if a.load != b.load {
+						return a.load < b.load
+					}
+					return a.email < b.email
+				})
+
+				chosen := candidates[0].email
+				column := columnOf[chosen]
+				for len(data[rowIndex]) <= column {
+					data[rowIndex] = append(data[rowIndex], "")
+				}
+				value := requirement.Start + "-" + requirement.End
+				data[rowIndex][column] = value
+
+				shift := ScheduledShift{EmployeeEmail: chosen, Date: date, Start: slotStart, End: slotEnd, Value: value}
+				assigned[chosen] = append(assigned[chosen], shift)
+				delete(compliance, chosen)
+				hours[chosen] += shift.Hours()
+				staffed++
+			}
+		}
+	}
+
+	return applyScheduleTotals(data, shop, month, year), unfilled
+}
+
+// matches reports whether the employee can fill the requirement's position
+func (requirement CoverageRequirement) matches(employee Employee) bool {
+	return requirement.Position == "" || strings.EqualFold(strings.TrimSpace(employee.Position), requirement.Position)
+}
+
+// handleGenerateSchedule builds a month from the coverage requirements and stores it as a
+// draft. Absence codes already in the sheet are kept; shifts are kept only on request.
+func handleGenerateSchedule(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can generate schedules", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID       string `json:"shop_id"`
+		Month        string `json:"month"`
+		Year         int    `json:"year"`
+		KeepExisting bool   `json:"keep_existing"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+	if getMonthNumber(req.Month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if len(shop.Coverage) == 0 {
+		http.Error(w, "Set the shop's coverage requirements first", http.StatusBadRequest)
+		return
+	}
+	if len(shop.Employees) == 0 {
+		http.Error(w, "The shop has no employees", http.StatusBadRequest)
+		return
+	}
+
+	unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+	defer unlock()
+
+	if !requireMonthOpen(w, shop.ID, req.Month, req.Year) {
+		return
+	}
+
+	// Generate on top of the current draft, or start one from the published sheet
+	draft, exists := getScheduleDraft(shop.ID, req.Month, req.Year)
+	if !exists {
+		now := time.Now()
+		draft = ScheduleDraft{ShopID: shop.ID, Month: req.Month, Year: req.Year, CreatedBy: session.UserInfo.Email, CreatedAt: now}
+		if spreadsheetID, exists := shop.Spreadsheets[req.Year]; exists {
+			spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+			if err != nil {
+				http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+				return
+			}
+			draft.Base, err = spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, req.Month)
+			if err != nil {
+				log.Printf("Error reading %s for schedule generation: %v", req.Month, err)
+			}
+		}
+		draft.Data = cloneGrid(draft.Base)
+	}
+	data := cloneGrid(draft.Data)
+	if len(data) == 0 || len(scheduleColumns(data[0], shop.Employees)) == 0 {
+		data = buildMonthGrid(req.Month, req.Year, shop.Employees)
+	}
+
+	if !req.KeepExisting {
+		forEachScheduleCell(data, shop.Employees, req.Month, req.Year, func(email string, date time.Time, value string) {
+			if _, _, _, isShift := shop.resolveShift(value, date); !isShift {
+				return
+			}
+			if row, column, found := findScheduleCell(data, shop, email, date.Day()); found {
+				data[row][column] = ""
+			}
+		})
+	}
+
+	data, unfilled := generateSchedule(shop, req.Month, req.Year, data)
+	shifts := parseMonthSchedule(data, shop, req.Month, req.Year)
+
+	draft.Data = data
+	draft.Unfilled = unfilled
+	draft.Warnings = append(availabilityWarnings(shop, shifts), checkCompliance(shop, req.Month, req.Year, shifts)...)
+	draft.UpdatedBy = session.UserInfo.Email
+	draft.UpdatedAt = time.Now()
+	storeScheduleDraft(draft)
+
+	log.Printf("Generated draft for %s %d in shop %s: %d shifts, %d unfilled slots", req.Month, req.Year, shop.ID, len(shifts), len(unfilled))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(draft)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+func handleShopSettings(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can manage shop settings", http.StatusForbidden)
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(shop.Settings)
+
+	case http.MethodPut:
+		// Decode over the stored settings, toggling auto_approve_swaps keeps open_shift_mode
+		settings := shop.Settings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if settings.OpenShiftMode != "" && settings.OpenShiftMode != openShiftFirstCome && settings.OpenShiftMode != openShiftApproval {
+			http.Error(w, "open_shift_mode must be first_come or approval", http.StatusBadRequest)
+			return
+		}
+
+		employerShopsMutex.Lock()
+		shop = employerShops[session.UserInfo.Email][shopID]
+		shop.Settings = settings
+		shop.UpdatedAt = time.Now()
+		employerShops[session.UserInfo.Email][shopID] = shop
+		employerShopsMutex.Unlock()
+
+		go saveShopsData()
+
+		log.Printf("Updated settings for shop %s by employer %s", shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(settings)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
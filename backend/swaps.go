@@ -0,0 +1,796 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const shiftSwapsFile = "shift_swaps_data.json"
+
+const (
+	swapTypeGiveAway = "give_away"
+	swapTypeSwap     = "swap"
+
+	swapStatusPendingColleague = "pending_colleague"
+	swapStatusPendingEmployer  = "pending_employer"
+	swapStatusApproving        = "approving" // the approved swap is being written to the sheet
+	swapStatusApproved         = "approved"
+	swapStatusDeclined         = "declined"
+	swapStatusRejected         = "rejected"
+	swapStatusCancelled        = "cancelled"
+)
+
+// ShiftSwap is an employee's proposal to give their shift on Date to a colleague, or to
+// exchange it for the colleague's shift on TargetDate
+type ShiftSwap struct {
+	ID             string            `json:"id"`
+	ShopID         string            `json:"shop_id"`
+	ShopName       string            `json:"shop_name"`
+	Type           string            `json:"type"` // give_away | swap
+	RequesterEmail string            `json:"requester_email"`
+	RequesterName  string            `json:"requester_name"`
+	Date           string            `json:"date"`
+	RequesterShift string            `json:"requester_shift"`
+	TargetEmail    string            `json:"target_email"`
+	TargetName     string            `json:"target_name"`
+	TargetDate     string            `json:"target_date,omitempty"`
+	TargetShift    string            `json:"target_shift,omitempty"`
+	Comment        string            `json:"comment,omitempty"`
+	Status         string            `json:"status"`
+	DecidedBy      string            `json:"decided_by,omitempty"`
+	DecidedAt      *time.Time        `json:"decided_at,omitempty"`
+	Issues         []ScheduleWarning `json:"issues,omitempty"` // why the last approval attempt failed
+	History        []LeaveEvent      `json:"history"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+var (
+	shiftSwaps      = make(map[string]ShiftSwap) // swap_id -> swap
+	shiftSwapsMutex sync.RWMutex
+)
+
+func saveShiftSwapsData() error {
+	shiftSwapsMutex.RLock()
+	defer shiftSwapsMutex.RUnlock()
+	return writeJSONFileAtomic(shiftSwapsFile, shiftSwaps)
+}
+
+func loadShiftSwapsData() error {
+	shiftSwapsMutex.Lock()
+	defer shiftSwapsMutex.Unlock()
+	return readJSONFile(shiftSwapsFile, &shiftSwaps)
+}
+
+func getShiftSwap(id string) (ShiftSwap, bool) {
+	shiftSwapsMutex.RLock()
+	defer shiftSwapsMutex.RUnlock()
+	swap, exists := shiftSwaps[id]
+	return swap, exists
+}
+
+func storeShiftSwap(swap ShiftSwap) {
+	shiftSwapsMutex.Lock()
+	shiftSwaps[swap.ID] = swap
+	shiftSwapsMutex.Unlock()
+
+	go saveShiftSwapsData()
+}
+
+// updatePendingSwap applies a status change to the stored swap, only if it still has the
+// expected status. Every transition goes through it, so a cancellation racing an approval
+// cannot store its outcome over the other one.
+func updatePendingSwap(id, expected string, update func(*ShiftSwap)) (ShiftSwap, error) {
+	shiftSwapsMutex.Lock()
+	swap, exists := shiftSwaps[id]
+	if !exists {
+		shiftSwapsMutex.Unlock()
+		return ShiftSwap{}, fmt.Errorf("shift swap %s not found", id)
+	}
+	if swap.Status != expected {
+		shiftSwapsMutex.Unlock()
+		return swap, fmt.Errorf("shift swap is already %s", swap.Status)
+	}
+	swap.History = append([]LeaveEvent(nil), swap.History...)
+	update(&swap)
+	shiftSwaps[id] = swap
+	shiftSwapsMutex.Unlock()
+
+	go saveShiftSwapsData()
+	return swap, nil
+}
+
+// finishSwapApproval stores the outcome of writing a swap taken into approving. A swap that
+// could not be written goes back to the employer, with the issues explaining why.
+func finishSwapApproval(swap ShiftSwap, written bool) ShiftSwap {
+	stored, _ := updatePendingSwap(swap.ID, swapStatusApproving, func(stored *ShiftSwap) {
+		*stored = swap
+		if !written {
+			stored.Status = swapStatusPendingEmployer
+		}
+	})
+	return stored
+}
+
+// indexedShiftOn finds a person's shift in a shop on the given day using the shift index
+func indexedShiftOn(shopID, personEmail string, date time.Time) (ScheduledShift, bool) {
+	shiftIndexMutex.RLock()
+	defer shiftIndexMutex.RUnlock()
+
+	key := normalizeEmail(personEmail)
+	for _, shift := range shiftIndex[shopID][monthKey(date.Year(), date.Month())] {
+		if shift.EmployeeEmail == key && shift.Date.Equal(date) {
+			return shift, true
+		}
+	}
+	return ScheduledShift{}, false
+}
+
+// findScheduleCell locates an employee's cell for a day of the month in a month grid
+func findScheduleCell(data [][]interface{}, shop Shop, shopEmail string, day int) (int, int, bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+
+	column := -1
+	for index, email := range scheduleColumns(data[0], shop.Employees) {
+		if email == shopEmail {
+			column = index
+		}
+	}
+	if column == -1 {
+		return 0, 0, false
+	}
+
+	for rowIndex := 1; rowIndex < len(data); rowIndex++ {
+		first := cellString(data[rowIndex], 0)
+		if first == "SUMA GODZIN" || first == "WYPŁATA" {
+			break
+		}
+		if rowDay, ok := parseDayCell(first); ok && rowDay == day {
+			return rowIndex, column, true
+		}
+	}
+	return 0, 0, false
+}
+
+// lastTotalsRow returns the index of the last SUMA GODZIN or WYPŁATA row, or fallback
+func lastTotalsRow(data [][]interface{}, fallback int) int {
+	last := fallback
+	for rowIndex := fallback + 1; rowIndex < len(data); rowIndex++ {
+		if first := cellString(data[rowIndex], 0); first == "SUMA GODZIN" || first == "WYPŁATA" {
+			last = rowIndex
+		}
+	}
+	return last
+}
+
+func isFreeCell(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == "" || strings.EqualFold(value, "DW")
+}
+
+// applySwap exchanges the cells of both employees on the swapped days. The month sheets are
+// only written when the swap introduces no new compliance problems or double bookings.
+func applySwap(ctx context.Context, service *SpreadsheetService, shop Shop, swap ShiftSwap, actor string) ([]CellChange, []ScheduleWarning, error) {
+	requesterEmail, _, requesterExists := shopEmployee(shop, swap.RequesterEmail)
+	targetEmail, _, targetExists := shopEmployee(shop, swap.TargetEmail)
+	if !requesterExists || !targetExists {
+		return nil, nil, fmt.Errorf("both employees must still work in %s", shop.Name)
+	}
+
+	// Cell values each day must still hold; an empty expectation means a free cell
+	type expectation struct {
+		date      string
+		requester string
+		target    string
+	}
+	expectations := []expectation{{date: swap.Date, requester: swap.RequesterShift}}
+	if swap.Type == swapTypeSwap {
+		if swap.TargetDate == swap.Date {
+			expectations[0].target = swap.TargetShift
+		} else {
+			expectations = append(expectations, expectation{date: swap.TargetDate, target: swap.TargetShift})
+		}
+	}
+
+	// Hold every month the swap touches, in calendar order so two swaps cannot wait on each other
+	var lockedMonths []time.Time
+	lockedKeys := make(map[string]bool)
+	for _, expected := range expectations {
+		date, err := time.ParseInLocation(dateLayout, expected.date, time.Local)
+		if err != nil {
+			return nil, nil, err
+		}
+		if key := monthKey(date.Year(), date.Month()); !lockedKeys[key] {
+			lockedKeys[key] = true
+			lockedMonths = append(lockedMonths, date)
+		}
+	}
+	sort.Slice(lockedMonths, func(i, j int) bool { return lockedMonths[i].Before(lockedMonths[j]) })
+	for _, date := range lockedMonths {
+		unlock := lockScheduleMonth(shop.ID, polishMonths[date.Month()-1], date.Year())
+		defer unlock()
+	}
+
+	type monthGrid struct {
+		month         string
+		year          int
+		spreadsheetID string
+		data          [][]interface{}
+		original      [][]interface{}
+		before        []ScheduleWarning
+		firstRow      int
+		columns       []int
+	}
+	grids := make(map[string]*monthGrid)
+	var order []string
+	var changes []CellChange
+	issues := make([]ScheduleWarning, 0)
+
+	for _, expected := range expectations {
+		date, err := time.ParseInLocation(dateLayout, expected.date, time.Local)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := monthKey(date.Year(), date.Month())
+		grid, loaded := grids[key]
+		if !loaded {
+			month := polishMonths[date.Month()-1]
+			spreadsheetID, exists := shop.Spreadsheets[date.Year()]
+			if !exists {
+				return nil, nil, fmt.Errorf("no spreadsheet found for year %d", date.Year())
+			}
+			if err := checkMonthOpen(shop.ID, month, date.Year()); err != nil {
+				return nil, []ScheduleWarning{{Type: "month_locked", Date: expected.date, Message: err.Error()}}, nil
+			}
+			data, err := service.ReadMonthSchedule(ctx, spreadsheetID, month)
+			if err != nil {
+				return nil, nil, err
+			}
+			grid = &monthGrid{
+				month:         month,
+				year:          date.Year(),
+				spreadsheetID: spreadsheetID,
+				data:          data,
+				original:      cloneGrid(data),
+				before:        checkCompliance(shop, month, date.Year(), parseMonthSchedule(data, shop, month, date.Year())),
+				firstRow:      len(data),
+			}
+			grids[key] = grid
+			order = append(order, key)
+		}
+
+		requesterRow, requesterColumn, found := findScheduleCell(grid.data, shop, requesterEmail, date.Day())
+		targetRow, targetColumn, targetFound := findScheduleCell(grid.data, shop, targetEmail, date.Day())
+		if !found || !targetFound {
+			return nil, nil, fmt.Errorf("schedule cells for %s not found", expected.date)
+		}
+
+		requesterValue := cellString(grid.data[requesterRow], requesterColumn)
+		targetValue := cellString(grid.data[targetRow], targetColumn)
+		for _, check := range []struct{ name, value, expected string }{
+			{swap.RequesterName, requesterValue, expected.requester},
+			{swap.TargetName, targetValue, expected.target},
+		} {
+			matches := isFreeCell(check.value)
+			if check.expected != "" {
+				matches = strings.EqualFold(check.value, check.expected)
+			}
+			if !matches {
+				issues = append(issues, ScheduleWarning{
+					Type:    "schedule_changed",
+					Date:    expected.date,
+					Shift:   check.value,
+					Message: fmt.Sprintf("The schedule of %s on %s changed since the swap was requested", check.name, expected.date),
+				})
+			}
+		}
+
+		for _, row := range []int{requesterRow, targetRow} {
+			for len(grid.data[row]) <= requesterColumn || len(grid.data[row]) <= targetColumn {
+				grid.data[row] = append(grid.data[row], "")
+			}
+		}
+		grid.data[requesterRow][requesterColumn] = targetValue
+		grid.data[targetRow][targetColumn] = requesterValue
+		if requesterRow < grid.firstRow {
+			grid.firstRow = requesterRow
+		}
+		grid.columns = []int{requesterColumn, targetColumn}
+
+		changes = append(changes,
+			CellChange{EmployeeEmail: requesterEmail, Date: expected.date, Before: requesterValue, After: targetValue},
+			CellChange{EmployeeEmail: targetEmail, Date: expected.date, Before: targetValue, After: requesterValue},
+		)
+	}
+	if len(issues) > 0 {
+		return nil, issues, nil
+	}
+
+	owner, _, _ := shopOwner(shop.ID)
+	swappedDays := make(map[string]bool)
+	for _, change := range changes {
+		swappedDays[change.EmployeeEmail+"|"+change.Date] = true
+	}
+
+	for _, key := range order {
+		grid := grids[key]
+		shifts := parseMonthSchedule(grid.data, shop, grid.month, grid.year)
+		issues = append(issues, newComplianceWarnings(grid.before, checkCompliance(shop, grid.month, grid.year, shifts))...)
+
+		var swapped []ScheduledShift
+		for _, shift := range shifts {
+			if swappedDays[shift.EmployeeEmail+"|"+shift.Date.Format(dateLayout)] {
+				swapped = append(swapped, shift)
+			}
+		}
+		for _, conflict := range findShiftConflicts(owner, shop, swapped) {
+			issues = append(issues, ScheduleWarning{
+				Type:          "double_booking",
+				EmployeeEmail: conflict.EmployeeEmail,
+				EmployeeName:  conflict.EmployeeName,
+				Date:          conflict.Date,
+				Shift:         conflict.Shift,
+				Message:       fmt.Sprintf("%s already works in another shop during %s on %s", conflict.EmployeeName, conflict.Shift, conflict.Date),
+			})
+		}
+	}
+	if len(issues) > 0 {
+		return nil, issues, nil
+	}
+
+	for _, key := range order {
+		grid := grids[key]
+		grid.data = applyScheduleTotals(grid.data, shop, grid.month, grid.year)
+		lastRow := lastTotalsRow(grid.data, grid.firstRow)
+		for _, column := range grid.columns {
+			if err := writeColumnSegment(ctx, service, grid.spreadsheetID, grid.month, grid.data, column, grid.firstRow, lastRow); err != nil {
+				return nil, nil, err
+			}
+		}
+		indexMonthSchedule(shop, grid.month, grid.year, grid.data)
+		recordScheduleVersion(shop, grid.original, grid.data, ScheduleVersion{Month: grid.month, Year: grid.year, Action: "swap", Actor: actor})
+	}
+	return changes, nil, nil
+}
+
+// approveSwap writes the swap into the sheet and records it. When the swap cannot be applied
+// the returned issues explain why and the swap stays pending.
+func approveSwap(ctx context.Context, service *SpreadsheetService, swap *ShiftSwap, actor string) ([]ScheduleWarning, error) {
+	_, shop, exists := shopOwner(swap.ShopID)
+	if !exists {
+		return nil, fmt.Errorf("shop %s not found", swap.ShopID)
+	}
+
+	changes, issues, err := applySwap(ctx, service, shop, *swap, actor)
+	if err != nil || len(issues) > 0 {
+		swap.Issues = issues
+		return issues, err
+	}
+
+	now := time.Now()
+	swap.Status = swapStatusApproved
+	swap.Issues = nil
+	swap.DecidedBy = actor
+	swap.DecidedAt = &now
+	swap.History = append(swap.History, LeaveEvent{At: now, By: actor, Action: swapStatusApproved})
+
+	recordAudit(AuditEntry{
+		ShopID:  shop.ID,
+		Action:  "shift_swap",
+		Actor:   actor,
+		Message: fmt.Sprintf("Shift swap between %s and %s approved", swap.RequesterName, swap.TargetName),
+		RefID:   swap.ID,
+		Changes: changes,
+	})
+	notify([]string{swap.RequesterEmail, swap.TargetEmail}, "swap_approved",
+		fmt.Sprintf("The shift swap between %s and %s on %s was approved", swap.RequesterName, swap.TargetName, swap.Date),
+		swap.ID)
+	return nil, nil
+}
+
+// shiftSwapsFor lists swaps visible to the session, newest first
+func shiftSwapsFor(session Session) []ShiftSwap {
+	shiftSwapsMutex.RLock()
+	defer shiftSwapsMutex.RUnlock()
+
+	email := normalizeEmail(session.UserInfo.Email)
+	result := make([]ShiftSwap, 0)
+	for _, swap := range shiftSwaps {
+		if session.Role == "employee" {
+			if swap.RequesterEmail != email && swap.TargetEmail != email {
+				continue
+			}
+		} else if _, exists := getEmployerShop(session.UserInfo.Email, swap.ShopID); !exists {
+			continue
+		}
+		result = append(result, swap)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result
+}
+
+func handleShiftSwaps(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		status := r.URL.Query().Get("status")
+		shopID := r.URL.Query().Get("shop_id")
+		swaps := shiftSwapsFor(session)
+		filtered := make([]ShiftSwap, 0, len(swaps))
+		for _, swap := range swaps {
+			if (status == "" || swap.Status == status) && (shopID == "" || swap.ShopID == shopID) {
+				filtered = append(filtered, swap)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"swaps": filtered})
+
+	case http.MethodPost:
+		if session.Role != "employee" {
+			http.Error(w, "Only employees can propose shift swaps", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			ShopID      string `json:"shop_id"`
+			Type        string `json:"type"`
+			Date        string `json:"date"`
+			TargetEmail string `json:"target_email"`
+			TargetDate  string `json:"target_date"`
+			Comment     string `json:"comment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Type == "" {
+			req.Type = swapTypeGiveAway
+		}
+		if req.Type != swapTypeGiveAway && req.Type != swapTypeSwap {
+			http.Error(w, "Type must be give_away or swap", http.StatusBadRequest)
+			return
+		}
+		if req.ShopID == "" || req.TargetEmail == "" {
+			http.Error(w, "Shop ID and target email are required", http.StatusBadRequest)
+			return
+		}
+		if normalizeEmail(req.TargetEmail) == normalizeEmail(session.UserInfo.Email) {
+			http.Error(w, "You cannot swap a shift with yourself", http.StatusBadRequest)
+			return
+		}
+
+		date, err := time.ParseInLocation(dateLayout, req.Date, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		_, shop, exists := findShopForEmployee(session.UserInfo.Email, req.ShopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		_, requester, _ := shopEmployee(shop, session.UserInfo.Email)
+		_, target, targetExists := shopEmployee(shop, req.TargetEmail)
+		if !targetExists {
+			http.Error(w, "Colleague not found in this shop", http.StatusNotFound)
+			return
+		}
+
+		shift, hasShift := indexedShiftOn(shop.ID, session.UserInfo.Email, date)
+		if !hasShift {
+			http.Error(w, "You have no shift on that day", http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		swap := ShiftSwap{
+			ID:             generateRandomString(16),
+			ShopID:         shop.ID,
+			ShopName:       shop.Name,
+			Type:           req.Type,
+			RequesterEmail: normalizeEmail(session.UserInfo.Email),
+			RequesterName:  requester.Name,
+			Date:           req.Date,
+			RequesterShift: shift.Value,
+			TargetEmail:    normalizeEmail(req.TargetEmail),
+			TargetName:     target.Name,
+			Comment:        strings.TrimSpace(req.Comment),
+			Status:         swapStatusPendingColleague,
+			History: []LeaveEvent{{
+				At:      now,
+				By:      session.UserInfo.Email,
+				Action:  "proposed",
+				Comment: strings.TrimSpace(req.Comment),
+			}},
+			CreatedAt: now,
+		}
+
+		if req.Type == swapTypeSwap {
+			if req.TargetDate == "" {
+				req.TargetDate = req.Date
+			}
+			targetDate, err := time.ParseInLocation(dateLayout, req.TargetDate, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid target date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			targetShift, hasShift := indexedShiftOn(shop.ID, req.TargetEmail, targetDate)
+			if !hasShift {
+				http.Error(w, "Your colleague has no shift on the target day", http.StatusBadRequest)
+				return
+			}
+			swap.TargetDate = req.TargetDate
+			swap.TargetShift = targetShift.Value
+		}
+
+		storeShiftSwap(swap)
+
+		message := fmt.Sprintf("%s wants to give you the shift %s on %s", swap.RequesterName, swap.RequesterShift, swap.Date)
+		if swap.Type == swapTypeSwap {
+			message = fmt.Sprintf("%s wants to swap the shift %s on %s for your shift %s on %s", swap.RequesterName, swap.RequesterShift, swap.Date, swap.TargetShift, swap.TargetDate)
+		}
+		notify([]string{swap.TargetEmail}, "swap_requested", message, swap.ID)
+
+		log.Printf("Employee %s proposed shift swap %s in shop %s", session.UserInfo.Email, swap.ID, shop.ID)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(swap)
+
+	case http.MethodDelete:
+		swap, exists := getShiftSwap(r.URL.Query().Get("id"))
+		if !exists || swap.RequesterEmail != normalizeEmail(session.UserInfo.Email) {
+			http.Error(w, "Shift swap not found", http.StatusNotFound)
+			return
+		}
+		if swap.Status != swapStatusPendingColleague && swap.Status != swapStatusPendingEmployer {
+			http.Error(w, "Only pending shift swaps can be cancelled", http.StatusConflict)
+			return
+		}
+
+		swap, err := updatePendingSwap(swap.ID, swap.Status, func(swap *ShiftSwap) {
+			swap.Status = swapStatusCancelled
+			swap.History = append(swap.History, LeaveEvent{At: time.Now(), By: session.UserInfo.Email, Action: swapStatusCancelled})
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(swap)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleShiftSwapResponse lets the named colleague accept or decline a swap. Accepted swaps
+// go to the employer, or are applied straight away when the shop auto-approves swaps.
+func handleShiftSwapResponse(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ID       string `json:"id"`
+		Decision string `json:"decision"` // accept | decline
+		Comment  string `json:"comment"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Decision != "accept" && req.Decision != "decline" {
+		http.Error(w, "Decision must be accept or decline", http.StatusBadRequest)
+		return
+	}
+
+	swap, exists := getShiftSwap(req.ID)
+	if !exists || swap.TargetEmail != normalizeEmail(session.UserInfo.Email) {
+		http.Error(w, "Shift swap not found", http.StatusNotFound)
+		return
+	}
+	if swap.Status != swapStatusPendingColleague {
+		http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+		return
+	}
+
+	now := time.Now()
+	if req.Decision == "decline" {
+		swap, err := updatePendingSwap(swap.ID, swapStatusPendingColleague, func(swap *ShiftSwap) {
+			swap.Status = swapStatusDeclined
+			swap.History = append(swap.History, LeaveEvent{At: now, By: session.UserInfo.Email, Action: swapStatusDeclined, Comment: strings.TrimSpace(req.Comment)})
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+			return
+		}
+		notify([]string{swap.RequesterEmail}, "swap_declined", fmt.Sprintf("%s declined your shift swap for %s", swap.TargetName, swap.Date), swap.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(swap)
+		return
+	}
+
+	owner, shop, shopExists := shopOwner(swap.ShopID)
+	if !shopExists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	// Auto-approval needs the employer's Google session to write the sheet
+	service, canWrite := cachedSpreadsheetService(owner)
+	autoApprove := shop.Settings.AutoApproveSwaps && canWrite
+
+	swap, err := updatePendingSwap(swap.ID, swapStatusPendingColleague, func(swap *ShiftSwap) {
+		swap.Status = swapStatusPendingEmployer
+		if autoApprove {
+			swap.Status = swapStatusApproving
+		}
+		swap.History = append(swap.History, LeaveEvent{At: now, By: session.UserInfo.Email, Action: "accepted", Comment: strings.TrimSpace(req.Comment)})
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+		return
+	}
+
+	autoApproved := false
+	if autoApprove {
+		issues, err := approveSwap(r.Context(), service, &swap, "auto-approval")
+		if err != nil {
+			log.Printf("Error auto-approving shift swap %s: %v", swap.ID, err)
+		}
+		autoApproved = err == nil && len(issues) == 0
+		swap = finishSwapApproval(swap, autoApproved)
+	}
+
+	if !autoApproved {
+		notify([]string{owner}, "swap_pending",
+			fmt.Sprintf("%s and %s agreed to swap shifts on %s in %s and wait for your approval", swap.RequesterName, swap.TargetName, swap.Date, swap.ShopName),
+			swap.ID)
+	}
+
+	log.Printf("Employee %s accepted shift swap %s (auto-approved: %v)", session.UserInfo.Email, swap.ID, autoApproved)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swap)
+}
+
+func handleShiftSwapDecision(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can decide on shift swaps", http.StatusForbidden)
+		return
+	}
+
+	var req LeaveDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Decision != "approve" && req.Decision != "reject" {
+		http.Error(w, "Decision must be approve or reject", http.StatusBadRequest)
+		return
+	}
+
+	swap, exists := getShiftSwap(req.ID)
+	if !exists {
+		http.Error(w, "Shift swap not found", http.StatusNotFound)
+		return
+	}
+	if _, owned := getEmployerShop(session.UserInfo.Email, swap.ShopID); !owned {
+		http.Error(w, "Shift swap not found", http.StatusNotFound)
+		return
+	}
+	if swap.Status != swapStatusPendingEmployer {
+		http.Error(w, fmt.Sprintf("Shift swap is %s", swap.Status), http.StatusConflict)
+		return
+	}
+
+	if req.Decision == "reject" {
+		now := time.Now()
+		swap, err := updatePendingSwap(swap.ID, swapStatusPendingEmployer, func(swap *ShiftSwap) {
+			swap.Status = swapStatusRejected
+			swap.DecidedBy = session.UserInfo.Email
+			swap.DecidedAt = &now
+			swap.History = append(swap.History, LeaveEvent{At: now, By: session.UserInfo.Email, Action: swapStatusRejected, Comment: strings.TrimSpace(req.Comment)})
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+			return
+		}
+
+		message := fmt.Sprintf("The shift swap between %s and %s on %s was rejected", swap.RequesterName, swap.TargetName, swap.Date)
+		if comment := strings.TrimSpace(req.Comment); comment != "" {
+			message += ": " + comment
+		}
+		notify([]string{swap.RequesterEmail, swap.TargetEmail}, "swap_rejected", message, swap.ID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(swap)
+		return
+	}
+
+	service, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	// Take the swap out of the pending ones first, so a cancellation made while the sheet is
+	// written is turned down
+	swap, err = updatePendingSwap(swap.ID, swapStatusPendingEmployer, func(swap *ShiftSwap) {
+		swap.Status = swapStatusApproving
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Shift swap is already %s", swap.Status), http.StatusConflict)
+		return
+	}
+
+	issues, err := approveSwap(r.Context(), service, &swap, session.UserInfo.Email)
+	swap = finishSwapApproval(swap, err == nil && len(issues) == 0)
+	if err != nil {
+		log.Printf("Error applying shift swap %s: %v", swap.ID, err)
+		http.Error(w, "Failed to update schedule", http.StatusInternalServerError)
+		return
+	}
+	if len(issues) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":  "Shift swap breaks scheduling rules",
+			"issues": issues,
+			"swap":   swap,
+		})
+		return
+	}
+
+	log.Printf("Shift swap %s approved by %s", swap.ID, session.UserInfo.Email)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swap)
+}
@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestUpdatePendingSwapTransitions(t *testing.T) {
+	const id = "swap-test"
+	reset := func(status string) {
+		shiftSwapsMutex.Lock()
+		shiftSwaps[id] = ShiftSwap{ID: id, Status: status}
+		shiftSwapsMutex.Unlock()
+	}
+	defer func() {
+		shiftSwapsMutex.Lock()
+		delete(shiftSwaps, id)
+		shiftSwapsMutex.Unlock()
+	}()
+
+	tests := []struct {
+		name       string
+		status     string
+		expected   string
+		next       string
+		wantErr    bool
+		wantStored string
+	}{
+		{"approval takes a pending swap", swapStatusPendingEmployer, swapStatusPendingEmployer, swapStatusApproving, false, swapStatusApproving},
+		{"cancelling a swap being approved is turned down", swapStatusApproving, swapStatusPendingEmployer, swapStatusCancelled, true, swapStatusApproving},
+		{"rejecting a cancelled swap is turned down", swapStatusCancelled, swapStatusPendingEmployer, swapStatusRejected, true, swapStatusCancelled},
+		{"declining an accepted swap is turned down", swapStatusPendingEmployer, swapStatusPendingColleague, swapStatusDeclined, true, swapStatusPendingEmployer},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reset(test.status)
+			_, err := updatePendingSwap(id, test.expected, func(swap *ShiftSwap) {
+				swap.Status = test.next
+			})
+			if (err != nil) != test.wantErr {
+				t.Errorf("error = %v, want error: %v", err, test.wantErr)
+			}
+			if stored, _ := getShiftSwap(id); stored.Status != test.wantStored {
+				t.Errorf("stored status = %s, want %s", stored.Status, test.wantStored)
+			}
+		})
+	}
+
+	t.Run("a swap that could not be written goes back to the employer", func(t *testing.T) {
+		reset(swapStatusApproving)
+		swap := finishSwapApproval(ShiftSwap{ID: id, Status: swapStatusApproving, Issues: []ScheduleWarning{{Message: "double booking"}}}, false)
+		if swap.Status != swapStatusPendingEmployer || len(swap.Issues) != 1 {
+			t.Errorf("got status %s with %d issues, want pending_employer with the issue", swap.Status, len(swap.Issues))
+		}
+	})
+}
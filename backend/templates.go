@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var shiftTemplateCodePattern = regexp.MustCompile(`^[A-ZĄĆĘŁŃÓŚŹŻ][A-ZĄĆĘŁŃÓŚŹŻ0-9]{0,4}$`)
+
+// ShiftTemplate is a named shift that can be written into schedule cells by its code, e.g.
+// R = 06:00-14:00. Every change is stored as a new version valid from EffectiveFrom, so cells
+// on earlier dates keep resolving to the times they were planned with.
+type ShiftTemplate struct {
+	Code          string    `json:"code"`
+	Name          string    `json:"name,omitempty"`
+	Start         string    `json:"start"` // HH:MM
+	End           string    `json:"end"`   // HH:MM, before Start for overnight shifts
+	BreakMinutes  int       `json:"break_minutes,omitempty"`
+	EffectiveFrom string    `json:"effective_from"` // YYYY-MM-DD
+	Retired       bool      `json:"retired,omitempty"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (template ShiftTemplate) validate() error {
+	if !shiftTemplateCodePattern.MatchString(template.Code) {
+		return fmt.Errorf("template code %q must be 1-5 uppercase letters or digits starting with a letter", template.Code)
+	}
+	start, end, ok := parseShiftTimes(template.Start + "-" + template.End)
+	if !ok {
+		return fmt.Errorf("invalid shift %s-%s, expected HH:MM times", template.Start, template.End)
+	}
+	if template.BreakMinutes < 0 || template.BreakMinutes >= end-start {
+		return fmt.Errorf("break_minutes must be between 0 and the shift length")
+	}
+	return nil
+}
+
+// shiftTemplate returns the version of a template code valid on the given day
+func (shop Shop) shiftTemplate(code string, day time.Time) (ShiftTemplate, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	date := day.Format(dateLayout)
+
+	var current ShiftTemplate
+	found := false
+	for _, template := range shop.ShiftTemplates {
+		if template.Code != code || template.EffectiveFrom > date {
+			continue
+		}
+		if !found || template.EffectiveFrom >= current.EffectiveFrom {
+			current = template
+			found = true
+		}
+	}
+	if !found || current.Retired {
+		return ShiftTemplate{}, false
+	}
+	return current, true
+}
+
+// hasShiftTemplate reports whether any version of the code exists, including retired ones
+func (shop Shop) hasShiftTemplate(code string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	for _, template := range shop.ShiftTemplates {
+		if template.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveShift reads a schedule cell holding either explicit shift times or a template code
+func (shop Shop) resolveShift(value string, day time.Time) (startMinutes, endMinutes, breakMinutes int, ok bool) {
+	if start, end, isShift := parseShiftTimes(value); isShift {
+		return start, end, 0, true
+	}
+	template, exists := shop.shiftTemplate(value, day)
+	if !exists {
+		return 0, 0, 0, false
+	}
+	start, end, _ := parseShiftTimes(template.Start + "-" + template.End)
+	return start, end, template.BreakMinutes, true
+}
+
+// currentShiftTemplates lists the templates valid on the given day, ordered by code
+func (shop Shop) currentShiftTemplates(day time.Time) []ShiftTemplate {
+	seen := make(map[string]bool)
+	result := make([]ShiftTemplate, 0)
+	for _, template := range shop.ShiftTemplates {
+		if seen[template.Code] {
+			continue
+		}
+		seen[template.Code] = true
+		if current, exists := shop.shiftTemplate(template.Code, day); exists {
+			result = append(result, current)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
+
+// effectiveFromParam checks that a template change does not reach back into already planned
+// days; an empty value means today
+func effectiveFromParam(value string) (string, error) {
+	today := time.Now().Format(dateLayout)
+	if value == "" {
+		return today, nil
+	}
+	date, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid effective_from date")
+	}
+	if date.Format(dateLayout) < today {
+		return "", fmt.Errorf("template changes cannot take effect in the past")
+	}
+	return date.Format(dateLayout), nil
+}
+
+// addShiftTemplateVersion stores a new version, replacing a version of the same code that
+// starts on the same day
+func addShiftTemplateVersion(employerEmail, shopID string, version ShiftTemplate) Shop {
+	employerShopsMutex.Lock()
+	shop := employerShops[employerEmail][shopID]
+	templates := make([]ShiftTemplate, 0, len(shop.ShiftTemplates)+1)
+	for _, template := range shop.ShiftTemplates {
+		if template.Code == version.Code && template.EffectiveFrom == version.EffectiveFrom {
+			continue
+		}
+		templates = append(templates, template)
+	}
+	shop.ShiftTemplates = append(templates, version)
+	shop.UpdatedAt = time.Now()
+	employerShops[employerEmail][shopID] = shop
+	employerShopsMutex.Unlock()
+
+	go saveShopsData()
+	return shop
+}
+
+func handleShiftTemplates(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	if shopID == "" {
+		http.Error(w, "Shop ID is required", http.StatusBadRequest)
+		return
+	}
+
+	var shop Shop
+	var exists bool
+	switch session.Role {
+	case "employer":
+		shop, exists = getEmployerShop(session.UserInfo.Email, shopID)
+	case "employee":
+		_, shop, exists = findShopForEmployee(session.UserInfo.Email, shopID)
+	}
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet && session.Role != "employer" {
+		http.Error(w, "Only employers can manage shift templates", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		day := time.Now()
+		if dateParam := r.URL.Query().Get("date"); dateParam != "" {
+			parsed, err := time.ParseInLocation(dateLayout, dateParam, time.Local)
+			if err != nil {
+				http.Error(w, "Invalid date", http.StatusBadRequest)
+				return
+			}
+			day = parsed
+		}
+
+		response := map[string]interface{}{
+			"date":      day.Format(dateLayout),
+			"templates": shop.currentShiftTemplates(day),
+		}
+		if session.Role == "employer" {
+			versions := shop.ShiftTemplates
+			if versions == nil {
+				versions = []ShiftTemplate{}
+			}
+			response["versions"] = versions
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPut:
+		var req ShiftTemplate
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+		req.Name = strings.TrimSpace(req.Name)
+		if err := req.validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, isAbsence := shop.absenceCode(req.Code); isAbsence {
+			http.Error(w, fmt.Sprintf("code %s is already used by an absence code", req.Code), http.StatusBadRequest)
+			return
+		}
+		effectiveFrom, err := effectiveFromParam(req.EffectiveFrom)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		req.EffectiveFrom = effectiveFrom
+		req.Retired = false
+		req.CreatedBy = session.UserInfo.Email
+		req.CreatedAt = time.Now()
+		shop = addShiftTemplateVersion(session.UserInfo.Email, shopID, req)
+
+		recordAudit(AuditEntry{
+			ShopID:  shopID,
+			Action:  "shift_template_updated",
+			Actor:   session.UserInfo.Email,
+			Message: fmt.Sprintf("Shift %s set to %s-%s from %s", req.Code, req.Start, req.End, req.EffectiveFrom),
+		})
+
+		log.Printf("Updated shift template %s for shop %s by employer %s", req.Code, shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"template":  req,
+			"templates": shop.currentShiftTemplates(time.Now()),
+		})
+
+	case http.MethodDelete:
+		code := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("code")))
+		if code == "" {
+			http.Error(w, "Template code is required", http.StatusBadRequest)
+			return
+		}
+		effectiveFrom, err := effectiveFromParam(r.URL.Query().Get("effective_from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		day, _ := time.ParseInLocation(dateLayout, effectiveFrom, time.Local)
+		current, exists := shop.shiftTemplate(code, day)
+		if !exists {
+			http.Error(w, "Shift template not found", http.StatusNotFound)
+			return
+		}
+
+		// Earlier days keep their template, only cells from effective_from on stop resolving
+		current.EffectiveFrom = effectiveFrom
+		current.Retired = true
+		current.CreatedBy = session.UserInfo.Email
+		current.CreatedAt = time.Now()
+		shop = addShiftTemplateVersion(session.UserInfo.Email, shopID, current)
+
+		recordAudit(AuditEntry{
+			ShopID:  shopID,
+			Action:  "shift_template_retired",
+			Actor:   session.UserInfo.Email,
+			Message: fmt.Sprintf("Shift %s retired from %s", code, effectiveFrom),
+		})
+
+		log.Printf("Retired shift template %s for shop %s by employer %s", code, shopID, session.UserInfo.Email)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":   "Shift template retired",
+			"templates": shop.currentShiftTemplates(time.Now()),
+		})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,890 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	timeEntriesFile = "time_entries_data.json"
+
+	timeSourceSelf    = "self"
+	timeSourceManager = "manager"
+
+	punchGraceMinutes = 5              // lateness and early leave below this are not reported
+	maxShiftDuration  = 16 * time.Hour // an older clock-in without clock-out counts as a missing punch
+	earlyClockIn      = 2 * time.Hour  // how long before a night shift a clock-in still belongs to it
+	clockOutGrace     = time.Hour      // overtime after which a missing clock-out is reported
+)
+
+// TimeEntry holds the actual clock-in and clock-out of one shift. Date is the day of the
+// planned shift, so a night shift clocked out after midnight still belongs to its day.
+type TimeEntry struct {
+	ID            string     `json:"id"`
+	ShopID        string     `json:"shop_id"`
+	EmployeeEmail string     `json:"employee_email"`
+	Date          string     `json:"date"`
+	ClockIn       *time.Time `json:"clock_in,omitempty"`
+	ClockOut      *time.Time `json:"clock_out,omitempty"`
+	Source        string     `json:"source"` // self | manager | kiosk
+	RecordedBy    string     `json:"recorded_by"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TimeReportRow compares one planned shift with what was actually clocked
+type TimeReportRow struct {
+	EmployeeEmail     string     `json:"employee_email"`
+	EmployeeName      string     `json:"employee_name"`
+	Date              string     `json:"date"`
+	Planned           string     `json:"planned,omitempty"`
+	ClockIn           *time.Time `json:"clock_in,omitempty"`
+	ClockOut          *time.Time `json:"clock_out,omitempty"`
+	PlannedHours      float64    `json:"planned_hours"`
+	ActualHours       float64    `json:"actual_hours"`
+	DifferenceHours   float64    `json:"difference_hours"`
+	LateMinutes       int        `json:"late_minutes"`
+	EarlyLeaveMinutes int        `json:"early_leave_minutes"`
+	MissingClockIn    bool       `json:"missing_clock_in"`
+	MissingClockOut   bool       `json:"missing_clock_out"`
+	Unplanned         bool       `json:"unplanned"`
+}
+
+// TimeReportSummary adds up the report rows of one employee
+type TimeReportSummary struct {
+	EmployeeEmail     string  `json:"employee_email"`
+	EmployeeName      string  `json:"employee_name"`
+	PlannedHours      float64 `json:"planned_hours"`
+	ActualHours       float64 `json:"actual_hours"`
+	DifferenceHours   float64 `json:"difference_hours"`
+	LateCount         int     `json:"late_count"`
+	LateMinutes       int     `json:"late_minutes"`
+	EarlyLeaveCount   int     `json:"early_leave_count"`
+	EarlyLeaveMinutes int     `json:"early_leave_minutes"`
+	MissingPunches    int     `json:"missing_punches"`
+}
+
+var (
+	timeEntries      = make(map[string][]TimeEntry) // shop_id -> entries
+	timeEntriesMutex sync.RWMutex
+)
+
+func saveTimeEntriesData() error {
+	timeEntriesMutex.RLock()
+	defer timeEntriesMutex.RUnlock()
+	return writeJSONFileAtomic(timeEntriesFile, timeEntries)
+}
+
+func loadTimeEntriesData() error {
+	timeEntriesMutex.Lock()
+	defer timeEntriesMutex.Unlock()
+	return readJSONFile(timeEntriesFile, &timeEntries)
+}
+
+func storeTimeEntry(entry TimeEntry) {
+	timeEntriesMutex.Lock()
+	putTimeEntry(entry)
+	timeEntriesMutex.Unlock()
+
+	go saveTimeEntriesData()
+}
+
+// putTimeEntry adds the entry or replaces the one with its ID; the caller holds timeEntriesMutex
+func putTimeEntry(entry TimeEntry) {
+	list := timeEntries[entry.ShopID]
+	replaced := false
+	for index := range list {
+		if list[index].ID == entry.ID {
+			list[index] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		list = append(list, entry)
+	}
+	timeEntries[entry.ShopID] = list
+}
+
+// replaceDayTimeEntries stores entry in place of all of the employee's entries on its date and
+// returns the ones it replaced
+func replaceDayTimeEntries(entry TimeEntry) []TimeEntry {
+	timeEntriesMutex.Lock()
+	var kept, replaced []TimeEntry
+	for _, existing := range timeEntries[entry.ShopID] {
+		if existing.Date == entry.Date && normalizeEmail(existing.EmployeeEmail) == normalizeEmail(entry.EmployeeEmail) {
+			replaced = append(replaced, existing)
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	timeEntries[entry.ShopID] = append(kept, entry)
+	timeEntriesMutex.Unlock()
+
+	go saveTimeEntriesData()
+	return replaced
+}
+
+func deleteTimeEntry(shopID, id string) (TimeEntry, bool) {
+	timeEntriesMutex.Lock()
+	defer timeEntriesMutex.Unlock()
+
+	list := timeEntries[shopID]
+	for index, entry := range list {
+		if entry.ID == id {
+			timeEntries[shopID] = append(list[:index], list[index+1:]...)
+			go saveTimeEntriesData()
+			return entry, true
+		}
+	}
+	return TimeEntry{}, false
+}
+
+// monthTimeEntries lists a shop's entries for a month, optionally for one employee
+func monthTimeEntries(shopID, month string, year int, employeeEmail string) []TimeEntry {
+	prefix := monthKey(year, getMonthNumber(month)) + "-"
+
+	timeEntriesMutex.RLock()
+	defer timeEntriesMutex.RUnlock()
+
+	result := make([]TimeEntry, 0)
+	for _, entry := range timeEntries[shopID] {
+		if !strings.HasPrefix(entry.Date, prefix) {
+			continue
+		}
+		if employeeEmail != "" && normalizeEmail(entry.EmployeeEmail) != normalizeEmail(employeeEmail) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		return result[i].EmployeeEmail < result[j].EmployeeEmail
+	})
+	return result
+}
+
+// openTimeEntry returns the employee's latest clock-in without a clock-out, unless it is so
+// old that the clock-out was evidently forgotten
+func openTimeEntry(shopID, employeeEmail string, now time.Time) (TimeEntry, bool) {
+	timeEntriesMutex.RLock()
+	defer timeEntriesMutex.RUnlock()
+	return findOpenTimeEntry(shopID, employeeEmail, now)
+}
+
+// findOpenTimeEntry is openTimeEntry for a caller holding timeEntriesMutex
+func findOpenTimeEntry(shopID, employeeEmail string, now time.Time) (TimeEntry, bool) {
+	var open TimeEntry
+	found := false
+	for _, entry := range timeEntries[shopID] {
+		if normalizeEmail(entry.EmployeeEmail) != normalizeEmail(employeeEmail) || entry.ClockIn == nil || entry.ClockOut != nil {
+			continue
+		}
+		if now.Sub(*entry.ClockIn) > maxShiftDuration {
+			continue
+		}
+		if !found || entry.ClockIn.After(*open.ClockIn) {
+			open = entry
+			found = true
+		}
+	}
+	return open, found
+}
+
+// shiftDateFor picks the day a clock-in belongs to: the previous day while its shift runs past
+// midnight, the next day shortly before a shift starting at midnight, otherwise today
+func shiftDateFor(shopID, employeeEmail string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if shift, exists := indexedShiftOn(shopID, employeeEmail, today.AddDate(0, 0, -1)); exists && now.Before(shift.End) {
+		return today.AddDate(0, 0, -1)
+	}
+	tomorrow := today.AddDate(0, 0, 1)
+	if shift, exists := indexedShiftOn(shopID, employeeEmail, tomorrow); exists && !now.Before(shift.Start.Add(-earlyClockIn)) {
+		return tomorrow
+	}
+	return today
+}
+
+// clockPunch records a clock-in or clock-out of an employee in a shop. An empty action
+// toggles: it clocks out when a clock-in is open and clocks in otherwise.
+func clockPunch(shop Shop, employeeEmail, action, source, actor string, now time.Time) (TimeEntry, error) {
+	shopEmail, _, exists := shopEmployee(shop, employeeEmail)
+	if !exists {
+		return TimeEntry{}, fmt.Errorf("%s is not an employee of %s", employeeEmail, shop.Name)
+	}
+
+	// Finding the open clock-in and storing the punch happen under one lock, so two punches
+	// arriving together cannot both clock in
+	timeEntriesMutex.Lock()
+	defer timeEntriesMutex.Unlock()
+
+	open, isOpen := findOpenTimeEntry(shop.ID, shopEmail, now)
+	if action == "" {
+		action = "in"
+		if isOpen {
+			action = "out"
+		}
+	}
+
+	switch action {
+	case "in":
+		if isOpen {
+			return TimeEntry{}, fmt.Errorf("already clocked in since %s", open.ClockIn.Format("15:04"))
+		}
+		date := shiftDateFor(shop.ID, shopEmail, now)
+		if err := checkMonthOpen(shop.ID, polishMonths[date.Month()-1], date.Year()); err != nil {
+			return TimeEntry{}, err
+		}
+		entry := TimeEntry{
+			ID:            generateRandomString(12),
+			ShopID:        shop.ID,
+			EmployeeEmail: shopEmail,
+			Date:          date.Format(dateLayout),
+			ClockIn:       &now,
+			Source:        source,
+			RecordedBy:    actor,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		putTimeEntry(entry)
+		go saveTimeEntriesData()
+		return entry, nil
+
+	case "out":
+		if !isOpen {
+			return TimeEntry{}, fmt.Errorf("not clocked in")
+		}
+		open.ClockOut = &now
+		open.UpdatedAt = now
+		putTimeEntry(open)
+		go saveTimeEntriesData()
+		return open, nil
+	}
+	return TimeEntry{}, fmt.Errorf("action must be in or out")
+}
+
+// span returns the clocked interval of an entry; an entry with one punch spans just that moment
+func (entry TimeEntry) span() (time.Time, time.Time, bool) {
+	switch {
+	case entry.ClockIn != nil && entry.ClockOut != nil:
+		return *entry.ClockIn, *entry.ClockOut, true
+	case entry.ClockIn != nil:
+		return *entry.ClockIn, *entry.ClockIn, true
+	case entry.ClockOut != nil:
+		return *entry.ClockOut, *entry.ClockOut, true
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func (entry TimeEntry) complete() bool {
+	return entry.ClockIn != nil && entry.ClockOut != nil && entry.ClockOut.After(*entry.ClockIn)
+}
+
+// pairTimeEntries gives every entry to the planned shift of the same employee it overlaps
+// most. A shift accepts entries from earlyClockIn before its start until clockOutGrace after
+// its end, so a day with a split shift keeps its two parts apart. Entries matching no shift
+// are returned as unplanned. The entries of a shift are ordered by time.
+func pairTimeEntries(planned []ScheduledShift, entries []TimeEntry) (map[int][]TimeEntry, []TimeEntry) {
+	byEmployee := make(map[string][]int)
+	for index, shift := range planned {
+		email := normalizeEmail(shift.EmployeeEmail)
+		byEmployee[email] = append(byEmployee[email], index)
+	}
+
+	paired := make(map[int][]TimeEntry)
+	var unplanned []TimeEntry
+	for _, entry := range entries {
+		start, end, ok := entry.span()
+		if !ok {
+			continue
+		}
+		best, bestOverlap := -1, time.Duration(0)
+		for _, index := range byEmployee[normalizeEmail(entry.EmployeeEmail)] {
+			shift := planned[index]
+			if start.After(shift.End.Add(clockOutGrace)) || end.Before(shift.Start.Add(-earlyClockIn)) {
+				continue
+			}
+			overlapStart, overlapEnd := shift.Start, shift.End
+			if start.After(overlapStart) {
+				overlapStart = start
+			}
+			if end.Before(overlapEnd) {
+				overlapEnd = end
+			}
+			if overlap := overlapEnd.Sub(overlapStart); best == -1 || overlap > bestOverlap {
+				best, bestOverlap = index, overlap
+			}
+		}
+		if best == -1 {
+			unplanned = append(unplanned, entry)
+			continue
+		}
+		paired[best] = append(paired[best], entry)
+	}
+
+	for _, list := range paired {
+		sort.Slice(list, func(i, j int) bool {
+			a, _, _ := list[i].span()
+			b, _, _ := list[j].span()
+			return a.Before(b)
+		})
+	}
+	return paired, unplanned
+}
+
+// actualShifts replaces planned shifts by the clocked times of their time entries. Shifts
+// without a complete entry keep their planned times, so a forgotten punch does not cut pay;
+// the time report lists them as missing punches. A shift clocked in several parts becomes
+// one shift per part without the planned break, which was taken between them. Complete
+// entries outside any planned shift are added as worked shifts.
+func actualShifts(planned []ScheduledShift, entries []TimeEntry) []ScheduledShift {
+	paired, unplanned := pairTimeEntries(planned, entries)
+
+	shifts := make([]ScheduledShift, 0, len(planned))
+	for index, shift := range planned {
+		var parts []TimeEntry
+		for _, entry := range paired[index] {
+			if entry.complete() {
+				parts = append(parts, entry)
+			}
+		}
+		if len(parts) == 0 {
+			shifts = append(shifts, shift)
+			continue
+		}
+		for _, entry := range parts {
+			actual := shift
+			actual.Start = *entry.ClockIn
+			actual.End = *entry.ClockOut
+			if len(parts) > 1 {
+				actual.BreakMinutes = 0
+			}
+			shifts = append(shifts, actual)
+		}
+	}
+	for _, entry := range unplanned {
+		if !entry.complete() {
+			continue
+		}
+		date, err := time.ParseInLocation(dateLayout, entry.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		shifts = append(shifts, ScheduledShift{
+			EmployeeEmail: entry.EmployeeEmail,
+			Date:          date,
+			Start:         *entry.ClockIn,
+			End:           *entry.ClockOut,
+			Value:         fmt.Sprintf("%s-%s", entry.ClockIn.Format("15:04"), entry.ClockOut.Format("15:04")),
+		})
+	}
+
+	sort.Slice(shifts, func(i, j int) bool {
+		if !shifts[i].Start.Equal(shifts[j].Start) {
+			return shifts[i].Start.Before(shifts[j].Start)
+		}
+		return shifts[i].EmployeeEmail < shifts[j].EmployeeEmail
+	})
+	return shifts
+}
+
+// buildTimeReport compares the planned shifts of a month with the time entries. A planned
+// shift in the past without any entry is reported with both punches missing. A shift clocked
+// in several parts shows its first clock-in, its last clock-out and the sum of the parts.
+func buildTimeReport(shop Shop, planned []ScheduledShift, entries []TimeEntry, now time.Time) ([]TimeReportRow, []TimeReportSummary) {
+	paired, unplanned := pairTimeEntries(planned, entries)
+
+	employeeName := func(email string) string {
+		if _, employee, exists := shopEmployee(shop, email); exists {
+			return employee.Name
+		}
+		return email
+	}
+	minutes := func(d time.Duration) int {
+		return int(d.Round(time.Minute) / time.Minute)
+	}
+
+	rows := make([]TimeReportRow, 0, len(planned))
+	for index, shift := range planned {
+		parts := paired[index]
+		if len(parts) == 0 && shift.Start.After(now) {
+			continue // not due yet
+		}
+
+		row := TimeReportRow{
+			EmployeeEmail: shift.EmployeeEmail,
+			EmployeeName:  employeeName(shift.EmployeeEmail),
+			Date:          shift.Date.Format(dateLayout),
+			Planned:       shift.Value,
+			PlannedHours:  roundMoney(shift.Hours()),
+		}
+		if len(parts) > 0 {
+			row.ClockIn = parts[0].ClockIn
+			row.ClockOut = parts[len(parts)-1].ClockOut
+		}
+		row.MissingClockIn = len(parts) == 0
+		for position, entry := range parts {
+			row.MissingClockIn = row.MissingClockIn || entry.ClockIn == nil
+			// Only the last part may still be open
+			if entry.ClockOut == nil && position < len(parts)-1 {
+				row.MissingClockOut = true
+			}
+		}
+		if row.ClockOut == nil && now.After(shift.End.Add(clockOutGrace)) {
+			row.MissingClockOut = true
+		}
+		if row.ClockIn != nil {
+			if late := minutes(row.ClockIn.Sub(shift.Start)); late > punchGraceMinutes {
+				row.LateMinutes = late
+			}
+		}
+		if row.ClockOut != nil {
+			if early := minutes(shift.End.Sub(*row.ClockOut)); early > punchGraceMinutes {
+				row.EarlyLeaveMinutes = early
+			}
+		}
+		for _, entry := range parts {
+			if !entry.complete() {
+				continue
+			}
+			actual := shift
+			actual.Start, actual.End = *entry.ClockIn, *entry.ClockOut
+			if len(parts) > 1 {
+				actual.BreakMinutes = 0
+			}
+			row.ActualHours = roundMoney(row.ActualHours + actual.Hours())
+		}
+		row.DifferenceHours = roundMoney(row.ActualHours - row.PlannedHours)
+		rows = append(rows, row)
+	}
+
+	// Time clocked outside any planned shift
+	for _, entry := range unplanned {
+		row := TimeReportRow{
+			EmployeeEmail:   entry.EmployeeEmail,
+			EmployeeName:    employeeName(entry.EmployeeEmail),
+			Date:            entry.Date,
+			ClockIn:         entry.ClockIn,
+			ClockOut:        entry.ClockOut,
+			MissingClockIn:  entry.ClockIn == nil,
+			MissingClockOut: entry.ClockOut == nil && entry.ClockIn != nil && now.Sub(*entry.ClockIn) > maxShiftDuration,
+			Unplanned:       true,
+		}
+		if entry.complete() {
+			row.ActualHours = roundMoney(entry.ClockOut.Sub(*entry.ClockIn).Hours())
+		}
+		row.DifferenceHours = row.ActualHours
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Date != rows[j].Date {
+			return rows[i].Date < rows[j].Date
+		}
+		return rows[i].EmployeeName < rows[j].EmployeeName
+	})
+
+	summaries := make(map[string]*TimeReportSummary)
+	var order []string
+	for _, row := range rows {
+		summary, exists := summaries[row.EmployeeEmail]
+		if !exists {
+			summary = &TimeReportSummary{EmployeeEmail: row.EmployeeEmail, EmployeeName: row.EmployeeName}
+			summaries[row.EmployeeEmail] = summary
+			order = append(order, row.EmployeeEmail)
+		}
+		summary.PlannedHours = roundMoney(summary.PlannedHours + row.PlannedHours)
+		summary.ActualHours = roundMoney(summary.ActualHours + row.ActualHours)
+		summary.DifferenceHours = roundMoney(summary.ActualHours - summary.PlannedHours)
+		if row.LateMinutes > 0 {
+			summary.LateCount++
+			summary.LateMinutes += row.LateMinutes
+		}
+		if row.EarlyLeaveMinutes > 0 {
+			summary.EarlyLeaveCount++
+			summary.EarlyLeaveMinutes += row.EarlyLeaveMinutes
+		}
+		if row.MissingClockIn {
+			summary.MissingPunches++
+		}
+		if row.MissingClockOut {
+			summary.MissingPunches++
+		}
+	}
+
+	result := make([]TimeReportSummary, 0, len(order))
+	for _, email := range order {
+		result = append(result, *summaries[email])
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].EmployeeName < result[j].EmployeeName })
+	return rows, result
+}
+
+// sessionShop resolves a shop the session may see: an employer's own shop or a shop the
+// employee works in
+func sessionShop(session Session, shopID string) (Shop, bool) {
+	switch session.Role {
+	case "employer":
+		return getEmployerShop(session.UserInfo.Email, shopID)
+	case "employee":
+		_, shop, exists := findShopForEmployee(session.UserInfo.Email, shopID)
+		return shop, exists
+	}
+	return Shop{}, false
+}
+
+// handleClock clocks the logged-in employee in or out of a shop
+func handleClock(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employee" {
+		http.Error(w, "Only employees can clock in", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID string `json:"shop_id"`
+		Action string `json:"action"` // in | out, empty toggles
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	shop, exists := sessionShop(session, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	if shop.Settings.KioskOnly {
+		http.Error(w, "This shop only accepts punches from its kiosk, scan the code at the shop", http.StatusForbidden)
+		return
+	}
+
+	entry, err := clockPunch(shop, session.UserInfo.Email, req.Action, timeSourceSelf, session.UserInfo.Email, time.Now())
+	if respondMonthLocked(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	log.Printf("Employee %s clocked %s in shop %s", session.UserInfo.Email, clockDirection(entry), shop.ID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": fmt.Sprintf("Clocked %s", clockDirection(entry)),
+		"entry":   entry,
+	})
+}
+
+func clockDirection(entry TimeEntry) string {
+	if entry.ClockOut != nil {
+		return "out"
+	}
+	return "in"
+}
+
+// parseEntryTime turns an HH:MM time of a shift day into a timestamp; an empty value means the
+// punch is missing
+func parseEntryTime(date time.Time, value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	minutes, ok := parseClockMinutes(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid time %q, use HH:MM", value)
+	}
+	t := date.Add(time.Duration(minutes) * time.Minute)
+	return &t, nil
+}
+
+// handleTimeEntries lists time entries (GET), lets a manager record the times of a shift
+// (PUT) or delete an entry (DELETE)
+func handleTimeEntries(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		shopID := r.URL.Query().Get("shop_id")
+		month := r.URL.Query().Get("month")
+		if shopID == "" || month == "" {
+			http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+			return
+		}
+		if getMonthNumber(month) == 0 {
+			http.Error(w, "Unknown month", http.StatusBadRequest)
+			return
+		}
+		shop, exists := sessionShop(session, shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+
+		employeeFilter := r.URL.Query().Get("employee_email")
+		if session.Role == "employee" {
+			employeeFilter = session.UserInfo.Email
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"entries": monthTimeEntries(shop.ID, month, parseYearParam(r), employeeFilter),
+		})
+
+	case http.MethodPut:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can record working times", http.StatusForbidden)
+			return
+		}
+
+		var req struct {
+			ID            string `json:"id"` // entry to correct; without it the day's entries are replaced
+			ShopID        string `json:"shop_id"`
+			EmployeeEmail string `json:"employee_email"`
+			Date          string `json:"date"`
+			ClockIn       string `json:"clock_in"`  // HH:MM
+			ClockOut      string `json:"clock_out"` // HH:MM, earlier than clock_in means the next day
+			Note          string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+		shopEmail, _, isEmployee := shopEmployee(shop, req.EmployeeEmail)
+		if !isEmployee {
+			http.Error(w, "Employee not found in this shop", http.StatusNotFound)
+			return
+		}
+		date, err := time.ParseInLocation(dateLayout, req.Date, time.Local)
+		if err != nil {
+			http.Error(w, "Invalid date, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		clockIn, err := parseEntryTime(date, req.ClockIn)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		clockOut, err := parseEntryTime(date, req.ClockOut)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if clockIn == nil && clockOut == nil {
+			http.Error(w, "At least one of clock_in and clock_out is required", http.StatusBadRequest)
+			return
+		}
+		if clockIn != nil && clockOut != nil && !clockOut.After(*clockIn) {
+			next := clockOut.AddDate(0, 0, 1)
+			clockOut = &next
+		}
+		if !requireMonthOpen(w, shop.ID, polishMonths[date.Month()-1], date.Year()) {
+			return
+		}
+
+		now := time.Now()
+		entry := TimeEntry{ID: generateRandomString(12), ShopID: shop.ID, EmployeeEmail: shopEmail, Date: req.Date, CreatedAt: now}
+		if req.ID != "" {
+			found := false
+			for _, existing := range monthTimeEntries(shop.ID, polishMonths[date.Month()-1], date.Year(), shopEmail) {
+				if existing.ID == req.ID && existing.Date == req.Date {
+					entry = existing
+					found = true
+				}
+			}
+			if !found {
+				http.Error(w, "Time entry not found for this employee and date", http.StatusNotFound)
+				return
+			}
+		}
+		entry.ClockIn = clockIn
+		entry.ClockOut = clockOut
+		entry.Source = timeSourceManager
+		entry.RecordedBy = session.UserInfo.Email
+		entry.Note = strings.TrimSpace(req.Note)
+		entry.UpdatedAt = now
+		if req.ID != "" {
+			storeTimeEntry(entry)
+		} else {
+			// Without an entry to correct, the manager's times replace everything clocked that
+			// day, so no part of a split shift is counted twice
+			replaceDayTimeEntries(entry)
+		}
+
+		recordAudit(AuditEntry{
+			ShopID:  shop.ID,
+			Action:  "time_entry_recorded",
+			Actor:   session.UserInfo.Email,
+			Message: fmt.Sprintf("Recorded working time of %s on %s: %s-%s", shopEmail, req.Date, req.ClockIn, req.ClockOut),
+			RefID:   entry.ID,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "Working time recorded",
+			"entry":   entry,
+		})
+
+	case http.MethodDelete:
+		if session.Role != "employer" {
+			http.Error(w, "Only employers can delete working times", http.StatusForbidden)
+			return
+		}
+
+		shopID := r.URL.Query().Get("shop_id")
+		id := r.URL.Query().Get("id")
+		shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+		if !exists {
+			http.Error(w, "Shop not found", http.StatusNotFound)
+			return
+		}
+
+		timeEntriesMutex.RLock()
+		var target TimeEntry
+		for _, entry := range timeEntries[shop.ID] {
+			if entry.ID == id {
+				target = entry
+			}
+		}
+		timeEntriesMutex.RUnlock()
+		if target.ID == "" {
+			http.Error(w, "Time entry not found", http.StatusNotFound)
+			return
+		}
+		if date, err := time.ParseInLocation(dateLayout, target.Date, time.Local); err == nil {
+			if !requireMonthOpen(w, shop.ID, polishMonths[date.Month()-1], date.Year()) {
+				return
+			}
+		}
+
+		deleteTimeEntry(shop.ID, id)
+		recordAudit(AuditEntry{
+			ShopID:  shop.ID,
+			Action:  "time_entry_deleted",
+			Actor:   session.UserInfo.Email,
+			Message: fmt.Sprintf("Deleted working time of %s on %s", target.EmployeeEmail, target.Date),
+			RefID:   target.ID,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Time entry deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleTimeReport compares planned and clocked hours of a month. Employees only see their own
+// rows.
+func handleTimeReport(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return
+	}
+	year := parseYearParam(r)
+
+	shop, exists := sessionShop(session, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	spreadsheetID, exists := shop.Spreadsheets[year]
+	if !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+	data, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, month)
+	if err != nil {
+		log.Printf("Error reading schedule data for time report: %v", err)
+		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
+		return
+	}
+
+	planned := parseMonthSchedule(data, shop, month, year)
+	employeeFilter := ""
+	if session.Role == "employee" {
+		employeeFilter = session.UserInfo.Email
+		own := planned[:0]
+		for _, shift := range planned {
+			if normalizeEmail(shift.EmployeeEmail) == normalizeEmail(employeeFilter) {
+				own = append(own, shift)
+			}
+		}
+		planned = own
+	}
+
+	rows, summaries := buildTimeReport(shop, planned, monthTimeEntries(shop.ID, month, year, employeeFilter), time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"shop_id":   shop.ID,
+		"month":     month,
+		"year":      year,
+		"rows":      rows,
+		"employees": summaries,
+	})
+}
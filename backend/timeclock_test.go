@@ -0,0 +1,143 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func testEntry(email, day, clockIn, clockOut string) TimeEntry {
+	at := func(value string) *time.Time {
+		if value == "" {
+			return nil
+		}
+		moment, _ := time.ParseInLocation(dateLayout+" 15:04", day+" "+value, time.Local)
+		return &moment
+	}
+	return TimeEntry{EmployeeEmail: email, Date: day, ClockIn: at(clockIn), ClockOut: at(clockOut)}
+}
+
+func TestTimeReportSeveralEntriesPerDay(t *testing.T) {
+	const email = "anna@example.com"
+	shop := testPayrollShop()
+	now := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name         string
+		planned      []ScheduledShift
+		entries      []TimeEntry
+		rows         int
+		actualHours  float64
+		actualShifts int
+	}{
+		{
+			name:         "one shift clocked in two parts",
+			planned:      []ScheduledShift{testShift(email, "2025-03-03", "08:00", "16:00", 0)},
+			entries:      []TimeEntry{testEntry(email, "2025-03-03", "08:00", "12:00"), testEntry(email, "2025-03-03", "12:30", "16:00")},
+			rows:         1,
+			actualHours:  7.5,
+			actualShifts: 2,
+		},
+		{
+			name: "split shift paired by time",
+			planned: []ScheduledShift{
+				testShift(email, "2025-03-03", "07:00", "11:00", 0),
+				testShift(email, "2025-03-03", "16:00", "20:00", 0),
+			},
+			entries:      []TimeEntry{testEntry(email, "2025-03-03", "15:55", "20:10"), testEntry(email, "2025-03-03", "07:00", "11:00")},
+			rows:         2,
+			actualHours:  8.25,
+			actualShifts: 2,
+		},
+		{
+			name:         "entry far from the planned shift is unplanned",
+			planned:      []ScheduledShift{testShift(email, "2025-03-03", "06:00", "10:00", 0)},
+			entries:      []TimeEntry{testEntry(email, "2025-03-03", "06:00", "10:00"), testEntry(email, "2025-03-03", "18:00", "20:00")},
+			rows:         2,
+			actualHours:  6,
+			actualShifts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, _ := buildTimeReport(shop, test.planned, test.entries, now)
+			if len(rows) != test.rows {
+				t.Fatalf("got %d rows, want %d", len(rows), test.rows)
+			}
+			total := 0.0
+			for _, row := range rows {
+				total += row.ActualHours
+				if row.MissingClockIn || row.MissingClockOut {
+					t.Errorf("%s: reported a missing punch", row.Date)
+				}
+			}
+			if total != test.actualHours {
+				t.Errorf("actual hours = %.2f, want %.2f", total, test.actualHours)
+			}
+			if got := len(actualShifts(test.planned, test.entries)); got != test.actualShifts {
+				t.Errorf("actual shifts = %d, want %d", got, test.actualShifts)
+			}
+		})
+	}
+}
+
+func TestClockPunchConcurrentClockIns(t *testing.T) {
+	shop := testPayrollShop()
+	shop.ID = "clock-punch-test-shop"
+	defer func() {
+		timeEntriesMutex.Lock()
+		delete(timeEntries, shop.ID)
+		timeEntriesMutex.Unlock()
+	}()
+
+	now := time.Date(2025, 3, 10, 8, 0, 0, 0, time.Local)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clockPunch(shop, "anna@example.com", "in", timeSourceKiosk, "anna@example.com", now)
+		}()
+	}
+	wg.Wait()
+
+	if entries := monthTimeEntries(shop.ID, "MARZEC", 2025, ""); len(entries) != 1 {
+		t.Errorf("got %d clock-ins, want 1", len(entries))
+	}
+}
+
+func TestReplaceDayTimeEntries(t *testing.T) {
+	const shopID = "replace-day-test-shop"
+	defer func() {
+		timeEntriesMutex.Lock()
+		delete(timeEntries, shopID)
+		timeEntriesMutex.Unlock()
+	}()
+
+	for i, entry := range []TimeEntry{
+		testEntry("anna@example.com", "2025-03-10", "08:00", "12:00"),
+		testEntry("anna@example.com", "2025-03-10", "16:00", "20:00"),
+		testEntry("anna@example.com", "2025-03-11", "08:00", "16:00"),
+		testEntry("ewa@example.com", "2025-03-10", "08:00", "16:00"),
+	} {
+		entry.ID = string(rune('a' + i))
+		entry.ShopID = shopID
+		storeTimeEntry(entry)
+	}
+
+	correction := testEntry("Anna@example.com", "2025-03-10", "09:00", "17:00")
+	correction.ID = "correction"
+	correction.ShopID = shopID
+	if replaced := replaceDayTimeEntries(correction); len(replaced) != 2 {
+		t.Errorf("replaced %d entries, want both parts of the split shift", len(replaced))
+	}
+
+	entries := monthTimeEntries(shopID, "MARZEC", 2025, "anna@example.com")
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries of anna, want the correction and the next day", len(entries))
+	}
+	if entries[0].ID != "correction" {
+		t.Errorf("entry of 2025-03-10 = %s, want the correction", entries[0].ID)
+	}
+}
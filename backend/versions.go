@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	scheduleVersionsFile        = "schedule_versions_data.json"
+	maxScheduleVersionsPerMonth = 50
+)
+
+// ScheduleVersion is a snapshot of a month sheet taken after the backend wrote to it
+type ScheduleVersion struct {
+	ID           string          `json:"id"`
+	ShopID       string          `json:"shop_id"`
+	Month        string          `json:"month"`
+	Year         int             `json:"year"`
+	Number       int             `json:"number"`
+	Action       string          `json:"action"` // initial | publish | patch | leave | swap | open_shift | revert
+	Actor        string          `json:"actor,omitempty"`
+	RevertedFrom int             `json:"reverted_from,omitempty"`
+	Changes      []CellChange    `json:"changes"`
+	Data         [][]interface{} `json:"data,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+var (
+	scheduleVersions      = make(map[string]map[string][]ScheduleVersion) // shop_id -> YYYY-MM -> versions, oldest first
+	scheduleVersionsMutex sync.RWMutex
+)
+
+func saveScheduleVersionsData() error {
+	scheduleVersionsMutex.RLock()
+	defer scheduleVersionsMutex.RUnlock()
+	return writeJSONFileAtomic(scheduleVersionsFile, scheduleVersions)
+}
+
+func loadScheduleVersionsData() error {
+	scheduleVersionsMutex.Lock()
+	defer scheduleVersionsMutex.Unlock()
+	return readJSONFile(scheduleVersionsFile, &scheduleVersions)
+}
+
+// recordScheduleVersion snapshots a month after a write to its sheet; version carries the
+// month, year, action and actor. The first snapshot of a month also keeps what the sheet held
+// before, so the first write can be reverted as well.
+func recordScheduleVersion(shop Shop, before, after [][]interface{}, version ScheduleVersion) ScheduleVersion {
+	key := monthKey(version.Year, getMonthNumber(version.Month))
+	now := time.Now()
+
+	scheduleVersionsMutex.Lock()
+	if scheduleVersions[shop.ID] == nil {
+		scheduleVersions[shop.ID] = make(map[string][]ScheduleVersion)
+	}
+	list := scheduleVersions[shop.ID][key]
+	if len(list) == 0 && len(before) > 0 {
+		list = append(list, ScheduleVersion{
+			ID:        generateRandomString(12),
+			ShopID:    shop.ID,
+			Month:     version.Month,
+			Year:      version.Year,
+			Number:    1,
+			Action:    "initial",
+			Changes:   []CellChange{},
+			Data:      cloneGrid(before),
+			CreatedAt: now,
+		})
+	}
+
+	version.Number = 1
+	if len(list) > 0 {
+		version.Number = list[len(list)-1].Number + 1
+	}
+	version.ID = generateRandomString(12)
+	version.ShopID = shop.ID
+	version.Changes = diffScheduleGrids(shop, version.Month, version.Year, before, after)
+	version.Data = cloneGrid(after)
+	version.CreatedAt = now
+
+	list = append(list, version)
+	if len(list) > maxScheduleVersionsPerMonth {
+		list = list[len(list)-maxScheduleVersionsPerMonth:]
+	}
+	scheduleVersions[shop.ID][key] = list
+	scheduleVersionsMutex.Unlock()
+
+	go saveScheduleVersionsData()
+
+	recordCorrection(shop.ID, version)
+	return version
+}
+
+func getScheduleVersion(shopID, month string, year, number int) (ScheduleVersion, bool) {
+	scheduleVersionsMutex.RLock()
+	defer scheduleVersionsMutex.RUnlock()
+	for _, version := range scheduleVersions[shopID][monthKey(year, getMonthNumber(month))] {
+		if version.Number == number {
+			return version, true
+		}
+	}
+	return ScheduleVersion{}, false
+}
+
+// padGrid extends a grid with empty cells to at least the given size, so writing it over a
+// larger range clears the cells outside it
+func padGrid(data [][]interface{}, rows, columns int) [][]interface{} {
+	for len(data) < rows {
+		data = append(data, []interface{}{})
+	}
+	width := columns
+	for _, row := range data {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	for index := range data {
+		for len(data[index]) < width {
+			data[index] = append(data[index], "")
+		}
+	}
+	return data
+}
+
+func gridSize(data [][]interface{}) (int, int) {
+	columns := 0
+	for _, row := range data {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	return len(data), columns
+}
+
+// versionParams reads the shop, month and year every versions endpoint needs
+func versionParams(w http.ResponseWriter, r *http.Request, session Session) (Shop, string, int, bool) {
+	shopID := r.URL.Query().Get("shop_id")
+	month := r.URL.Query().Get("month")
+	if shopID == "" || month == "" {
+		http.Error(w, "Month and shop ID parameters are required", http.StatusBadRequest)
+		return Shop{}, "", 0, false
+	}
+	if getMonthNumber(month) == 0 {
+		http.Error(w, "Unknown month", http.StatusBadRequest)
+		return Shop{}, "", 0, false
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, shopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return Shop{}, "", 0, false
+	}
+	return shop, month, parseYearParam(r), true
+}
+
+func handleScheduleVersions(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view schedule history", http.StatusForbidden)
+		return
+	}
+
+	shop, month, year, ok := versionParams(w, r, session)
+	if !ok {
+		return
+	}
+
+	// Newest first, without the grids themselves
+	scheduleVersionsMutex.RLock()
+	list := scheduleVersions[shop.ID][monthKey(year, getMonthNumber(month))]
+	versions := make([]ScheduleVersion, 0, len(list))
+	for index := len(list) - 1; index >= 0; index-- {
+		version := list[index]
+		version.Data = nil
+		versions = append(versions, version)
+	}
+	scheduleVersionsMutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"versions": versions})
+}
+
+func handleScheduleVersionDiff(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can view schedule history", http.StatusForbidden)
+		return
+	}
+
+	shop, month, year, ok := versionParams(w, r, session)
+	if !ok {
+		return
+	}
+
+	from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+	to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+	if fromErr != nil || toErr != nil {
+		http.Error(w, "Version numbers from and to are required", http.StatusBadRequest)
+		return
+	}
+	fromVersion, fromExists := getScheduleVersion(shop.ID, month, year, from)
+	toVersion, toExists := getScheduleVersion(shop.ID, month, year, to)
+	if !fromExists || !toExists {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"changes": diffScheduleGrids(shop, month, year, fromVersion.Data, toVersion.Data),
+	})
+}
+
+func handleRevertSchedule(w http.ResponseWriter, r *http.Request) {
+	enableCors(&w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := requireSession(w, r)
+	if !ok {
+		return
+	}
+
+	if session.Role != "employer" {
+		http.Error(w, "Only employers can revert schedules", http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		ShopID  string `json:"shop_id"`
+		Month   string `json:"month"`
+		Year    int    `json:"year"`
+		Version int    `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+
+	shop, exists := getEmployerShop(session.UserInfo.Email, req.ShopID)
+	if !exists {
+		http.Error(w, "Shop not found", http.StatusNotFound)
+		return
+	}
+	target, exists := getScheduleVersion(shop.ID, req.Month, req.Year, req.Version)
+	if !exists || len(target.Data) == 0 {
+		http.Error(w, "Version not found", http.StatusNotFound)
+		return
+	}
+	spreadsheetID, exists := shop.Spreadsheets[req.Year]
+	if !exists {
+		http.Error(w, fmt.Sprintf("No spreadsheet found for year %d", req.Year), http.StatusNotFound)
+		return
+	}
+
+	spreadsheetService, err := getOrCreateSpreadsheetService(session.UserInfo.Email, session.Token)
+	if err != nil {
+		log.Printf("Error getting spreadsheet service: %v", err)
+		http.Error(w, "Failed to initialize services", http.StatusInternalServerError)
+		return
+	}
+
+	unlock := lockScheduleMonth(shop.ID, req.Month, req.Year)
+	defer unlock()
+
+	if !requireMonthOpen(w, shop.ID, req.Month, req.Year) {
+		return
+	}
+
+	current, err := spreadsheetService.ReadMonthSchedule(r.Context(), spreadsheetID, req.Month)
+	if err != nil {
+		log.Printf("Error reading %s before revert: %v", req.Month, err)
+		http.Error(w, "Failed to read schedule data", http.StatusInternalServerError)
+		return
+	}
+
+	// Cells the current sheet has beyond the old version are cleared
+	rows, columns := gridSize(current)
+	data := padGrid(cloneGrid(target.Data), rows, columns)
+	data, shifts, err := saveMonthSchedule(r.Context(), spreadsheetService, shop, spreadsheetID, req.Month, req.Year, data)
+	if err != nil {
+		log.Printf("Error reverting %s %d for shop %s: %v", req.Month, req.Year, shop.ID, err)
+		http.Error(w, fmt.Sprintf("Failed to revert schedule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	version := recordScheduleVersion(shop, current, data, ScheduleVersion{
+		Month:        req.Month,
+		Year:         req.Year,
+		Action:       "revert",
+		Actor:        session.UserInfo.Email,
+		RevertedFrom: target.Number,
+	})
+
+	recordAudit(AuditEntry{
+		ShopID:  shop.ID,
+		Action:  "schedule_reverted",
+		Actor:   session.UserInfo.Email,
+		Message: fmt.Sprintf("Reverted %s %d to version %d", req.Month, req.Year, target.Number),
+		RefID:   version.ID,
+		Changes: version.Changes,
+	})
+
+	log.Printf("Reverted %s %d for shop %s to version %d by %s", req.Month, req.Year, shop.ID, target.Number, session.UserInfo.Email)
+	version.Data = nil
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":   fmt.Sprintf("Schedule reverted to version %d", target.Number),
+		"version":   version,
+		"data":      data,
+		"conflicts": findShiftConflicts(session.UserInfo.Email, shop, shifts),
+		"warnings":  append(availabilityWarnings(shop, shifts), checkCompliance(shop, req.Month, req.Year, shifts)...),
+	})
+}
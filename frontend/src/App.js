@@ -2,6 +2,7 @@ import React, { useState, useEffect } from 'react';
 import axios from 'axios';
 import EmployerDashboard from './components/EmployerDashboard';
 import EmployeeDashboard from './components/EmployeeDashboard';
+import KioskScreen from './components/KioskScreen';
 
 axios.defaults.withCredentials = true;
 
@@ -11,8 +12,34 @@ function App() {
   const [user, setUser] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
+  const [kioskMessage, setKioskMessage] = useState(null);
+  const [kioskToken, setKioskToken] = useState(() => localStorage.getItem('kiosk_token'));
 
   useEffect(() => {
+    // The shop's kiosk device is opened once with ?kiosk_token= and shows codes without a login
+    const tokenParams = new URLSearchParams(window.location.search);
+    const token = tokenParams.get('kiosk_token');
+    if (token) {
+      localStorage.setItem('kiosk_token', token);
+      setKioskToken(token);
+      window.history.replaceState(null, '', window.location.pathname);
+      setLoading(false);
+      return;
+    }
+    if (localStorage.getItem('kiosk_token')) {
+      setLoading(false);
+      return;
+    }
+
+    // A scanned kiosk QR code opens the app with ?kiosk_code=, keep it across the Google login
+    const params = new URLSearchParams(window.location.search);
+    const kioskCode = params.get('kiosk_code');
+    if (kioskCode) {
+      sessionStorage.setItem('kiosk_code', kioskCode);
+      params.delete('kiosk_code');
+      const query = params.toString();
+      window.history.replaceState(null, '', window.location.pathname + (query ? `?${query}` : ''));
+    }
     checkAuthStatus();
   }, []);
 
@@ -21,6 +48,9 @@ function App() {
       const response = await axios.get(`${API_BASE_URL}/user`);
       setUser(response.data);
       setError(null);
+      if (response.data.role === 'employee') {
+        submitKioskScan();
+      }
     } catch (error) {
       console.log('Not authenticated');
       setUser(null);
@@ -29,6 +59,21 @@ function App() {
     }
   };
 
+  const submitKioskScan = async () => {
+    const code = sessionStorage.getItem('kiosk_code');
+    if (!code) {
+      return;
+    }
+    sessionStorage.removeItem('kiosk_code');
+    try {
+      const response = await axios.post(`${API_BASE_URL}/api/kiosk/scan`, { code });
+      setKioskMessage(response.data.message);
+    } catch (error) {
+      console.error('Kiosk scan failed:', error);
+      setError('Kiosk scan failed: ' + (error.response?.data || error.message));
+    }
+  };
+
   const handleLogin = () => {
     window.location.href = `${API_BASE_URL}/auth/google`;
   };
@@ -55,6 +100,14 @@ function App() {
     );
   }
 
+  if (kioskToken) {
+    return (
+      <div className="min-h-screen bg-gradient-to-br from-indigo-500 via-purple-500 to-pink-500 flex items-center justify-center">
+        <KioskScreen kioskToken={kioskToken} />
+      </div>
+    );
+  }
+
   return (
     <div className="min-h-screen bg-gradient-to-br from-indigo-500 via-purple-500 to-pink-500">
       <div className="min-h-screen flex flex-col items-center justify-start p-5 text-black">
@@ -91,6 +144,14 @@ function App() {
               </button>
             </div>
 
+            {/* Kiosk Scan Result */}
+            {kioskMessage && (
+              <div className="bg-green-500/90 text-white p-4 rounded-xl mb-6 flex items-center space-x-3">
+                <span className="text-xl">⏱️</span>
+                <span>{kioskMessage}</span>
+              </div>
+            )}
+
             {/* Error Message */}
             {error && (
               <div className="bg-red-500/90 text-white p-4 rounded-xl mb-6 flex items-center space-x-3">
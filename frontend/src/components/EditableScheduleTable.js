@@ -1,4 +1,32 @@
-import React, { useState, useEffect, useCallback } from 'react';
+import React, { useState, useEffect, useCallback, useMemo } from 'react';
+
+// Day of month from a "Poniedziałek 1" style cell
+const parseDay = (value) => {
+  const parts = String(value || '').trim().split(/\s+/);
+  const day = parseInt(parts[parts.length - 1], 10);
+  return Number.isNaN(day) ? null : day;
+};
+
+// Start and end of a "HH:MM-HH:MM" cell in minutes after midnight, null for anything else
+const shiftMinutes = (value) => {
+  const match = String(value || '').match(/(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})/);
+  if (!match) return null;
+  const start = parseInt(match[1], 10) * 60 + parseInt(match[2], 10);
+  let end = parseInt(match[3], 10) * 60 + parseInt(match[4], 10);
+  if (end <= start) end += 24 * 60;
+  return { start, end };
+};
+
+// An availability entry blocks a shift when its window overlaps the shift's times
+const overlapsShift = (entry, shift) => {
+  if (!entry.start) return true;
+  const blocked = shiftMinutes(`${entry.start}-${entry.end}`);
+  return blocked && shift.start < blocked.end && blocked.start < shift.end;
+};
+
+const describeAvailability = (entries) => entries
+  .map(entry => `${entry.status} ${entry.start ? `${entry.start}-${entry.end}` : 'whole day'}${entry.note ? `: ${entry.note}` : ''}`)
+  .join('\n');
 
 const EditableScheduleTable = ({ 
   data, 
@@ -6,7 +34,8 @@ const EditableScheduleTable = ({
   availableTags, 
   readOnly, 
   onChange, 
-  calculateHours 
+  calculateHours,
+  availability
 }) => {
   const [tableData, setTableData] = useState(data);
   const [editingCell, setEditingCell] = useState(null);
@@ -40,7 +69,7 @@ const EditableScheduleTable = ({
         if (newData[rowIndex] && newData[rowIndex][0] && newData[rowIndex][0] !== '') {
           const schedule = newData[rowIndex][empIndex + 1];
           if (schedule) {
-            totalHours += calculateHours(schedule);
+            totalHours += calculateHours(schedule, parseDay(newData[rowIndex][0]));
           }
         }
       }
@@ -121,6 +150,25 @@ const EditableScheduleTable = ({
     return Object.values(employees).map(emp => emp.name.toUpperCase());
   };
 
+  // email -> day of month -> availability entries submitted by the employee
+  const availabilityByDay = useMemo(() => {
+    const byDay = {};
+    Object.entries(availability || {}).forEach(([email, days]) => {
+      byDay[email] = {};
+      days.forEach(day => {
+        byDay[email][parseInt(day.date.slice(8), 10)] = day.entries;
+      });
+    });
+    return byDay;
+  }, [availability]);
+
+  const availabilityForCell = (rowIndex, cellIndex) => {
+    const email = Object.keys(employees)[cellIndex - 1];
+    const day = parseDay(tableData[rowIndex]?.[0]);
+    if (!email || day === null) return [];
+    return availabilityByDay[email]?.[day] || [];
+  };
+
   const renderCell = (rowIndex, cellIndex, cellValue) => {
     const isEditing = editingCell?.row === rowIndex && editingCell?.cell === cellIndex;
     const isFirstColumn = cellIndex === 0;
@@ -197,6 +245,29 @@ const EditableScheduleTable = ({
       }
     }
 
+    // Availability the employee submitted for this day, shown while planning
+    const isEmployeeCell = !isFirstColumn && !isHeaderRow && !isSummaryRow && !isTagsColumn;
+    const dayAvailability = isEmployeeCell ? availabilityForCell(rowIndex, cellIndex) : [];
+    const unavailable = dayAvailability.filter(entry => entry.status === 'unavailable');
+    const shift = shiftMinutes(cellValue);
+    const conflict = shift && unavailable.some(entry => overlapsShift(entry, shift));
+    if (conflict) {
+      contentClasses += " ring-2 ring-inset ring-red-500";
+    }
+    let availabilityMarker = null;
+    if (dayAvailability.length > 0) {
+      const marker = unavailable.length > 0
+        ? { symbol: '✕', classes: 'text-red-600' }
+        : dayAvailability.some(entry => entry.status === 'preferred')
+        ? { symbol: '★', classes: 'text-amber-500' }
+        : { symbol: '✓', classes: 'text-green-600' };
+      availabilityMarker = (
+        <span className={`absolute top-0 right-1 text-xs font-bold ${marker.classes}`}>
+          {marker.symbol}
+        </span>
+      );
+    }
+
     // Handle tags display
     if (isTagsColumn && cellValue) {
       displayValue = (
@@ -231,7 +302,9 @@ const EditableScheduleTable = ({
         key={cellIndex} 
         className={cellClasses}
         onClick={() => handleCellClick(rowIndex, cellIndex)}
+        title={dayAvailability.length > 0 ? describeAvailability(dayAvailability) : undefined}
       >
+        {availabilityMarker}
         <div className={contentClasses}>
           {isSummaryRow && cellIndex > 0 && cellIndex <= getEmployeeNames().length ? (
             <strong>{displayValue}</strong>
@@ -315,6 +388,14 @@ const EditableScheduleTable = ({
               <div className="w-4 h-4 bg-green-100 border-2 border-green-400 rounded"></div>
               <span className="text-gray-700">Work Schedule (HH:MM-HH:MM)</span>
             </div>
+            {availability && (
+              <div className="flex items-center gap-2">
+                <span className="text-xs font-bold text-red-600">✕</span>
+                <span className="text-xs font-bold text-amber-500">★</span>
+                <span className="text-xs font-bold text-green-600">✓</span>
+                <span className="text-gray-700">Unavailable / preferred / available (hover for details)</span>
+              </div>
+            )}
             <div className="flex items-center gap-2">
               <div className="flex gap-1">
                 <div className="w-3 h-3 bg-yellow-100 border border-yellow-400 rounded-full"></div>
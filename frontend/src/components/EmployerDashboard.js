@@ -3,6 +3,7 @@ import axios from 'axios';
 import ScheduleCalendar from './ScheduleCalendar';
 import EmployeeManagement from './EmployeeManagement';
 import ShopManagement from './ShopManagement';
+import KioskScreen from './KioskScreen';
 
 const API_BASE_URL = 'http://localhost:8080';
 
@@ -122,6 +123,7 @@ const EmployerDashboard = ({ user }) => {
   // Determine which tabs should be visible
   const showScheduleTab = shops.length > 0 && selectedShop;
   const showEmployeesTab = shops.length > 0 && selectedShop;
+  const showKioskTab = shops.length > 0 && selectedShop;
   const showShopsTab = true; // Always show shops tab
 
   return (
@@ -266,6 +268,22 @@ const EmployerDashboard = ({ user }) => {
             </button>
           )}
           
+          {showKioskTab && (
+            <button 
+              onClick={() => handleTabChange('kiosk')}
+              className={`
+                flex-1 px-6 py-4 text-sm font-medium transition-all duration-200 border-b-2 flex items-center justify-center space-x-2
+                ${activeTab === 'kiosk' 
+                  ? 'text-white bg-white/10 border-yellow-400' 
+                  : 'text-white/70 hover:text-white hover:bg-white/5 border-transparent'
+                }
+              `}
+            >
+              <span className="text-lg">⏱️</span>
+              <span>Kiosk</span>
+            </button>
+          )}
+          
           {showShopsTab && (
             <button 
               onClick={() => handleTabChange('shops')}
@@ -369,6 +387,14 @@ const EmployerDashboard = ({ user }) => {
             </div>
           )}
 
+          {activeTab === 'kiosk' && selectedShop && (
+            <KioskScreen 
+              key={selectedShop.id}
+              shopId={selectedShop.id}
+              shopName={selectedShop.name}
+            />
+          )}
+
           {activeTab === 'shops' && (
             <div>
               {shops.length === 0 && (
@@ -0,0 +1,206 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import axios from 'axios';
+import { qrMatrix } from '../qrcode';
+
+const API_BASE_URL = 'http://localhost:8080';
+
+// QR code drawn as one SVG path, with the 4 module quiet zone around it
+const QRCode = ({ text, size = 320 }) => {
+  const modules = qrMatrix(text);
+  const count = modules.length + 8;
+  let path = '';
+  modules.forEach((row, r) => row.forEach((dark, c) => {
+    if (dark) path += `M${c + 4} ${r + 4}h1v1h-1z`;
+  }));
+
+  return (
+    <svg
+      viewBox={`0 0 ${count} ${count}`}
+      width={size}
+      height={size}
+      shapeRendering="crispEdges"
+      className="rounded-xl"
+    >
+      <rect width={count} height={count} fill="#ffffff" />
+      <path d={path} fill="#000000" />
+    </svg>
+  );
+};
+
+// With a kioskToken the screen runs on the shop's own device, which can only fetch codes.
+// Without one an employer previews the kiosk and manages the devices of the shop.
+const KioskScreen = ({ shopId, shopName, kioskToken }) => {
+  const [kiosk, setKiosk] = useState(null);
+  const [kioskOnly, setKioskOnly] = useState(false);
+  const [secondsLeft, setSecondsLeft] = useState(0);
+  const [error, setError] = useState(null);
+  const [devices, setDevices] = useState([]);
+  const [deviceName, setDeviceName] = useState('');
+  const [newDeviceUrl, setNewDeviceUrl] = useState(null);
+
+  const fetchCode = useCallback(async () => {
+    try {
+      const response = kioskToken
+        ? await axios.get(`${API_BASE_URL}/api/kiosk/code`, {
+            withCredentials: false,
+            headers: { Authorization: `Kiosk ${kioskToken}` },
+          })
+        : await axios.get(`${API_BASE_URL}/api/kiosk/code?shop_id=${shopId}`);
+      setKiosk(response.data);
+      setSecondsLeft(response.data.refresh_in);
+      setError(null);
+    } catch (error) {
+      console.error('Failed to fetch kiosk code:', error);
+      setError('Failed to fetch kiosk code: ' + (error.response?.data || error.message));
+      setSecondsLeft(10);
+    }
+  }, [shopId, kioskToken]);
+
+  const fetchDevices = useCallback(async () => {
+    try {
+      const response = await axios.get(`${API_BASE_URL}/api/kiosk/tokens?shop_id=${shopId}`);
+      setDevices(response.data.tokens || []);
+    } catch (error) {
+      console.error('Failed to fetch kiosk devices:', error);
+    }
+  }, [shopId]);
+
+  useEffect(() => {
+    fetchCode();
+    if (kioskToken) {
+      return;
+    }
+    fetchDevices();
+    axios.get(`${API_BASE_URL}/api/shops/settings?shop_id=${shopId}`)
+      .then(response => setKioskOnly(!!response.data.kiosk_only))
+      .catch(error => console.error('Failed to fetch shop settings:', error));
+  }, [shopId, kioskToken, fetchCode, fetchDevices]);
+
+  // Count down to the next code and fetch it when the current one expires
+  useEffect(() => {
+    const timer = setTimeout(() => {
+      if (secondsLeft <= 1) {
+        fetchCode();
+      } else {
+        setSecondsLeft(secondsLeft - 1);
+      }
+    }, 1000);
+    return () => clearTimeout(timer);
+  }, [secondsLeft, fetchCode]);
+
+  const toggleKioskOnly = async () => {
+    try {
+      await axios.put(`${API_BASE_URL}/api/shops/settings?shop_id=${shopId}`, { kiosk_only: !kioskOnly });
+      setKioskOnly(!kioskOnly);
+    } catch (error) {
+      console.error('Failed to update shop settings:', error);
+      setError('Failed to update shop settings: ' + (error.response?.data || error.message));
+    }
+  };
+
+  const addDevice = async () => {
+    try {
+      const response = await axios.post(`${API_BASE_URL}/api/kiosk/tokens?shop_id=${shopId}`, { name: deviceName });
+      setNewDeviceUrl(response.data.kiosk_url);
+      setDeviceName('');
+      fetchDevices();
+    } catch (error) {
+      console.error('Failed to add kiosk device:', error);
+      setError('Failed to add kiosk device: ' + (error.response?.data || error.message));
+    }
+  };
+
+  const revokeDevice = async (id) => {
+    try {
+      await axios.delete(`${API_BASE_URL}/api/kiosk/tokens?shop_id=${shopId}&id=${id}`);
+      fetchDevices();
+    } catch (error) {
+      console.error('Failed to revoke kiosk device:', error);
+      setError('Failed to revoke kiosk device: ' + (error.response?.data || error.message));
+    }
+  };
+
+  return (
+    <div className="flex flex-col items-center space-y-6 py-6">
+      <div className="text-center">
+        <h3 className="text-2xl font-bold text-white mb-2">{kiosk?.shop_name || shopName}</h3>
+        <p className="text-white/80">Scan the code with your phone to clock in or out</p>
+      </div>
+
+      {error && (
+        <div className="bg-red-500/90 text-white p-4 rounded-xl flex items-center space-x-3">
+          <span className="text-xl">⚠️</span>
+          <span>{error}</span>
+        </div>
+      )}
+
+      {kiosk ? (
+        <div className="bg-white p-4 rounded-2xl shadow-xl">
+          <QRCode text={kiosk.scan_url} />
+        </div>
+      ) : (
+        <div className="w-16 h-16 border-4 border-white/20 border-t-white rounded-full animate-spin"></div>
+      )}
+
+      {kiosk && (
+        <p className="text-white/70 text-sm">
+          New code in {secondsLeft}s
+        </p>
+      )}
+
+      {!kioskToken && (
+        <label className="flex items-center space-x-3 text-white/90 cursor-pointer">
+          <input
+            type="checkbox"
+            checked={kioskOnly}
+            onChange={toggleKioskOnly}
+            className="w-4 h-4"
+          />
+          <span>Only accept punches from this kiosk</span>
+        </label>
+      )}
+
+      {!kioskToken && (
+        <div className="w-full max-w-xl bg-white/10 rounded-xl p-4 space-y-3">
+          <h4 className="text-lg font-semibold text-white">Kiosk devices</h4>
+          <p className="text-white/70 text-sm">
+            Open the device link on the shop's screen. It can only show codes, no login is needed.
+          </p>
+          <div className="flex space-x-2">
+            <input
+              type="text"
+              value={deviceName}
+              onChange={(e) => setDeviceName(e.target.value)}
+              placeholder="Device name"
+              className="flex-1 px-3 py-2 rounded-lg"
+            />
+            <button
+              onClick={addDevice}
+              className="px-4 py-2 bg-green-500 hover:bg-green-600 text-white font-semibold rounded-lg"
+            >
+              Add device
+            </button>
+          </div>
+          {newDeviceUrl && (
+            <div className="bg-white/90 text-gray-800 p-3 rounded-lg text-sm break-all">
+              Open this link on the device, it is shown only once: {newDeviceUrl}
+            </div>
+          )}
+          {devices.map(device => (
+            <div key={device.id} className="flex items-center justify-between text-white/90">
+              <span>{device.name}</span>
+              <button
+                onClick={() => revokeDevice(device.id)}
+                className="px-3 py-1 bg-red-500 hover:bg-red-600 text-white text-sm rounded-lg"
+              >
+                Revoke
+              </button>
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default KioskScreen;
@@ -5,6 +5,39 @@ import RefreshButton from './RefreshButton';
 
 const API_BASE_URL = 'http://localhost:8080';
 
+const cellText = (row, index) => (row && row[index] != null ? String(row[index]) : '');
+
+// Applies the cells edited locally (local vs base) on top of the server's grid. Cells the
+// server changed too are listed in overlaps; the local value wins.
+const mergeLocalEdits = (base, local, server) => {
+  const merged = JSON.parse(JSON.stringify(server));
+  const overlaps = [];
+
+  local.forEach((row, rowIndex) => {
+    row.forEach((value, colIndex) => {
+      const localValue = cellText(row, colIndex);
+      const baseValue = cellText(base[rowIndex], colIndex);
+      if (localValue === baseValue) return;
+
+      const serverValue = cellText(server[rowIndex], colIndex);
+      if (serverValue !== baseValue && serverValue !== localValue) {
+        overlaps.push(`${cellText(server[rowIndex], 0)} / ${cellText(server[0], colIndex)}: "${serverValue}" -> "${localValue}"`);
+      }
+      while (merged.length <= rowIndex) merged.push([]);
+      while (merged[rowIndex].length <= colIndex) merged[rowIndex].push('');
+      merged[rowIndex][colIndex] = value;
+    });
+  });
+
+  return { merged, overlaps };
+};
+
+// Tabs of the calendar; a month's index is its number
+const months = [
+  'MANAGEMENT', 'STYCZEŃ', 'LUTY', 'MARZEC', 'KWIECIEŃ', 'MAJ', 'CZERWIEC',
+  'LIPIEC', 'SIERPIEŃ', 'WRZESIEŃ', 'PAŹDZIERNIK', 'LISTOPAD', 'GRUDZIEŃ'
+];
+
 const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
   const [selectedYear, setSelectedYear] = useState(new Date().getFullYear());
   const [activeMonth, setActiveMonth] = useState(spreadsheetData?.current_month || 'STYCZEŃ');
@@ -14,6 +47,16 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
   const [loading, setLoading] = useState(false);
   const [saving, setSaving] = useState(false);
   const [hasChanges, setHasChanges] = useState(false);
+  const [isDraft, setIsDraft] = useState(false);
+  const [publication, setPublication] = useState(null);
+  const [publishing, setPublishing] = useState(false);
+  const [monthClose, setMonthClose] = useState(null);
+  // Employee availability for the month, only sent to employers
+  const [availability, setAvailability] = useState(null);
+  // Every version of the shop's shift templates, so cells holding a code count their hours
+  const [shiftTemplates, setShiftTemplates] = useState([]);
+  // Revision of the server grid the local edits started from
+  const [revision, setRevision] = useState('');
   const [availableTags] = useState(['DOSTAWA', 'PROMO', 'AKTUALIZACJA PROMO']);
   const [cachedData, setCachedData] = useState({});
   const [refreshCooldown, setRefreshCooldown] = useState(0);
@@ -21,11 +64,6 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
   // Store unsaved changes for each month
   const [unsavedChanges, setUnsavedChanges] = useState({});
   
-  const months = [
-    'MANAGEMENT', 'STYCZEŃ', 'LUTY', 'MARZEC', 'KWIECIEŃ', 'MAJ', 'CZERWIEC',
-    'LIPIEC', 'SIERPIEŃ', 'WRZESIEŃ', 'PAŹDZIERNIK', 'LISTOPAD', 'GRUDZIEŃ'
-  ];
-
   const availableYears = Array.from({ length: 10 }, (_, i) => new Date().getFullYear() - 5 + i);
 
   // Load cached data and unsaved changes from localStorage
@@ -61,7 +99,7 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
   }, []);
 
   // Save current data as unsaved changes
-  const saveUnsavedChanges = useCallback((shopId, year, month, data, originalData, employees) => {
+  const saveUnsavedChanges = useCallback((shopId, year, month, data, originalData, employees, revision) => {
     const key = getUnsavedChangesKey(shopId, year, month);
     setUnsavedChanges(prev => ({
       ...prev,
@@ -69,6 +107,7 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
         data: JSON.parse(JSON.stringify(data)),
         originalData: JSON.parse(JSON.stringify(originalData)),
         employees: JSON.parse(JSON.stringify(employees)),
+        revision,
         timestamp: Date.now(),
         hasChanges: JSON.stringify(data) !== JSON.stringify(originalData)
       }
@@ -86,13 +125,19 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
   }, [getUnsavedChangesKey]);
 
   // Save cached data to localStorage
-  const saveToCache = useCallback((shopId, year, month, data, employees) => {
+  const saveToCache = useCallback((shopId, year, month, data, employees, status = {}) => {
     const cacheKey = `${shopId}-${year}-${month}`;
     const newCache = {
       ...cachedData,
       [cacheKey]: {
         data,
         employees,
+        draft: status.draft || false,
+        published: status.published || null,
+        revision: status.revision || '',
+        monthClose: status.monthClose || null,
+        availability: status.availability || null,
+        shiftTemplates: status.shiftTemplates || [],
         timestamp: Date.now()
       }
     };
@@ -116,6 +161,7 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
       setScheduleData(saved.data);
       setOriginalData(saved.originalData);
       setEmployees(saved.employees);
+      setRevision(saved.revision || '');
       setHasChanges(saved.hasChanges);
       return;
     }
@@ -127,6 +173,12 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
       setScheduleData(cached.data);
       setOriginalData(JSON.parse(JSON.stringify(cached.data)));
       setEmployees(cached.employees);
+      setIsDraft(cached.draft || false);
+      setPublication(cached.published || null);
+      setRevision(cached.revision || '');
+      setMonthClose(cached.monthClose || null);
+      setAvailability(cached.availability || null);
+      setShiftTemplates(cached.shiftTemplates || []);
       setHasChanges(false);
       return;
     }
@@ -136,14 +188,28 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
       const response = await axios.get(`${API_BASE_URL}/api/schedule?month=${month}&year=${year}&shop_id=${shopId}`);
       const data = response.data.data || [];
       const employeesData = response.data.employees || {};
+      const status = {
+        draft: response.data.draft || false,
+        published: response.data.published || null,
+        revision: response.data.revision || '',
+        monthClose: response.data.month_close || null,
+        availability: response.data.availability || null,
+        shiftTemplates: response.data.shift_templates || []
+      };
       
       setScheduleData(data);
       setOriginalData(JSON.parse(JSON.stringify(data)));
       setEmployees(employeesData);
+      setIsDraft(status.draft);
+      setPublication(status.published);
+      setRevision(status.revision);
+      setMonthClose(status.monthClose);
+      setAvailability(status.availability);
+      setShiftTemplates(status.shiftTemplates);
       setHasChanges(false);
       
       // Save to cache
-      saveToCache(shopId, year, month, data, employeesData);
+      saveToCache(shopId, year, month, data, employeesData, status);
       
       // Clear any existing unsaved changes for this month since we got fresh data
       if (forceRefresh) {
@@ -223,10 +289,11 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
         activeMonth, 
         newData, 
         originalData, 
-        employees
+        employees,
+        revision
       );
     }
-  }, [originalData, spreadsheetData?.shop_id, selectedYear, activeMonth, employees, saveUnsavedChanges]);
+  }, [originalData, spreadsheetData?.shop_id, selectedYear, activeMonth, employees, saveUnsavedChanges, revision]);
 
   // Handle month change with unsaved data preservation
   const handleMonthChange = useCallback((newMonth) => {
@@ -239,12 +306,13 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
           activeMonth, 
           scheduleData, 
           originalData, 
-          employees
+          employees,
+          revision
         );
       }
     }
     setActiveMonth(newMonth);
-  }, [hasChanges, readOnly, activeMonth, spreadsheetData?.shop_id, selectedYear, scheduleData, originalData, employees, saveUnsavedChanges]);
+  }, [hasChanges, readOnly, activeMonth, spreadsheetData?.shop_id, selectedYear, scheduleData, originalData, employees, saveUnsavedChanges, revision]);
 
   // Memoize the refresh function to prevent unnecessary re-renders
   const handleRefresh = useCallback(() => {
@@ -280,29 +348,88 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
         month: activeMonth,
         year: selectedYear,
         shop_id: spreadsheetData.shop_id,
-        data: scheduleData
+        data: scheduleData,
+        revision
       });
 
       console.log('Save response:', response.data);
 
-      const newOriginalData = JSON.parse(JSON.stringify(scheduleData));
-      setOriginalData(newOriginalData);
+      const savedData = response.data.data || scheduleData;
+      setScheduleData(savedData);
+      setOriginalData(JSON.parse(JSON.stringify(savedData)));
+      setRevision(response.data.revision);
       setHasChanges(false);
+      setIsDraft(true);
       
       // Update cache with saved data
-      saveToCache(spreadsheetData.shop_id, selectedYear, activeMonth, scheduleData, employees);
+      saveToCache(spreadsheetData.shop_id, selectedYear, activeMonth, savedData, employees, { draft: true, published: publication, revision: response.data.revision, availability, shiftTemplates });
       
       // Clear unsaved changes since we just saved
       clearUnsavedChanges(spreadsheetData.shop_id, selectedYear, activeMonth);
       
-      alert('Schedule saved successfully!');
+      alert('Draft saved. Publish it to make it visible to employees.');
     } catch (error) {
+      if (error.response?.status === 409 && error.response.data?.data) {
+        // Someone else saved this month meanwhile: keep our edits on top of their version
+        const serverData = error.response.data.data;
+        const { merged, overlaps } = mergeLocalEdits(originalData, scheduleData, serverData);
+        setScheduleData(merged);
+        setOriginalData(JSON.parse(JSON.stringify(serverData)));
+        setRevision(error.response.data.revision);
+        setHasChanges(true);
+        saveUnsavedChanges(spreadsheetData.shop_id, selectedYear, activeMonth, merged, serverData, employees, error.response.data.revision);
+
+        const details = overlaps.length > 0
+          ? `\n\nCells changed by both (your value was kept):\n${overlaps.join('\n')}`
+          : '';
+        alert(`Someone else changed this month since you loaded it. Your edits were applied to the latest version - review them and save again.${details}`);
+        return;
+      }
       console.error('Failed to save schedule:', error);
       alert(`Failed to save schedule: ${error.response?.data?.message || error.message}`);
     } finally {
       setSaving(false);
     }
-  }, [hasChanges, readOnly, activeMonth, spreadsheetData?.shop_id, selectedYear, scheduleData, saveToCache, employees, clearUnsavedChanges]);
+  }, [hasChanges, readOnly, activeMonth, spreadsheetData?.shop_id, selectedYear, scheduleData, originalData, revision, saveToCache, saveUnsavedChanges, employees, clearUnsavedChanges, publication, availability, shiftTemplates]);
+
+  const handlePublish = useCallback(async () => {
+    if (!isDraft || hasChanges || readOnly || !spreadsheetData?.shop_id) return;
+
+    setPublishing(true);
+    try {
+      const publish = (force) => axios.post(`${API_BASE_URL}/api/schedule/publish`, {
+        month: activeMonth,
+        year: selectedYear,
+        shop_id: spreadsheetData.shop_id,
+        force
+      });
+
+      let response;
+      try {
+        response = await publish(false);
+      } catch (error) {
+        if (error.response?.status !== 409) throw error;
+        const conflicts = error.response.data.conflicts || [];
+        const summary = conflicts.map(c => `${c.date} ${c.employee_email || c.column}: sheet "${c.before}", draft "${c.after}"`).join('\n');
+        if (!window.confirm(`The published schedule changed the same cells:\n${summary}\n\nPublish the draft anyway?`)) return;
+        response = await publish(true);
+      }
+
+      const publishedData = response.data.data || scheduleData;
+      setScheduleData(publishedData);
+      setOriginalData(JSON.parse(JSON.stringify(publishedData)));
+      setRevision(response.data.revision);
+      setIsDraft(false);
+      setPublication(response.data.publication);
+      saveToCache(spreadsheetData.shop_id, selectedYear, activeMonth, publishedData, employees, { draft: false, published: response.data.publication, revision: response.data.revision, availability, shiftTemplates });
+      alert(`Schedule published (${response.data.changes?.length || 0} changed cells).`);
+    } catch (error) {
+      console.error('Failed to publish schedule:', error);
+      alert(`Failed to publish schedule: ${error.response?.data?.error || error.message}`);
+    } finally {
+      setPublishing(false);
+    }
+  }, [isDraft, hasChanges, readOnly, spreadsheetData?.shop_id, activeMonth, selectedYear, scheduleData, employees, saveToCache, availability, shiftTemplates]);
 
   const handleDiscard = useCallback(() => {
     if (!hasChanges) return;
@@ -326,10 +453,27 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
     alert('Cache and unsaved changes cleared successfully!');
   }, []);
 
-  const calculateHours = useCallback((timeRange) => {
+  // Hours of a cell on a day of the month: explicit HH:MM-HH:MM times, or a shift template code
+  // resolved to the version valid on that day, less its unpaid break
+  const calculateHours = useCallback((timeRange, day) => {
     if (!timeRange || timeRange === 'DW' || timeRange === '') return 0;
-    
-    const match = timeRange.match(/(\d{1,2}):(\d{2})-(\d{1,2}):(\d{2})/);
+
+    let value = String(timeRange);
+    let breakMinutes = 0;
+    if (!/\d{1,2}:\d{2}-\d{1,2}:\d{2}/.test(value)) {
+      const code = value.trim().toUpperCase();
+      const monthNumber = String(months.indexOf(activeMonth)).padStart(2, '0');
+      const date = `${selectedYear}-${monthNumber}-${String(day || 1).padStart(2, '0')}`;
+      const template = shiftTemplates
+        .filter(t => t.code === code && t.effective_from <= date)
+        .sort((a, b) => a.effective_from.localeCompare(b.effective_from))
+        .pop();
+      if (!template || template.retired) return 0;
+      value = `${template.start}-${template.end}`;
+      breakMinutes = template.break_minutes || 0;
+    }
+
+    const match = value.match(/(\d{1,2}):(\d{2})-(\d{1,2}):(\d{2})/);
     if (!match) return 0;
 
     const startHour = parseInt(match[1]);
@@ -344,8 +488,8 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
       endTime += 24;
     }
 
-    return endTime - startTime;
-  }, []);
+    return endTime - startTime - breakMinutes / 60;
+  }, [shiftTemplates, activeMonth, selectedYear]);
 
   // Check if current month has unsaved changes
   const currentMonthHasUnsavedChanges = useCallback((month) => {
@@ -490,9 +634,22 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
                 <span className="text-sm text-orange-600 font-medium">Unsaved changes (stored locally)</span>
               </div>
             )}
+            {isDraft && !readOnly && (
+              <p className="text-sm text-purple-600 font-medium mt-1">Draft - not visible to employees until published</p>
+            )}
+            {monthClose?.locked && (
+              <p className="text-sm text-red-600 font-medium mt-1">
+                Closed for payroll {new Date(monthClose.closed_at).toLocaleString()} by {monthClose.closed_by} - reopen the month to change it
+              </p>
+            )}
+            {publication && (
+              <p className="text-sm text-gray-500 mt-1">
+                Published {new Date(publication.published_at).toLocaleString()} by {publication.published_by}
+              </p>
+            )}
           </div>
           <div className="flex items-center gap-3">
-            {!readOnly && (
+            {!readOnly && !monthClose?.locked && (
               <>
                 <button 
                   className="px-4 py-2 text-sm bg-gray-500 hover:bg-gray-600 text-white rounded-lg transition-colors duration-200 disabled:opacity-50 font-medium"
@@ -506,7 +663,14 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
                   onClick={handleSave}
                   disabled={!hasChanges || saving}
                 >
-                  {saving ? 'Saving...' : 'Save Schedule'}
+                  {saving ? 'Saving...' : 'Save Draft'}
+                </button>
+                <button 
+                  className="px-4 py-2 text-sm bg-purple-500 hover:bg-purple-600 text-white rounded-lg transition-colors duration-200 disabled:opacity-50 font-medium"
+                  onClick={handlePublish}
+                  disabled={!isDraft || hasChanges || publishing}
+                >
+                  {publishing ? 'Publishing...' : 'Publish'}
                 </button>
               </>
             )}
@@ -533,9 +697,10 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
           data={scheduleData}
           employees={employees}
           availableTags={availableTags}
-          readOnly={readOnly}
+          readOnly={readOnly || monthClose?.locked}
           onChange={handleDataChange}
           calculateHours={calculateHours}
+          availability={readOnly ? null : availability}
         />
 
         <div className="grid grid-cols-1 lg:grid-cols-2 gap-6">
@@ -558,6 +723,12 @@ const ScheduleCalendar = ({ spreadsheetData, onRefresh, readOnly }) => {
                 <span className="text-blue-500 mt-1">•</span>
                 Add tags like DOSTAWA, PROMO in the tags column
               </li>
+              {!readOnly && (
+                <li className="flex items-start gap-2">
+                  <span className="text-blue-500 mt-1">•</span>
+                  Marks in a cell corner show the employee's availability; a red outline is a shift on unavailable time
+                </li>
+              )}
               {!readOnly && (
                 <li className="flex items-start gap-2">
                   <span className="text-blue-500 mt-1">•</span>
@@ -0,0 +1,291 @@
+// Minimal QR code encoder for the kiosk screen: byte mode, error correction level M,
+// versions 1-10 (up to 213 bytes). Returns a square matrix of booleans, true for dark modules.
+
+// Error correction blocks of level M per version: [count, total codewords, data codewords]...
+const RS_BLOCKS_M = [
+  null,
+  [1, 26, 16],
+  [1, 44, 28],
+  [1, 70, 44],
+  [2, 50, 32],
+  [2, 67, 43],
+  [4, 43, 27],
+  [4, 49, 31],
+  [2, 60, 38, 2, 61, 39],
+  [3, 58, 36, 2, 59, 37],
+  [4, 69, 43, 1, 70, 44]
+];
+
+const ALIGNMENT_POSITIONS = [
+  null, [], [6, 18], [6, 22], [6, 26], [6, 30], [6, 34],
+  [6, 22, 38], [6, 24, 42], [6, 26, 46], [6, 28, 50]
+];
+
+const LEVEL_M = 0; // format bits of level M
+
+const EXP = new Array(512);
+const LOG = new Array(256);
+(() => {
+  let value = 1;
+  for (let i = 0; i < 255; i++) {
+    EXP[i] = value;
+    LOG[value] = i;
+    value <<= 1;
+    if (value & 0x100) value ^= 0x11d;
+  }
+  for (let i = 255; i < 512; i++) EXP[i] = EXP[i - 255];
+})();
+
+const multiply = (a, b) => (a === 0 || b === 0 ? 0 : EXP[LOG[a] + LOG[b]]);
+
+// Reed-Solomon error correction codewords of a block
+const errorCorrection = (data, count) => {
+  let generator = [1];
+  for (let i = 0; i < count; i++) {
+    const next = new Array(generator.length + 1).fill(0);
+    generator.forEach((coefficient, j) => {
+      next[j] ^= coefficient;
+      next[j + 1] ^= multiply(coefficient, EXP[i]);
+    });
+    generator = next;
+  }
+
+  const remainder = [...data, ...new Array(count).fill(0)];
+  for (let i = 0; i < data.length; i++) {
+    const factor = remainder[i];
+    if (factor === 0) continue;
+    generator.forEach((coefficient, j) => {
+      remainder[i + j] ^= multiply(coefficient, factor);
+    });
+  }
+  return remainder.slice(data.length);
+};
+
+const blocksOf = (version) => {
+  const table = RS_BLOCKS_M[version];
+  const blocks = [];
+  for (let i = 0; i < table.length; i += 3) {
+    for (let n = 0; n < table[i]; n++) {
+      blocks.push({ total: table[i + 1], data: table[i + 2] });
+    }
+  }
+  return blocks;
+};
+
+const utf8Bytes = (text) => Array.from(new TextEncoder().encode(text));
+
+// Data and error correction codewords, interleaved block by block
+const codewords = (bytes, version) => {
+  const blocks = blocksOf(version);
+  const capacity = blocks.reduce((sum, block) => sum + block.data, 0);
+
+  const bits = [];
+  const put = (value, length) => {
+    for (let i = length - 1; i >= 0; i--) bits.push((value >>> i) & 1);
+  };
+  put(0b0100, 4);
+  put(bytes.length, version < 10 ? 8 : 16);
+  bytes.forEach(byte => put(byte, 8));
+  put(0, Math.min(4, capacity * 8 - bits.length));
+  while (bits.length % 8) bits.push(0);
+
+  const data = [];
+  for (let i = 0; i < bits.length; i += 8) {
+    data.push(bits.slice(i, i + 8).reduce((value, bit) => (value << 1) | bit, 0));
+  }
+  for (let pad = 0; data.length < capacity; pad++) data.push(pad % 2 ? 0x11 : 0xec);
+
+  let offset = 0;
+  const split = blocks.map(block => {
+    const blockData = data.slice(offset, offset + block.data);
+    offset += block.data;
+    return { data: blockData, ec: errorCorrection(blockData, block.total - block.data) };
+  });
+
+  const result = [];
+  const interleave = (key) => {
+    const longest = Math.max(...split.map(block => block[key].length));
+    for (let i = 0; i < longest; i++) {
+      split.forEach(block => {
+        if (i < block[key].length) result.push(block[key][i]);
+      });
+    }
+  };
+  interleave('data');
+  interleave('ec');
+  return result;
+};
+
+// BCH code of the format (15 bits) and version (18 bits) information
+const bch = (value, generator, length) => {
+  const degree = (n) => (n === 0 ? 0 : 32 - Math.clz32(n));
+  let remainder = value << (degree(generator) - 1);
+  while (degree(remainder) >= degree(generator)) {
+    remainder ^= generator << (degree(remainder) - degree(generator));
+  }
+  return ((value << (degree(generator) - 1)) | remainder) & ((1 << length) - 1);
+};
+
+const MASKS = [
+  (r, c) => (r + c) % 2 === 0,
+  (r) => r % 2 === 0,
+  (r, c) => c % 3 === 0,
+  (r, c) => (r + c) % 3 === 0,
+  (r, c) => (Math.floor(r / 2) + Math.floor(c / 3)) % 2 === 0,
+  (r, c) => ((r * c) % 2) + ((r * c) % 3) === 0,
+  (r, c) => (((r * c) % 2) + ((r * c) % 3)) % 2 === 0,
+  (r, c) => (((r * c) % 3) + ((r + c) % 2)) % 2 === 0
+];
+
+const buildMatrix = (version, words, mask) => {
+  const size = version * 4 + 17;
+  const modules = Array.from({ length: size }, () => new Array(size).fill(null));
+
+  const finder = (row, col) => {
+    for (let r = -1; r <= 7; r++) {
+      for (let c = -1; c <= 7; c++) {
+        if (row + r < 0 || row + r >= size || col + c < 0 || col + c >= size) continue;
+        modules[row + r][col + c] =
+          (r >= 0 && r <= 6 && (c === 0 || c === 6)) ||
+          (c >= 0 && c <= 6 && (r === 0 || r === 6)) ||
+          (r >= 2 && r <= 4 && c >= 2 && c <= 4);
+      }
+    }
+  };
+  finder(0, 0);
+  finder(size - 7, 0);
+  finder(0, size - 7);
+
+  const positions = ALIGNMENT_POSITIONS[version];
+  positions.forEach(row => positions.forEach(col => {
+    if (modules[row][col] !== null) return;
+    for (let r = -2; r <= 2; r++) {
+      for (let c = -2; c <= 2; c++) {
+        modules[row + r][col + c] = Math.max(Math.abs(r), Math.abs(c)) !== 1;
+      }
+    }
+  }));
+
+  for (let i = 8; i < size - 8; i++) {
+    if (modules[i][6] === null) modules[i][6] = i % 2 === 0;
+    if (modules[6][i] === null) modules[6][i] = i % 2 === 0;
+  }
+
+  const format = bch((LEVEL_M << 3) | mask, 0x537, 15) ^ 0x5412;
+  for (let i = 0; i < 15; i++) {
+    const dark = ((format >> i) & 1) === 1;
+    if (i < 6) modules[i][8] = dark;
+    else if (i < 8) modules[i + 1][8] = dark;
+    else modules[size - 15 + i][8] = dark;
+
+    if (i < 8) modules[8][size - i - 1] = dark;
+    else if (i < 9) modules[8][15 - i] = dark;
+    else modules[8][15 - i - 1] = dark;
+  }
+  modules[size - 8][8] = true;
+
+  if (version >= 7) {
+    const info = bch(version, 0x1f25, 18);
+    for (let i = 0; i < 18; i++) {
+      const dark = ((info >> i) & 1) === 1;
+      modules[Math.floor(i / 3)][(i % 3) + size - 11] = dark;
+      modules[(i % 3) + size - 11][Math.floor(i / 3)] = dark;
+    }
+  }
+
+  // Data goes upwards and downwards in two-module columns from the bottom right corner
+  let bitIndex = 0;
+  let upwards = true;
+  for (let col = size - 1; col > 0; col -= 2) {
+    if (col === 6) col--;
+    for (let step = 0; step < size; step++) {
+      const row = upwards ? size - 1 - step : step;
+      for (let offset = 0; offset < 2; offset++) {
+        const c = col - offset;
+        if (modules[row][c] !== null) continue;
+        const byte = words[bitIndex >> 3];
+        let dark = byte !== undefined && ((byte >> (7 - (bitIndex & 7))) & 1) === 1;
+        if (MASKS[mask](row, c)) dark = !dark;
+        modules[row][c] = dark;
+        bitIndex++;
+      }
+    }
+    upwards = !upwards;
+  }
+  return modules;
+};
+
+// Penalty score of a masked matrix; the mask with the lowest score is used
+const penalty = (modules) => {
+  const size = modules.length;
+  let score = 0;
+
+  const runs = (get) => {
+    for (let i = 0; i < size; i++) {
+      let run = 1;
+      for (let j = 1; j <= size; j++) {
+        if (j < size && get(i, j) === get(i, j - 1)) {
+          run++;
+        } else {
+          if (run >= 5) score += run - 2;
+          run = 1;
+        }
+      }
+    }
+  };
+  runs((i, j) => modules[i][j]);
+  runs((i, j) => modules[j][i]);
+
+  for (let r = 0; r < size - 1; r++) {
+    for (let c = 0; c < size - 1; c++) {
+      const dark = modules[r][c];
+      if (dark === modules[r + 1][c] && dark === modules[r][c + 1] && dark === modules[r + 1][c + 1]) score += 3;
+    }
+  }
+
+  const pattern = [true, false, true, true, true, false, true];
+  const finderLike = (get, i, j) => {
+    if (!pattern.every((dark, k) => get(i, j + k) === dark)) return false;
+    const light = (from) => [0, 1, 2, 3].every(k => from + k < 0 || from + k >= size || !get(i, from + k));
+    return light(j - 4) || light(j + 7);
+  };
+  for (let i = 0; i < size; i++) {
+    for (let j = 0; j + 7 <= size; j++) {
+      if (finderLike((a, b) => modules[a][b], i, j)) score += 40;
+      if (finderLike((a, b) => modules[b][a], i, j)) score += 40;
+    }
+  }
+
+  const dark = modules.reduce((sum, row) => sum + row.filter(Boolean).length, 0);
+  score += Math.floor(Math.abs((dark * 100) / (size * size) - 50) / 5) * 10;
+  return score;
+};
+
+export const qrVersionFor = (length) => {
+  for (let version = 1; version < RS_BLOCKS_M.length; version++) {
+    const capacity = blocksOf(version).reduce((sum, block) => sum + block.data, 0);
+    if (4 + (version < 10 ? 8 : 16) + length * 8 <= capacity * 8) return version;
+  }
+  return 0;
+};
+
+export const qrMatrix = (text, mask) => {
+  const bytes = utf8Bytes(text);
+  const version = qrVersionFor(bytes.length);
+  if (!version) throw new Error('Text is too long for a QR code');
+
+  const words = codewords(bytes, version);
+  if (mask !== undefined) return buildMatrix(version, words, mask);
+
+  let best = null;
+  let bestScore = Infinity;
+  for (let candidate = 0; candidate < MASKS.length; candidate++) {
+    const modules = buildMatrix(version, words, candidate);
+    const score = penalty(modules);
+    if (score < bestScore) {
+      best = modules;
+      bestScore = score;
+    }
+  }
+  return best;
+};